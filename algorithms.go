@@ -3,127 +3,352 @@ package main
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"math/big"
-	"math/bits"
+	"slices"
+	"strings"
 	"sync"
+
+	"github.com/agbruneau/FibJule/fib"
 )
 
 // fibFunc is a type for functions calculating Fibonacci numbers.
-// It takes a context for cancellation, a channel for progress, the index n,
-// and a pool of big.Int objects for memory reuse.
-type fibFunc func(ctx context.Context, progress chan<- progressData, n int, pool *sync.Pool) (*big.Int, error)
+// It takes a context for cancellation, which may also carry a
+// ProgressReporter, the index n, and a pool of big.Int objects for memory reuse.
+type fibFunc func(ctx context.Context, n int, pool *sync.Pool) (*big.Int, error)
 
 // ------------------------------------------------------------
 // Fibonacci Calculation Algorithms
 // ------------------------------------------------------------
-
-// fibFastDoubling calculates F(n) using the "Fast Doubling" algorithm.
-//
-// Concept:
-// A very efficient algorithm based on mathematical identities that allow
-// transitioning from F(k) and F(k+1) to F(2k) and F(2k+1) in a few operations:
-// F(2k)   = F(k) * [2*F(k+1) – F(k)]
-// F(2k+1) = F(k)² + F(k+1)²
 //
-// Implementation:
-// The algorithm iterates through the bits of index `n` from left to right (most
-// significant to least significant). At each step, it applies the "doubling" formulas.
-// If the current bit of `n` is 1, it takes an additional step to advance.
-//
-// Strengths/Weaknesses:
-// Extremely fast and efficient (O(log n) complexity). It's one of the best
-// algorithms for this problem. It heavily uses the `sync.Pool` to optimize
-// `big.Int` allocations.
-func fibFastDoubling(ctx context.Context, progress chan<- progressData, n int, pool *sync.Pool) (*big.Int, error) {
-	taskName := "Fast Doubling" // Used for progress reporting
-	if n < 0 {
-		return nil, fmt.Errorf("negative index n is not supported: %d", n)
-	}
-	if n <= 1 {
-		if progress != nil {
-			progress <- progressData{name: taskName, pct: 100.0}
+// The algorithms themselves live in the `fib` package so they can be imported
+// by other programs. The functions below adapt them to the `fibFunc` signature
+// used by the orchestrator, forwarding progress to the reporter of the context.
+
+// fibFastDoubling calculates F(n) using fib.FastDoubling.
+func fibFastDoubling(ctx context.Context, n int, pool *sync.Pool) (*big.Int, error) {
+	return fib.FastDoubling(ctx, n, fib.WithPool(pool), progressOption(ctx, "Fast Doubling"))
+}
+
+// fibFastDoublingInto calculates F(n) into dst using fib.FastDoublingInto,
+// avoiding the allocation of the result.
+func fibFastDoublingInto(ctx context.Context, dst *big.Int, n int, pool *sync.Pool) error {
+	return fib.FastDoublingInto(ctx, dst, n, fib.WithPool(pool))
+}
+
+// fibFastDoublingPair calculates F(n) and F(n+1) in one pass using fib.FastDoublingPair.
+func fibFastDoublingPair(ctx context.Context, n int, pool *sync.Pool) (*big.Int, *big.Int, error) {
+	return fib.FastDoublingPair(ctx, n, fib.WithPool(pool), progressOption(ctx, "Fast Doubling"))
+}
+
+// fibFastDoublingMod calculates F(n) mod m using fib.FastDoublingMod.
+func fibFastDoublingMod(ctx context.Context, n int, m *big.Int, pool *sync.Pool) (*big.Int, error) {
+	return fib.FastDoublingMod(ctx, n, m, fib.WithPool(pool), progressOption(ctx, "Fast Doubling"))
+}
+
+// fibLucas calculates the Lucas number L(n) using fib.Lucas.
+func fibLucas(ctx context.Context, n int, pool *sync.Pool) (*big.Int, error) {
+	return fib.Lucas(ctx, n, fib.WithPool(pool), progressOption(ctx, "Lucas"))
+}
+
+// fibMemo calculates F(n) with memoized recursion using fib.Memo.
+func fibMemo(ctx context.Context, n int, pool *sync.Pool) (*big.Int, error) {
+	return fib.Memo(ctx, n, fib.WithPool(pool), progressOption(ctx, "Memoized"))
+}
+
+// fibBinet calculates F(n) with Binet's formula using fib.Binet.
+func fibBinet(ctx context.Context, n int, pool *sync.Pool) (*big.Int, error) {
+	return fib.Binet(ctx, n, fib.WithPool(pool), progressOption(ctx, "Binet"))
+}
+
+// fibMatrix calculates F(n) by Q-matrix exponentiation using fib.Matrix.
+func fibMatrix(ctx context.Context, n int, pool *sync.Pool) (*big.Int, error) {
+	return fib.Matrix(ctx, n, fib.WithPool(pool), progressOption(ctx, "Matrix"))
+}
+
+// fibMatrixFast calculates F(n) by symmetric Q-matrix exponentiation using fib.MatrixFast.
+func fibMatrixFast(ctx context.Context, n int, pool *sync.Pool) (*big.Int, error) {
+	return fib.MatrixFast(ctx, n, fib.WithPool(pool), progressOption(ctx, "Matrix Fast"))
+}
+
+// fibTribonacci calculates the tribonacci number T(n) by 3x3 matrix
+// exponentiation using fib.Tribonacci.
+func fibTribonacci(ctx context.Context, n int, pool *sync.Pool) (*big.Int, error) {
+	return fib.Tribonacci(ctx, n, fib.WithPool(pool), progressOption(ctx, "Tribonacci"))
+}
+
+// ------------------------------------------------------------
+// Task Construction from the Configuration
+// ------------------------------------------------------------
+
+// plainAlgorithm and modularAlgorithm are the signatures of the exported
+// algorithms of the `fib` package and of their modular variants.
+type (
+	plainAlgorithm   func(ctx context.Context, n int, opts ...fib.Option) (*big.Int, error)
+	modularAlgorithm func(ctx context.Context, n int, m *big.Int, opts ...fib.Option) (*big.Int, error)
+)
+
+// algorithmOptions derives, from the configuration, the modulus (nil outside
+// modular mode) and the extra options shared by all algorithms.
+func algorithmOptions(cfg config) (*big.Int, []fib.Option) {
+	var m *big.Int
+	if cfg.mod > 0 {
+		m = new(big.Int).SetUint64(cfg.mod)
+	}
+	var opts []fib.Option
+	if cfg.parallelMul {
+		opts = append(opts, fib.WithParallelMultiplication(cfg.parallelThreshold))
+	}
+	if cfg.precisionBits > 0 {
+		opts = append(opts, fib.WithBinetPrecision(uint(cfg.precisionBits)))
+	}
+	return m, opts
+}
+
+// newAlgorithmTask adapts a `fib` algorithm to the fibFunc signature. The pool
+// and the progress reporter of the context given at call time are combined
+// with opts. A non-nil m selects the modular variant.
+func newAlgorithmTask(name string, plain plainAlgorithm, modular modularAlgorithm, m *big.Int, opts ...fib.Option) fibFunc {
+	return func(ctx context.Context, n int, pool *sync.Pool) (*big.Int, error) {
+		all := append([]fib.Option{fib.WithPool(pool), progressOption(ctx, name)}, opts...)
+		if counts, ok := ctx.Value(opCountsKey{}).(*fib.OpCounts); ok {
+			all = append(all, fib.WithOpCounts(counts))
 		}
-		return big.NewInt(int64(n)), nil
-	}
-
-	// Initialize F(k) and F(k+1)
-	// a = F(k), b = F(k+1)
-	a := pool.Get().(*big.Int).SetInt64(0)
-	b := pool.Get().(*big.Int).SetInt64(1)
-	defer pool.Put(a) // Ensure 'a' is returned to the pool when done
-	defer pool.Put(b) // Ensure 'b' is returned to the pool when done
-
-	// Temporary variables for calculations, taken from the pool.
-	t1 := pool.Get().(*big.Int)
-	t2 := pool.Get().(*big.Int)
-	defer pool.Put(t1)
-	defer pool.Put(t2)
-
-	totalBits := bits.Len(uint(n)) // Number of bits in n
-	// Iterate from the most significant bit of n down to the least significant bit
-	for i := totalBits - 1; i >= 0; i-- {
-		// Cooperative context cancellation check
-		select {
-		case <-ctx.Done():
-			return nil, ctx.Err()
-		default:
+		if m != nil {
+			return modular(ctx, n, m, all...)
 		}
+		return plain(ctx, n, all...)
+	}
+}
+
+// fastDoublingTask returns a fibFunc running Fast Doubling with extra options
+// (e.g. parallel multiplication). A non-nil m selects the modular variant.
+func fastDoublingTask(m *big.Int, opts ...fib.Option) fibFunc {
+	return newAlgorithmTask("Fast Doubling", fib.FastDoubling, fib.FastDoublingMod, m, opts...)
+}
+
+// lucasTask returns a fibFunc computing Lucas numbers with extra options.
+// A non-nil m selects the modular variant.
+func lucasTask(m *big.Int, opts ...fib.Option) fibFunc {
+	return newAlgorithmTask("Lucas", fib.Lucas, fib.LucasMod, m, opts...)
+}
+
+// memoTask returns a fibFunc running memoized recursion with extra options.
+// A non-nil m selects the modular variant.
+func memoTask(m *big.Int, opts ...fib.Option) fibFunc {
+	return newAlgorithmTask("Memoized", fib.Memo, fib.MemoMod, m, opts...)
+}
+
+// binetTask returns a fibFunc running Binet's formula with extra options.
+// A non-nil m selects the modular variant.
+func binetTask(m *big.Int, opts ...fib.Option) fibFunc {
+	return newAlgorithmTask("Binet", fib.Binet, fib.BinetMod, m, opts...)
+}
 
-		// Doubling Step:
-		// F(2k)   = F(k) * [2*F(k+1) – F(k)]
-		// F(2k+1) = F(k)² + F(k+1)²
-		//
-		// Current a = F(k), b = F(k+1)
-		// We calculate F(2k) and F(2k+1) and store them in a and b respectively.
-
-		// t1 = 2*F(k+1) - F(k) = 2*b - a
-		t1.Lsh(b, 1)  // t1 = 2*b
-		t1.Sub(t1, a) // t1 = 2*b - a
-
-		// t2 = F(k)^2 = a^2
-		t2.Mul(a, a) // t2 = a*a
-
-		// New a = F(2k) = F(k) * (2*F(k+1) - F(k)) = a * t1
-		a.Mul(a, t1) // a = a * t1
-
-		// t1 = F(k+1)^2 = b^2  (reusing t1)
-		t1.Mul(b, b) // t1 = b*b
-
-		// New b = F(2k+1) = F(k)^2 + F(k+1)^2 = t2 + t1
-		b.Add(t2, t1) // b = t2 + t1 (which is F(k)^2 + F(k+1)^2)
-
-		// If the i-th bit of n is 1, apply the "addition" step:
-		// F(m+1) = F(m) + F(m-1)
-		// Here, if current a=F(2k), b=F(2k+1), and bit is 1, we need F(2k+1), F(2k+2)
-		// New a' = F(2k+1) = b
-		// New b' = F(2k+2) = F(2k) + F(2k+1) = a + b (using OLD a and b from before this if block,
-		// but since a and b are updated to F(2k) and F(2k+1) respectively in this iteration,
-		// it means the new a' = F(2k+1) (which is current b),
-		// and new b' = F(2k+2) = F(2k) + F(2k+1) (which is current a + current b).
-		if (uint(n)>>i)&1 == 1 {
-			// t1 = F(2k) + F(2k+1) (this is the new F(k+1), i.e., F(2k+2))
-			t1.Add(a, b) // t1 = current_a (F(2k)) + current_b (F(2k+1))
-			// a becomes F(2k+1)
-			a.Set(b) // a = current_b (F(2k+1))
-			// b becomes F(2k+2)
-			b.Set(t1) // b = t1 (F(2k+2))
+// binetExactTask returns a fibFunc running the exact, Lucas-based evaluation
+// of Binet's formula with extra options. A non-nil m selects the modular variant.
+func binetExactTask(m *big.Int, opts ...fib.Option) fibFunc {
+	return newAlgorithmTask("Binet Exact", fib.BinetExact, fib.BinetExactMod, m, opts...)
+}
+
+// matrixTask returns a fibFunc running Q-matrix exponentiation with extra
+// options. A non-nil m selects the modular variant.
+func matrixTask(m *big.Int, opts ...fib.Option) fibFunc {
+	return newAlgorithmTask("Matrix", fib.Matrix, fib.MatrixMod, m, opts...)
+}
+
+// matrixFastTask returns a fibFunc running symmetric Q-matrix exponentiation
+// with extra options. A non-nil m selects the modular variant.
+func matrixFastTask(m *big.Int, opts ...fib.Option) fibFunc {
+	return newAlgorithmTask("Matrix Fast", fib.MatrixFast, fib.MatrixFastMod, m, opts...)
+}
+
+// tribonacciTask returns a fibFunc computing tribonacci numbers with extra
+// options. A non-nil m selects the modular variant.
+func tribonacciTask(m *big.Int, opts ...fib.Option) fibFunc {
+	return newAlgorithmTask("Tribonacci", fib.Tribonacci, fib.TribonacciMod, m, opts...)
+}
+
+// quantityTask returns the single task computing the quantity requested by
+// `-sum`, `-sum-squares`, `-seed` or `-recurrence`, which replaces the
+// selected algorithms.
+// ok is false when F(n) itself is requested. A non-nil m selects the modular
+// variant.
+func quantityTask(cfg config, m *big.Int, opts ...fib.Option) (t task, ok bool) {
+	switch {
+	case cfg.sum:
+		return task{name: "Sum", symbol: "ΣF", fn: newAlgorithmTask("Sum", fib.Sum, fib.SumMod, m, opts...)}, true
+	case cfg.sumSquares:
+		return task{name: "Sum of Squares", symbol: "ΣF²", fn: newAlgorithmTask("Sum of Squares", fib.SumSquares, fib.SumSquaresMod, m, opts...)}, true
+	case cfg.seed != "":
+		a, b, _ := parseSeed(cfg.seed) // Validated by parseConfig
+		plain := func(ctx context.Context, n int, opts ...fib.Option) (*big.Int, error) {
+			return fib.Generalized(ctx, n, a, b, opts...)
+		}
+		modular := func(ctx context.Context, n int, m *big.Int, opts ...fib.Option) (*big.Int, error) {
+			return fib.GeneralizedMod(ctx, n, a, b, m, opts...)
+		}
+		return task{name: "Generalized", symbol: "G", fn: newAlgorithmTask("Generalized", plain, modular, m, opts...)}, true
+	case cfg.recurrence != "":
+		coeffs, init, _ := parseRecurrence(cfg.recurrence) // Validated by parseConfig
+		plain := func(ctx context.Context, n int, opts ...fib.Option) (*big.Int, error) {
+			return fib.Recurrence(ctx, n, coeffs, init, opts...)
+		}
+		modular := func(ctx context.Context, n int, m *big.Int, opts ...fib.Option) (*big.Int, error) {
+			return fib.RecurrenceMod(ctx, n, coeffs, init, m, opts...)
+		}
+		return task{name: "Recurrence", symbol: "G", fn: newAlgorithmTask("Recurrence", plain, modular, m, opts...)}, true
+	}
+	return task{}, false
+}
+
+// parseSeed parses the `-seed a,b` value into the starting values G(0) = a
+// and G(1) = b, integers of any size and sign.
+func parseSeed(spec string) (a, b *big.Int, err error) {
+	first, second, found := strings.Cut(spec, ",")
+	if !found {
+		return nil, nil, fmt.Errorf("invalid seed %q (expected a,b, e.g. 2,1)", spec)
+	}
+	a, okA := new(big.Int).SetString(strings.TrimSpace(first), 10)
+	b, okB := new(big.Int).SetString(strings.TrimSpace(second), 10)
+	if !okA || !okB {
+		return nil, nil, fmt.Errorf("invalid seed %q (expected two integers a,b)", spec)
+	}
+	return a, b, nil
+}
+
+// parseRecurrence parses the `-recurrence c1,...,ck[:g0,...,g(k-1)]` value
+// into the coefficients of G(n) = c1·G(n−1) + ... + ck·G(n−k) and its
+// initial terms G(0), ..., G(k−1), integers of any size and sign. Without
+// initial terms, they default to 0, ..., 0, 1, which makes 1,1 the Fibonacci
+// numbers and 1,1,1 the tribonacci numbers.
+func parseRecurrence(spec string) (coeffs, init []*big.Int, err error) {
+	coeffSpec, initSpec, hasInit := strings.Cut(spec, ":")
+	parse := func(list string) ([]*big.Int, error) {
+		var vs []*big.Int
+		for _, field := range strings.Split(list, ",") {
+			v, ok := new(big.Int).SetString(strings.TrimSpace(field), 10)
+			if !ok {
+				return nil, fmt.Errorf("invalid recurrence %q: %q is not an integer (expected c1,...,ck[:g0,...,g(k-1)], e.g. 2,1 or 1,1:2,1)", spec, strings.TrimSpace(field))
+			}
+			vs = append(vs, v)
+		}
+		return vs, nil
+	}
+	if coeffs, err = parse(coeffSpec); err != nil {
+		return nil, nil, err
+	}
+	if !hasInit {
+		init = make([]*big.Int, len(coeffs))
+		for i := range init {
+			init[i] = new(big.Int)
 		}
+		init[len(init)-1].SetInt64(1)
+		return coeffs, init, nil
+	}
+	if init, err = parse(initSpec); err != nil {
+		return nil, nil, err
+	}
+	if len(init) != len(coeffs) {
+		return nil, nil, fmt.Errorf("invalid recurrence %q: %d coefficients need %d initial terms, got %d", spec, len(coeffs), len(coeffs), len(init))
+	}
+	return coeffs, init, nil
+}
 
-		if progress != nil {
-			progress <- progressData{name: taskName, pct: (float64(totalBits-i) / float64(totalBits)) * 100.0}
+// binetOutOfRange reports whether one of tasks is Binet and |n| exceeds
+// fib.BinetThreshold, where its floating-point precision makes it
+// impractically slow.
+func binetOutOfRange(n int, tasks []task) bool {
+	limit := fib.BinetThreshold()
+	if n >= -limit && n <= limit {
+		return false
+	}
+	for _, t := range tasks {
+		if t.name == "Binet" {
+			return true
 		}
 	}
+	return false
+}
+
+// logBinetPrecision logs the precision Binet works at for n when it is one of
+// tasks: the `-precision-bits` override, or the value derived from n, which
+// the verification pass may still double.
+func logBinetPrecision(cfg config, n int, tasks []task) {
+	if !slices.ContainsFunc(tasks, func(t task) bool { return t.name == "Binet" }) {
+		return
+	}
+	if cfg.precisionBits > 0 {
+		slog.Info("binet precision", "bits", cfg.precisionBits, "source", "-precision-bits, single unverified pass")
+		return
+	}
+	slog.Info("binet precision", "bits", fib.BinetPrecision(n), "source", "n·log2(φ)+20, doubled if the verification pass disagrees")
+}
+
+// autoIterativeLimit is the |n| below which `-algorithms auto` picks the
+// iterative method. The fib benchmarks put the crossover with Fast Doubling
+// around n = 40 (≈1.5µs for both): below, n additions of word-sized values
+// cost less than the doubling steps and their pooled temporaries; at n = 100,
+// Fast Doubling is already 25% faster, and 10 times faster at n = 1000.
+const autoIterativeLimit = 40
+
+// autoAlgorithm returns the key of the algorithm `-algorithms auto` runs for
+// n, "iterative" or a registry key, along with the reason for the choice.
+// Binet is never chosen: its precision grows with n, and it is only correct
+// thanks to a verification pass.
+func autoAlgorithm(n int) (key, reason string) {
+	if n > -autoIterativeLimit && n < autoIterativeLimit {
+		return "iterative", fmt.Sprintf("|n| < %d: a few additions cost less than the setup of the doubling steps", autoIterativeLimit)
+	}
+	return "fast", fmt.Sprintf("|n| >= %d: Fast Doubling needs O(log n) steps, the lowest cost of the exact algorithms for large n", autoIterativeLimit)
+}
+
+// autoTask returns the task chosen by autoAlgorithm for n, and the reason
+// for the choice. The iterative method is not registered, since it would make
+// "all" quadratic in n, so its task is built here. A non-nil m selects the
+// modular variant.
+func autoTask(n int, m *big.Int, opts ...fib.Option) (task, string) {
+	key, reason := autoAlgorithm(n)
+	if key == "iterative" {
+		return task{name: "Iterative", symbol: "F", fn: newAlgorithmTask("Iterative", fib.Iterative, fib.IterativeMod, m, opts...)}, reason
+	}
+	available, _ := registeredTasks(m, opts...)
+	return available[key], reason
+}
 
-	if progress != nil {
-		progress <- progressData{name: taskName, pct: 100.0}
+// resolveTasks resolves the `-algorithms` value into tasks for n, like
+// selectTasks over the registered algorithms, except for "auto", which
+// selects the single task chosen by autoTask and logs the choice.
+func resolveTasks(spec string, n int, m *big.Int, opts ...fib.Option) ([]task, error) {
+	if strings.EqualFold(strings.TrimSpace(spec), "auto") {
+		t, reason := autoTask(n, m, opts...)
+		slog.Info("algorithm auto-selected", "n", n, "algorithm", t.name, "reason", reason)
+		return []task{t}, nil
 	}
-	// Return a new instance to avoid returning a pooled object that might be modified.
-	return new(big.Int).Set(a), nil
+	available, defaultOrder := registeredTasks(m, opts...) // See registry.go
+	return selectTasks(spec, available, defaultOrder)
 }
 
-// progressData is defined in utils.go
-// It encapsulates progress information for a task.
-// type progressData struct {
-// 	name string
-// 	pct  float64
-// }
+// opCountsKey is the context key of the operation counters of a task.
+type opCountsKey struct{}
+
+// withOpCounts returns a copy of ctx carrying counts. The algorithms built by
+// newAlgorithmTask then count their operations into it: the context is the
+// only per-call input of a fibFunc that can carry them, since the options are
+// fixed when the task is built.
+func withOpCounts(ctx context.Context, counts *fib.OpCounts) context.Context {
+	return context.WithValue(ctx, opCountsKey{}, counts)
+}
+
+// progressOption returns a fib.Option forwarding progress updates to the
+// ProgressReporter carried by ctx under the given task name. Without one,
+// reporting is disabled.
+func progressOption(ctx context.Context, taskName string) fib.Option {
+	r, ok := progressReporter(ctx)
+	if !ok {
+		return fib.WithProgress(nil)
+	}
+	return fib.WithProgress(func(pct float64) {
+		r.Report(taskName, pct)
+	})
+}
@@ -0,0 +1,22 @@
+//go:build gmp
+
+// algorithms_gmp.go
+
+package main
+
+import (
+	"math/big"
+
+	"github.com/agbruneau/FibJule/fib"
+)
+
+// fastDoublingGMPTask returns a fibFunc running Fast Doubling on GMP
+// integers. A non-nil m selects the modular variant.
+func fastDoublingGMPTask(m *big.Int, opts ...fib.Option) fibFunc {
+	return newAlgorithmTask("Fast Doubling (GMP)", fib.FastDoublingGMP, fib.FastDoublingGMPMod, m, opts...)
+}
+
+// Built with `-tags gmp`, the GMP backend is selectable as `fast-gmp`.
+func init() {
+	register("fast-gmp", "Fast Doubling (GMP)", "F", fastDoublingGMPTask)
+}
@@ -0,0 +1,223 @@
+// batch.go
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/agbruneau/FibJule/fib"
+)
+
+// ------------------------------------------------------------
+// Batch Mode: Indices Read From Stdin (-stdin)
+// ------------------------------------------------------------
+//
+// Concept:
+// Computing thousands of indices with one process per index pays the process
+// startup, and the cold pools and heap, every time. `-stdin` reads the indices
+// from standard input, one per line, and computes them one after the other
+// in a single process: the tasks are built once and each keeps its
+// `sync.Pool` from one index to the next. Each index gets the whole global
+// timeout, and its results are written as soon as they are known.
+
+// runBatch computes every index read from in, one per line, with the
+// algorithms selected by cfg, and writes the results to out: one JSON object
+// per line and index with formatJSON, or with formatCSV a header, then one
+// line per index and algorithm prefixed with n. Blank lines are skipped, and
+// lines that are not an integer, above `-max-n`, or whose term exceeds the
+// `-max-memory` budget, are logged and skipped.
+//
+// The returned status is the highest exit code among the indices (see
+// exitStatus), an invalid line counting as 1. The error reports an
+// interruption of ctx or a failure to read or write.
+func runBatch(ctx context.Context, in io.Reader, out io.Writer, cfg config) (int, error) {
+	m, opts := algorithmOptions(cfg)
+	auto := strings.EqualFold(strings.TrimSpace(cfg.algorithms), "auto")
+	var tasks []task
+	if t, ok := quantityTask(cfg, m, opts...); ok {
+		tasks = []task{t}
+	} else if !auto {
+		var err error
+		if tasks, err = resolveTasks(cfg.algorithms, 0, m, opts...); err != nil {
+			return 1, err
+		}
+	}
+	pools := make(map[string]*sync.Pool)     // By task name: auto may switch tasks
+	budget, _ := memoryBudget(cfg.maxMemory) // Validated by parseConfig
+
+	bw := bufio.NewWriter(out)
+	cw := csv.NewWriter(bw)
+	if cfg.format == formatCSV {
+		cw.Write(append([]string{"n"}, csvHeader(cfg)...))
+	}
+
+	status := exitOK
+	scanner := bufio.NewScanner(in)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		n, err := strconv.Atoi(text)
+		if err != nil {
+			slog.Warn("skipping invalid index", "line", line, "input", text)
+			status = max(status, 1)
+			continue
+		}
+		if err := checkMaxN(cfg, n); err != nil {
+			slog.Warn("skipping index above the maximum index", "line", line, "n", n, "error", err)
+			status = max(status, 1)
+			continue
+		}
+		if err := checkMemoryBudget(indexBytes(cfg, n), budget); err != nil {
+			slog.Warn("skipping index beyond the memory budget", "line", line, "n", n, "error", err)
+			status = max(status, 1)
+			continue
+		}
+		if auto {
+			t, _ := autoTask(n, m, opts...)
+			tasks = []task{t}
+		}
+		taskPools := make([]*sync.Pool, len(tasks))
+		for i, t := range tasks {
+			if pools[t.name] == nil {
+				pools[t.name] = newIntPool()
+			}
+			taskPools[i] = pools[t.name]
+		}
+
+		valueCtx, cancel := context.WithTimeout(ctx, cfg.timeout)
+		results := sortResults(computeTasks(valueCtx, tasks, n, runOptions{
+			sequential:  cfg.sequential,
+			taskTimeout: cfg.perAlgoTimeout,
+			repeat:      cfg.repeat,
+			countOps:    cfg.countOps,
+			concurrency: concurrencyLimit(cfg.concurrency),
+			onlyFastest: cfg.onlyFastest,
+			pools:       taskPools,
+		}))
+		cancel()
+		if ctx.Err() != nil {
+			return status, ctx.Err()
+		}
+
+		if s := exitStatus(results, failOnDiscrepancy(cfg)); s != exitOK {
+			slog.Warn("index without agreeing results", "n", n, "status", s)
+			status = max(status, s)
+		}
+		indexCfg := cfg
+		indexCfg.n = n
+		if cfg.format == formatCSV {
+			for _, r := range results {
+				cw.Write(append([]string{strconv.Itoa(n)}, csvRecord(cfg, r)...))
+			}
+			cw.Flush()
+			err = cw.Error()
+		} else {
+			err = json.NewEncoder(bw).Encode(newJSONReport(indexCfg, results))
+		}
+		// Flush each index, so that a reader of out sees it right away.
+		if err == nil {
+			err = bw.Flush()
+		}
+		if err != nil {
+			return status, err
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return status, err
+	}
+	if err := bw.Flush(); err != nil {
+		return status, err
+	}
+	return status, scanner.Err()
+}
+
+// runStdinMode runs the `-stdin` mode: it computes the indices read from
+// stdin and writes their results to stdout (see runBatch).
+func runStdinMode(cfg config) int {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stopSignals := handleSignals(cancel)
+	defer stopSignals()
+
+	slog.Info("reading indices from stdin", "algorithms", cfg.algorithms, "format", cfg.format, "timeout_per_index", cfg.timeout)
+	status, err := runBatch(ctx, os.Stdin, os.Stdout, cfg)
+	if err != nil {
+		fatal("batch interrupted", "error", err)
+	}
+	slog.Info("batch finished")
+	return status
+}
+
+// ------------------------------------------------------------
+// Batch of Listed Indices (-batch)
+// ------------------------------------------------------------
+//
+// Concept:
+// Unlike `-stdin`, whose indices arrive one by one, `-batch` knows all of them
+// up front, so fib.Batch can share the doubling steps of indices with a
+// common binary prefix, and reach the indices close to another by additions.
+
+// parseIndices parses the comma-separated list of indices of the flag name,
+// `-batch` or `-n-list`.
+func parseIndices(name, spec string) ([]int, error) {
+	var ns []int
+	for _, field := range strings.Split(spec, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			return nil, fmt.Errorf("invalid index %q in -%s (expected a comma-separated list of integers, e.g. 1000,2000,4000)", field, name)
+		}
+		ns = append(ns, n)
+	}
+	return ns, nil
+}
+
+// runBatchListMode runs the `-batch` mode: it writes F(n) for each listed
+// index, one value per line in the order of the list and in the base of
+// `-base`, to stdout or to the `-output` file.
+func runBatchListMode(cfg config) {
+	ns, _ := parseIndices("batch", cfg.batch) // Validated by parseConfig
+
+	w := io.Writer(os.Stdout)
+	if cfg.output != "" {
+		f, err := os.Create(cfg.output)
+		if err != nil {
+			fatal("failed to create output file", "path", cfg.output, "error", err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.timeout)
+	defer cancel()
+	stopSignals := handleSignals(cancel)
+	defer stopSignals()
+
+	slog.Info("computing batch", "indices", len(ns), "timeout", cfg.timeout)
+	values, err := fib.Batch(ctx, ns, fib.WithPool(newIntPool()))
+	if err != nil {
+		fatal("batch interrupted", "error", err)
+	}
+	bw := bufio.NewWriterSize(w, 1<<20)
+	for _, v := range values {
+		if err := writeValueLine(bw, v, cfg.base); err != nil {
+			fatal("failed to write the batch", "error", err)
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		fatal("failed to write the batch", "error", err)
+	}
+	slog.Info("batch finished")
+}
@@ -0,0 +1,108 @@
+// batch_test.go
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestRunBatch pipes several indices, a blank line, an invalid one and one
+// beyond the memory budget through runBatch and checks the output lines in
+// both formats.
+func TestRunBatch(t *testing.T) {
+	const input = "10\n\n-5\nx\n1000000000\n 100 \n"
+
+	cfg, err := parseConfig([]string{"-stdin", "-format", "json", "-algorithms", "fast,matrix", "-max-memory", "1MiB"}, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out strings.Builder
+	status, err := runBatch(context.Background(), strings.NewReader(input), &out, cfg)
+	if err != nil || status != 1 {
+		t.Fatalf("expected status 1 for the skipped lines and no error, got %d (err=%v)", status, err)
+	}
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	want := []struct {
+		n     int
+		value string
+	}{{10, "55"}, {-5, "5"}, {100, "354224848179261915075"}}
+	if len(lines) != len(want) {
+		t.Fatalf("expected %d JSON lines, got %d:\n%s", len(want), len(lines), out.String())
+	}
+	for i, line := range lines {
+		var report jsonReport
+		if err := json.Unmarshal([]byte(line), &report); err != nil {
+			t.Fatalf("line %d is not a JSON object: %v", i+1, err)
+		}
+		if report.N != want[i].n || len(report.Results) != 2 {
+			t.Fatalf("line %d: expected 2 results for n=%d, got %+v", i+1, want[i].n, report)
+		}
+		for _, r := range report.Results {
+			if r.Value != want[i].value {
+				t.Errorf("line %d: expected F(%d) = %s from %s, got %q", i+1, want[i].n, want[i].value, r.Name, r.Value)
+			}
+		}
+	}
+
+	cfg, err = parseConfig([]string{"-stdin", "-format", "csv", "-csv-values"}, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	if status, err := runBatch(context.Background(), strings.NewReader("1\n2\n3\n"), &out, cfg); err != nil || status != exitOK {
+		t.Fatalf("expected status 0 and no error, got %d (err=%v)", status, err)
+	}
+	lines = strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 4 || lines[0] != "n,name,duration_ns,status,digits,value" {
+		t.Fatalf("expected a header and 3 lines, got:\n%s", out.String())
+	}
+	for i, v := range []string{"1", "1", "2"} {
+		fields := strings.Split(lines[i+1], ",")
+		if fields[0] != string(rune('1'+i)) || fields[1] != "Fast Doubling" || fields[len(fields)-1] != v {
+			t.Errorf("unexpected line for n=%d: %s", i+1, lines[i+1])
+		}
+	}
+
+	// A cancelled context stops the batch.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := runBatch(ctx, strings.NewReader("1000\n"), io.Discard, cfg); err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+// TestBatchListMode runs `-batch` into an `-output` file and checks that the
+// values follow the order of the list, duplicates and negative indices
+// included, and match independent computations.
+func TestBatchListMode(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "batch.txt")
+	cfg, err := parseConfig([]string{"-batch", "4000, 10,-7,1000,4000,0,2001", "-output", path}, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	runBatchListMode(cfg)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	ns := []int{4000, 10, -7, 1000, 4000, 0, 2001}
+	if len(lines) != len(ns) {
+		t.Fatalf("expected %d lines, got %d:\n%s", len(ns), len(lines), data)
+	}
+	for i, n := range ns {
+		want, err := fibMatrix(context.Background(), n, newIntPool())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if lines[i] != want.String() {
+			t.Errorf("line %d: expected F(%d) = %s, got %s", i+1, n, abbreviate(want.String()), abbreviate(lines[i]))
+		}
+	}
+}
@@ -0,0 +1,152 @@
+// benchmark.go
+
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ------------------------------------------------------------
+// Benchmark Mode: Sweeping n and Emitting CSV
+// ------------------------------------------------------------
+
+// defaultBenchmarkRuns is the number of recorded runs per data point.
+const defaultBenchmarkRuns = 5
+
+// benchmarkPoint is the timing of one algorithm at one index.
+type benchmarkPoint struct {
+	n         int
+	algorithm string
+	mean      time.Duration
+	stddev    time.Duration
+}
+
+// parseSweep expands a range of the form `start:end:multiplier`, e.g.
+// `1000:1000000:10x`, into the indices start, start*multiplier, ... up to end
+// (inclusive). The trailing `x` of the multiplier is optional.
+func parseSweep(spec string) ([]int, error) {
+	parts := strings.Split(spec, ":")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("invalid benchmark range %q (expected start:end:multiplier, e.g. 1000:1000000:10x)", spec)
+	}
+	start, err := strconv.Atoi(parts[0])
+	if err != nil || start < 1 {
+		return nil, fmt.Errorf("invalid benchmark range start %q (expected a positive integer)", parts[0])
+	}
+	end, err := strconv.Atoi(parts[1])
+	if err != nil || end < start {
+		return nil, fmt.Errorf("invalid benchmark range end %q (expected an integer >= %d)", parts[1], start)
+	}
+	factor, err := strconv.ParseFloat(strings.TrimSuffix(parts[2], "x"), 64)
+	if err != nil || factor <= 1 {
+		return nil, fmt.Errorf("invalid benchmark multiplier %q (expected a number greater than 1)", parts[2])
+	}
+
+	var ns []int
+	for v := float64(start); v <= float64(end); v *= factor {
+		n := int(math.Round(v))
+		// A small multiplier may round to the same index twice.
+		if len(ns) == 0 || n > ns[len(ns)-1] {
+			ns = append(ns, n)
+		}
+	}
+	return ns, nil
+}
+
+// measurePoint runs t at index n once as an unrecorded warmup, then `runs`
+// times, and returns the mean and standard deviation of the recorded runs.
+// The whole data point, warmup included, must complete within timeout.
+func measurePoint(ctx context.Context, t task, n, runs int, timeout time.Duration) (benchmarkPoint, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	pool := newIntPool()
+	if _, err := t.fn(ctx, n, pool); err != nil {
+		return benchmarkPoint{}, err
+	}
+
+	samples := make([]float64, runs)
+	for i := range samples {
+		start := time.Now()
+		if _, err := t.fn(ctx, n, pool); err != nil {
+			return benchmarkPoint{}, err
+		}
+		samples[i] = float64(time.Since(start))
+	}
+
+	mean, stddev := meanStddev(samples)
+	return benchmarkPoint{
+		n:         n,
+		algorithm: t.name,
+		mean:      time.Duration(mean),
+		stddev:    time.Duration(stddev),
+	}, nil
+}
+
+// meanStddev returns the mean and the sample standard deviation of samples,
+// a non-empty slice. The deviation is 0 for a single sample.
+func meanStddev(samples []float64) (mean, stddev float64) {
+	var sum float64
+	for _, s := range samples {
+		sum += s
+	}
+	mean = sum / float64(len(samples))
+	var variance float64
+	if len(samples) > 1 {
+		for _, s := range samples {
+			variance += (s - mean) * (s - mean)
+		}
+		variance /= float64(len(samples) - 1) // Sample variance
+	}
+	return mean, math.Sqrt(variance)
+}
+
+// runBenchmark measures every task at every index of ns and writes one CSV
+// row per data point to w, flushing after each row so partial results survive
+// an interruption. An algorithm that fails or exceeds the timeout at some n
+// is skipped for the larger indices, which would only take longer.
+func runBenchmark(ctx context.Context, w io.Writer, tasks []task, ns []int, runs int, timeout time.Duration) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"n", "algorithm", "mean_ns", "stddev_ns"}); err != nil {
+		return err
+	}
+
+	stopped := make(map[string]bool)
+	for _, n := range ns {
+		for _, t := range tasks {
+			if stopped[t.name] {
+				continue
+			}
+			p, err := measurePoint(ctx, t, n, runs, timeout)
+			if ctx.Err() != nil {
+				cw.Flush()
+				return ctx.Err()
+			}
+			if err != nil {
+				slog.Warn("benchmark point failed, skipping larger indices", "algorithm", t.name, "n", n, "error", err)
+				stopped[t.name] = true
+				continue
+			}
+			slog.Info("benchmark point", "algorithm", p.algorithm, "n", p.n, "mean", p.mean, "stddev", p.stddev)
+			cw.Write([]string{
+				strconv.Itoa(p.n),
+				p.algorithm,
+				strconv.FormatInt(int64(p.mean), 10),
+				strconv.FormatInt(int64(p.stddev), 10),
+			})
+			cw.Flush()
+			if err := cw.Error(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
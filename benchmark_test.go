@@ -0,0 +1,50 @@
+// benchmark_test.go
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestBenchmarkSweep verifies range parsing and the CSV written by runBenchmark.
+func TestBenchmarkSweep(t *testing.T) {
+	ns, err := parseSweep("1000:1000000:10x")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []int{1000, 10000, 100000, 1000000}; !reflect.DeepEqual(ns, want) {
+		t.Errorf("expected %v, got %v", want, ns)
+	}
+	if ns, _ := parseSweep("1:3:1.2"); !reflect.DeepEqual(ns, []int{1, 2, 3}) {
+		t.Errorf("expected duplicate indices to be dropped, got %v", ns)
+	}
+	for _, spec := range []string{"1000", "0:10:2x", "10:5:2x", "1:10:1x", "1:10:ax"} {
+		if _, err := parseSweep(spec); err == nil {
+			t.Errorf("expected an error for range %q, but got none", spec)
+		}
+	}
+
+	tasks := []task{
+		{name: "Fast Doubling", symbol: "F", fn: fibFastDoubling},
+		{name: "Lucas", symbol: "L", fn: fibLucas},
+	}
+	var buf bytes.Buffer
+	if err := runBenchmark(context.Background(), &buf, tasks, []int{10, 100}, 2, time.Minute); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	if len(rows) != 5 || !reflect.DeepEqual(rows[0], []string{"n", "algorithm", "mean_ns", "stddev_ns"}) {
+		t.Fatalf("expected a header and 4 rows, got %v", rows)
+	}
+	if rows[1][0] != "10" || rows[1][1] != "Fast Doubling" || rows[4][0] != "100" || rows[4][1] != "Lucas" {
+		t.Errorf("unexpected row order: %v", rows)
+	}
+}
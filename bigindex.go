@@ -0,0 +1,44 @@
+// bigindex.go
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/big"
+
+	"github.com/agbruneau/FibJule/fib"
+)
+
+// ------------------------------------------------------------
+// Index Beyond the Range of int (-n with -mod)
+// ------------------------------------------------------------
+//
+// Concept:
+// F(10^20) has about 7·10^19 bits and can never be computed, but its residue
+// modulo m can: Fast Doubling takes one step per bit of n, about 67 steps for
+// 10^20, each on numbers below m. `-n` keeps such an index as a *big.Int,
+// accepted only with `-mod`, and this mode computes it with
+// fib.FastDoublingModBig, the single algorithm able to take it.
+
+// runBigIndexMode computes F(n) mod m for an index beyond the range of int,
+// and prints it as `F(n) mod m = r`, or only r with `-format value`.
+func runBigIndexMode(cfg config) {
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.timeout)
+	defer cancel()
+	stopSignals := handleSignals(cancel)
+	defer stopSignals()
+
+	m := new(big.Int).SetUint64(cfg.mod)
+	slog.Info("computing F(n) mod m for an index beyond the range of int", "n", cfg.bigN, "mod", cfg.mod, "bits", cfg.bigN.BitLen())
+	v, err := fib.FastDoublingModBig(ctx, cfg.bigN, m, fib.WithPool(newIntPool()))
+	if err != nil {
+		fatal("failed to compute F(n) mod m", "n", cfg.bigN, "error", err)
+	}
+	if cfg.format == formatValue {
+		fmt.Println(v.Text(cfg.base))
+		return
+	}
+	fmt.Printf("F(%s) mod %d = %s\n", cfg.bigN, cfg.mod, v.Text(cfg.base))
+}
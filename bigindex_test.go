@@ -0,0 +1,54 @@
+// bigindex_test.go
+
+package main
+
+import (
+	"context"
+	"io"
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/agbruneau/FibJule/fib"
+)
+
+// TestBigIndexMode checks the output of F(10^20) mod 10^9+7, which Fast
+// Doubling reaches through the 67 bits of the index, in both formats.
+func TestBigIndexMode(t *testing.T) {
+	for format, want := range map[string]string{
+		formatTable: "F(100000000000000000000) mod 1000000007 = 745064812\n",
+		formatValue: "745064812\n",
+	} {
+		cfg, err := parseConfig([]string{"-n", "100000000000000000000", "-mod", "1000000007", "-format", format}, io.Discard)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := captureStdout(t, func() { runBigIndexMode(cfg) }); got != want {
+			t.Errorf("format %s: expected %q, got %q", format, want, got)
+		}
+	}
+}
+
+// TestBigIndexMinInt checks that -n -2^63, whose absolute value overflows
+// int, goes through the big index path instead of failing.
+func TestBigIndexMinInt(t *testing.T) {
+	cfg, err := parseConfig([]string{"-n", "-9223372036854775808", "-mod", "1000000007", "-format", formatValue}, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.bigN == nil || cfg.bigN.Cmp(big.NewInt(math.MinInt64)) != 0 {
+		t.Fatalf("expected -2^63 to be kept as a big index, got n=%d, bigN=%v", cfg.n, cfg.bigN)
+	}
+	// The Pisano period of 10^9+7 is 2·(10^9+8): F(-2^63) is F(2^63 mod π)
+	// with the negafibonacci sign, 2^63 being even.
+	period := big.NewInt(2 * (1000000007 + 1))
+	k := new(big.Int).Mod(new(big.Int).Neg(cfg.bigN), period)
+	v, err := fib.FastDoublingMod(context.Background(), int(k.Int64()), big.NewInt(1000000007))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := new(big.Int).Mod(v.Neg(v), big.NewInt(1000000007))
+	if got := captureStdout(t, func() { runBigIndexMode(cfg) }); got != want.String()+"\n" {
+		t.Errorf("expected %s, got %q", want, got)
+	}
+}
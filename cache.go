@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/gob"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ------------------------------------------------------------
+// On-Disk Result Cache
+// ------------------------------------------------------------
+//
+// Concept:
+// When scripting many invocations, the same n is often requested repeatedly.
+// With `-cache dir`, each computed value is stored gob-encoded in dir, keyed by
+// the sequence, the index n and the modulus; the key of the G of `-seed` and
+// `-recurrence` also holds its parameters (see sequenceKey). A later run
+// finding every selected sequence in the cache skips the algorithms entirely.
+//
+// Several processes may write the same key at the same time. Each one writes
+// to its own temporary file in dir and then renames it into place, which is
+// atomic on the same filesystem: readers see either the old or the new file,
+// never a partial one.
+
+// cachePath returns the cache file of the given sequence term.
+func cachePath(dir, symbol string, n int, mod uint64) string {
+	name := fmt.Sprintf("%s_%d.gob", symbol, n)
+	if mod > 0 {
+		name = fmt.Sprintf("%s_%d_mod_%d.gob", symbol, n, mod)
+	}
+	return filepath.Join(dir, name)
+}
+
+// sequenceKey returns the symbol identifying the sequence symbol in the
+// cache and in `-save` files: every `-seed` and `-recurrence` is displayed as
+// G, so its parameters are appended, e.g. "G[2,1]" for the seed 2,1 and
+// "G[1,1,1;0,0,1]" for the recurrence 1,1,1:0,0,1.
+func sequenceKey(cfg config, symbol string) string {
+	switch {
+	case cfg.seed != "":
+		a, b, _ := parseSeed(cfg.seed) // Validated by parseConfig
+		return fmt.Sprintf("%s[%s,%s]", symbol, a, b)
+	case cfg.recurrence != "":
+		coeffs, init, _ := parseRecurrence(cfg.recurrence) // Validated by parseConfig
+		return fmt.Sprintf("%s[%s;%s]", symbol, joinInts(coeffs), joinInts(init))
+	}
+	return symbol
+}
+
+// joinInts joins vs with commas.
+func joinInts(vs []*big.Int) string {
+	texts := make([]string, len(vs))
+	for i, v := range vs {
+		texts[i] = v.String()
+	}
+	return strings.Join(texts, ",")
+}
+
+// loadCachedValue reads a cached value. A missing entry is reported with an
+// error satisfying errors.Is(err, os.ErrNotExist).
+func loadCachedValue(dir, symbol string, n int, mod uint64) (*big.Int, error) {
+	f, err := os.Open(cachePath(dir, symbol, n, mod))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	v := new(big.Int)
+	if err := gob.NewDecoder(f).Decode(v); err != nil {
+		return nil, fmt.Errorf("corrupted cache entry %s: %w", f.Name(), err)
+	}
+	return v, nil
+}
+
+// storeCachedValue writes v to the cache atomically (temporary file + rename).
+func storeCachedValue(dir, symbol string, n int, mod uint64, v *big.Int) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, ".tmp-*.gob")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // No-op once the rename succeeded
+
+	if err := gob.NewEncoder(tmp).Encode(v); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), cachePath(dir, symbol, n, mod))
+}
+
+// loadCachedResults looks up every sequence computed by tasks. It returns one
+// cached result per sequence, and ok is true only when all of them were found.
+func loadCachedResults(cfg config, tasks []task) (results []Result, ok bool) {
+	seen := make(map[string]bool)
+	for _, t := range tasks {
+		if seen[t.symbol] {
+			continue
+		}
+		seen[t.symbol] = true
+
+		start := time.Now()
+		v, err := loadCachedValue(cfg.cacheDir, sequenceKey(cfg, t.symbol), cfg.n, cfg.mod)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				slog.Warn("ignoring cache entry", "symbol", t.symbol, "n", cfg.n, "error", err)
+			}
+			return nil, false
+		}
+		results = append(results, Result{Name: t.name, symbol: t.symbol, Value: v, Duration: time.Since(start), cached: true})
+	}
+	return results, true
+}
+
+// storeResultsInCache writes the fastest successful result of each sequence
+// to the cache. Failures are logged but never abort the program.
+func storeResultsInCache(cfg config, results []Result) {
+	stored := make(map[string]bool)
+	for _, r := range results { // Sorted: the fastest success of each sequence comes first
+		if r.Err != nil || r.Value == nil || r.cached || stored[r.symbol] {
+			continue
+		}
+		stored[r.symbol] = true
+		if err := storeCachedValue(cfg.cacheDir, sequenceKey(cfg, r.symbol), cfg.n, cfg.mod, r.Value); err != nil {
+			slog.Warn("failed to cache result", "symbol", r.symbol, "n", cfg.n, "error", err)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+// cache_test.go
+
+package main
+
+import (
+	"errors"
+	"math/big"
+	"os"
+	"sync"
+	"testing"
+)
+
+// TestCacheRoundTrip checks storing and loading values, and that keys with a
+// different sequence or modulus do not collide.
+func TestCacheRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, err := loadCachedValue(dir, "F", 100, 0); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected a miss on an empty cache, got %v", err)
+	}
+
+	want, _ := new(big.Int).SetString("354224848179261915075", 10) // F(100)
+	if err := storeCachedValue(dir, "F", 100, 0, want); err != nil {
+		t.Fatalf("unexpected error storing the value: %v", err)
+	}
+	got, err := loadCachedValue(dir, "F", 100, 0)
+	if err != nil {
+		t.Fatalf("unexpected error loading the value: %v", err)
+	}
+	if got.Cmp(want) != 0 {
+		t.Errorf("expected %s, but got %s", want, got)
+	}
+
+	for _, miss := range []struct {
+		symbol string
+		mod    uint64
+	}{{"L", 0}, {"F", 1000}} {
+		if _, err := loadCachedValue(dir, miss.symbol, 100, miss.mod); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("expected a miss for %s(100) mod %d, got %v", miss.symbol, miss.mod, err)
+		}
+	}
+}
+
+// TestCacheConcurrentWrites checks that concurrent writers of the same key
+// always leave a complete, readable entry and no temporary files behind.
+func TestCacheConcurrentWrites(t *testing.T) {
+	dir := t.TempDir()
+	want := new(big.Int).Lsh(big.NewInt(1), 100000)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := storeCachedValue(dir, "F", 42, 0, want); err != nil {
+				t.Errorf("unexpected error storing the value: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	got, err := loadCachedValue(dir, "F", 42, 0)
+	if err != nil {
+		t.Fatalf("unexpected error loading the value: %v", err)
+	}
+	if got.Cmp(want) != 0 {
+		t.Error("cached value differs from the stored one")
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("expected a single cache file, found %d entries", len(entries))
+	}
+}
+
+// TestLoadCachedResults checks that a hit requires every selected sequence.
+func TestLoadCachedResults(t *testing.T) {
+	cfg := config{n: 10, cacheDir: t.TempDir()}
+	tasks := []task{
+		{name: "Fast Doubling", symbol: "F"},
+		{name: "Lucas", symbol: "L"},
+	}
+	if err := storeCachedValue(cfg.cacheDir, "F", 10, 0, big.NewInt(55)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, ok := loadCachedResults(cfg, tasks); ok {
+		t.Error("expected a miss while L(10) is not cached")
+	}
+	results, ok := loadCachedResults(cfg, tasks[:1])
+	if !ok || len(results) != 1 || !results[0].cached || results[0].Value.Int64() != 55 {
+		t.Errorf("expected a cached F(10) = 55, got ok=%v results=%+v", ok, results)
+	}
+}
+
+// TestCacheSeeds checks that two `-seed` runs sharing a cache directory do not
+// read each other's G(n).
+func TestCacheSeeds(t *testing.T) {
+	dir := t.TempDir()
+	tasks := []task{{name: "Generalized", symbol: "G"}}
+	lucas := config{n: 10, seed: "2,1", cacheDir: dir}
+	storeResultsInCache(lucas, []Result{{Name: "Generalized", symbol: "G", Value: big.NewInt(123)}})
+
+	if results, ok := loadCachedResults(config{n: 10, seed: "3,4", cacheDir: dir}, tasks); ok {
+		t.Errorf("expected a miss for the seed 3,4, got %+v", results)
+	}
+	results, ok := loadCachedResults(config{n: 10, seed: " 2, 1", cacheDir: dir}, tasks)
+	if !ok || len(results) != 1 || results[0].Value.Int64() != 123 {
+		t.Errorf("expected the cached G(10) = 123 of the seed 2,1, got ok=%v results=%+v", ok, results)
+	}
+}
+
+// TestCacheRecurrences checks that `-recurrence` runs sharing a cache
+// directory only read the G(n) of the same recurrence, whether its initial
+// terms are given or not, and never that of a `-seed`.
+func TestCacheRecurrences(t *testing.T) {
+	dir := t.TempDir()
+	tasks := []task{{name: "Recurrence", symbol: "G"}}
+	storeResultsInCache(config{n: 10, seed: "2,1", cacheDir: dir}, []Result{{Name: "Generalized", symbol: "G", Value: big.NewInt(123)}})
+
+	tribonacci := config{n: 10, recurrence: "1,1,1:0,0,1", cacheDir: dir}
+	if results, ok := loadCachedResults(tribonacci, tasks); ok {
+		t.Errorf("expected a miss for the tribonacci numbers, got %+v", results)
+	}
+	storeResultsInCache(tribonacci, []Result{{Name: "Recurrence", symbol: "G", Value: big.NewInt(81)}})
+
+	if results, ok := loadCachedResults(config{n: 10, recurrence: "1,1,1,1", cacheDir: dir}, tasks); ok {
+		t.Errorf("expected a miss for the tetranacci numbers, got %+v", results)
+	}
+	results, ok := loadCachedResults(config{n: 10, recurrence: "1,1,1", cacheDir: dir}, tasks)
+	if !ok || len(results) != 1 || results[0].Value.Int64() != 81 {
+		t.Errorf("expected the cached T(10) = 81, got ok=%v results=%+v", ok, results)
+	}
+}
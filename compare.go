@@ -0,0 +1,181 @@
+// compare.go
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// ------------------------------------------------------------
+// Correctness Matrix (-compare-algorithms)
+// ------------------------------------------------------------
+//
+// Concept:
+// The cross-validation of a normal run compares the algorithms at a single
+// n, and `-selftest` at two. `-compare-algorithms` sweeps every index from
+// 0 to compareMaxN, plus a few negative and larger spot values, with every
+// algorithm of `-selftest`, regardless of timing. For each pair of
+// algorithms computing the same sequence, it records the first index at
+// which they disagree, or at which one of them fails, and prints the pairs
+// as a matrix: one row and one column per algorithm, each cell holding
+// "pass" or that first index.
+
+// compareMaxN is the end of the contiguous sweep of `-compare-algorithms`.
+const compareMaxN = 2000
+
+// compareSpotIndices are the indices checked beyond the contiguous sweep:
+// negafibonacci terms, and terms large enough for the thresholds of the
+// algorithms (Binet's precision, the parallel products) to come into play.
+var compareSpotIndices = []int{-1000, -1, 4096, 10000, 100000}
+
+// compareIndices returns the indices of `-compare-algorithms`, in the order
+// they are computed.
+func compareIndices() []int {
+	indices := make([]int, 0, compareMaxN+1+len(compareSpotIndices))
+	for n := 0; n <= compareMaxN; n++ {
+		indices = append(indices, n)
+	}
+	return append(indices, compareSpotIndices...)
+}
+
+// divergence is the first index at which two algorithms disagree, and why.
+type divergence struct {
+	n      int
+	reason string
+}
+
+// comparison holds the outcome of `-compare-algorithms`: for each pair of
+// tasks computing the same sequence, the first divergence, if any. The pairs
+// are keyed by the indices of the tasks, lowest first.
+type comparison struct {
+	tasks     []task
+	first     map[[2]int]divergence
+	completed int // Number of indices computed before the end or the timeout
+}
+
+// compareAlgorithms computes every index with every task and records the
+// first divergence of each pair. It stops early when ctx expires. The
+// calculations are not logged one by one: the matrix sums them up.
+func compareAlgorithms(ctx context.Context, tasks []task, indices []int) comparison {
+	c := comparison{tasks: tasks, first: make(map[[2]int]divergence)}
+	for _, n := range indices {
+		results := computeTasks(ctx, tasks, n, runOptions{silent: true})
+		if ctx.Err() != nil {
+			break
+		}
+		byName := make(map[string]Result, len(results))
+		for _, r := range results {
+			byName[r.Name] = r
+		}
+		for i := range tasks {
+			for j := i + 1; j < len(tasks); j++ {
+				pair := [2]int{i, j}
+				if _, failed := c.first[pair]; failed || tasks[i].symbol != tasks[j].symbol {
+					continue
+				}
+				a, b := byName[tasks[i].name], byName[tasks[j].name]
+				switch {
+				case a.Err != nil:
+					c.first[pair] = divergence{n, fmt.Sprintf("%s failed: %v", a.Name, a.Err)}
+				case b.Err != nil:
+					c.first[pair] = divergence{n, fmt.Sprintf("%s failed: %v", b.Name, b.Err)}
+				case a.Value.Cmp(b.Value) != 0:
+					c.first[pair] = divergence{n, fmt.Sprintf("%s(%d) differs: %s vs %s", a.symbol, n, abbreviate(a.Value.String()), abbreviate(b.Value.String()))}
+				}
+			}
+		}
+		c.completed++
+	}
+	return c
+}
+
+// writeComparison writes the matrix of each sequence to w, then the first
+// divergence of each failing pair. A sequence computed by a single
+// algorithm has nothing to compare and is only mentioned.
+func writeComparison(w io.Writer, c comparison) error {
+	var symbols []string
+	bySymbol := make(map[string][]int)
+	for i, t := range c.tasks {
+		if _, ok := bySymbol[t.symbol]; !ok {
+			symbols = append(symbols, t.symbol)
+		}
+		bySymbol[t.symbol] = append(bySymbol[t.symbol], i)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, symbol := range symbols {
+		members := bySymbol[symbol]
+		if len(members) < 2 {
+			fmt.Fprintf(tw, "%s: %s is the only algorithm of this sequence, not compared\n", symbol, c.tasks[members[0]].name)
+			continue
+		}
+		header := []string{symbol}
+		for _, i := range members {
+			header = append(header, c.tasks[i].name)
+		}
+		fmt.Fprintln(tw, strings.Join(header, "\t"))
+		for _, i := range members {
+			row := []string{c.tasks[i].name}
+			for _, j := range members {
+				cell := "-"
+				if i != j {
+					cell = "pass"
+					if d, failed := c.first[[2]int{min(i, j), max(i, j)}]; failed {
+						cell = fmt.Sprintf("n=%d", d.n)
+					}
+				}
+				row = append(row, cell)
+			}
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+		fmt.Fprintln(tw)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	for i := range c.tasks {
+		for j := i + 1; j < len(c.tasks); j++ {
+			if d, failed := c.first[[2]int{i, j}]; failed {
+				fmt.Fprintf(w, "First divergence of %s and %s at n=%d: %s\n", c.tasks[i].name, c.tasks[j].name, d.n, d.reason)
+			}
+		}
+	}
+	return nil
+}
+
+// runCompareMode runs the `-compare-algorithms` mode within the global
+// timeout. It exits with exitDiscrepancy on any divergence, and with
+// exitAllFailed when the timeout cuts the sweep short.
+func runCompareMode(cfg config) int {
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.timeout)
+	defer cancel()
+	stopSignals := handleSignals(cancel)
+	defer stopSignals()
+
+	tasks := selfTestTasks()
+	indices := compareIndices()
+	slog.Info("comparing algorithms", "algorithms", len(tasks), "indices", len(indices), "timeout", cfg.timeout)
+	c := compareAlgorithms(ctx, tasks, indices)
+
+	fmt.Printf("Compared %d algorithms over %d indices (0 to %d and %s)\n\n", len(tasks), c.completed, compareMaxN, strings.Trim(fmt.Sprint(compareSpotIndices), "[]"))
+	if err := writeComparison(os.Stdout, c); err != nil {
+		fatal("failed to write the comparison", "error", err)
+	}
+	switch {
+	case len(c.first) > 0:
+		slog.Error("algorithms disagree", "pairs", len(c.first))
+		return exitDiscrepancy
+	case c.completed < len(indices):
+		slog.Error("comparison interrupted", "completed", c.completed, "indices", len(indices), "error", ctx.Err())
+		return exitAllFailed
+	}
+	slog.Info("all algorithms agree")
+	return exitOK
+}
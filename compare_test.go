@@ -0,0 +1,55 @@
+// compare_test.go
+
+package main
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// TestCompareAlgorithms compares the real algorithms, which must agree,
+// then a task going wrong from n = 5 on, which must be reported at exactly
+// that index against each of the others, and only there.
+func TestCompareAlgorithms(t *testing.T) {
+	ctx := context.Background()
+	indices := []int{0, 1, 2, 3, 4, 5, 6, 7, -3}
+	if c := compareAlgorithms(ctx, selfTestTasks(), indices); len(c.first) != 0 || c.completed != len(indices) {
+		t.Fatalf("expected the algorithms to agree on all %d indices, got %d divergences after %d", len(indices), len(c.first), c.completed)
+	}
+
+	broken := func(ctx context.Context, n int, pool *sync.Pool) (*big.Int, error) {
+		v, err := fibFastDoubling(ctx, n, pool)
+		if err == nil && n >= 5 {
+			v.Add(v, big.NewInt(1))
+		}
+		return v, err
+	}
+	tasks := []task{
+		{name: "Fast Doubling", symbol: "F", fn: fibFastDoubling},
+		{name: "Broken", symbol: "F", fn: broken},
+		{name: "Matrix", symbol: "F", fn: fibMatrix},
+		{name: "Lucas", symbol: "L", fn: fibLucas},
+	}
+	c := compareAlgorithms(ctx, tasks, indices)
+	if len(c.first) != 2 || c.first[[2]int{0, 1}].n != 5 || c.first[[2]int{1, 2}].n != 5 {
+		t.Errorf("expected Broken to diverge from the others at n=5, got %v", c.first)
+	}
+
+	var out strings.Builder
+	if err := writeComparison(&out, c); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"Broken         n=5            -       n=5",
+		"Matrix         pass           n=5     -",
+		"L: Lucas is the only algorithm of this sequence",
+		"First divergence of Fast Doubling and Broken at n=5: F(5) differs: 5 vs 6",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected %q in the comparison, got:\n%s", want, out.String())
+		}
+	}
+}
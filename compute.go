@@ -0,0 +1,168 @@
+// compute.go
+
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+	"math/big"
+	"slices"
+	"time"
+
+	"github.com/agbruneau/FibJule/fib"
+)
+
+// ------------------------------------------------------------
+// Computation Entry Point
+// ------------------------------------------------------------
+//
+// Compute runs the selected algorithms and returns their results instead of
+// printing them. The CLI goes through the same computeTasks and only formats
+// what it returns, so the calculation can be tested without capturing stdout.
+
+// ComputeOption configures Compute.
+type ComputeOption func(*computeConfig)
+
+// computeConfig holds the settings of Compute.
+type computeConfig struct {
+	algorithms string       // Comma-separated algorithm names, or "all"
+	mod        uint64       // Modulus, 0 to compute the full values
+	algoOpts   []fib.Option // Options passed to every algorithm
+	cache      *ResultCache // In-memory cache of the values, nil when disabled
+	run        runOptions
+}
+
+// WithAlgorithms selects the algorithms to run, with the syntax of the
+// `-algorithms` flag. Compute runs "fast" only by default.
+func WithAlgorithms(spec string) ComputeOption {
+	return func(c *computeConfig) { c.algorithms = spec }
+}
+
+// WithModulus makes the algorithms compute F(n) mod m. 0 disables it.
+func WithModulus(m uint64) ComputeOption {
+	return func(c *computeConfig) { c.mod = m }
+}
+
+// WithAlgorithmOptions passes opts, such as fib.WithParallelMultiplication,
+// to every algorithm.
+func WithAlgorithmOptions(opts ...fib.Option) ComputeOption {
+	return func(c *computeConfig) { c.algoOpts = append(c.algoOpts, opts...) }
+}
+
+// WithSequential runs the algorithms one after the other instead of concurrently.
+func WithSequential() ComputeOption {
+	return func(c *computeConfig) { c.run.sequential = true }
+}
+
+// WithConcurrency runs at most k algorithms at once, like `-concurrency`.
+// By default, all of them run concurrently.
+func WithConcurrency(k int) ComputeOption {
+	return func(c *computeConfig) { c.run.concurrency = k }
+}
+
+// WithOnlyFastest stops the other algorithms as soon as one succeeds, like
+// `-only-fastest`.
+func WithOnlyFastest() ComputeOption {
+	return func(c *computeConfig) { c.run.onlyFastest = true }
+}
+
+// WithTaskTimeout gives each algorithm its own deadline, like `-per-algo-timeout`.
+func WithTaskTimeout(d time.Duration) ComputeOption {
+	return func(c *computeConfig) { c.run.taskTimeout = d }
+}
+
+// WithResultCache answers from cache the algorithms whose value of F(n) it
+// holds, with a near-zero duration and flagged as cached, and stores the
+// values the others compute. The same cache can serve many calls, provided
+// they pass identical WithAlgorithmOptions: the cache keys a value by n, the
+// modulus and the algorithm only, so a call with other options, such as
+// another fib.WithBinetPrecision, would get the values computed with the
+// first ones.
+func WithResultCache(cache *ResultCache) ComputeOption {
+	return func(c *computeConfig) { c.cache = cache }
+}
+
+// Compute calculates F(n) (or L(n) for Lucas) with the selected algorithms
+// and returns their results, sorted as by sortResults. An algorithm failing
+// or timing out is reported in its Result; the error is only set when the
+// options are invalid, e.g. an unknown algorithm name.
+func Compute(ctx context.Context, n int, opts ...ComputeOption) ([]Result, error) {
+	c := computeConfig{algorithms: "fast"}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	var m *big.Int
+	if c.mod > 0 {
+		m = new(big.Int).SetUint64(c.mod)
+	}
+	tasks, err := resolveTasks(c.algorithms, n, m, c.algoOpts...)
+	if err != nil {
+		return nil, err
+	}
+	hits, tasks := c.cache.lookup(tasks, n, c.mod)
+	if c.run.onlyFastest && len(hits) > 0 {
+		// A cached value has already won the race: the others do not start.
+		for _, t := range tasks {
+			hits = append(hits, Result{Name: t.name, symbol: t.symbol, Err: context.Canceled, outrun: true})
+		}
+		return sortResults(hits), nil
+	}
+	results := computeTasks(ctx, tasks, n, c.run)
+	c.cache.store(results, n, c.mod)
+	return sortResults(append(hits, results...)), nil
+}
+
+// computeTasks runs the tasks with runTasks and returns their results in
+// completion order.
+//
+// With opts.onlyFastest, the results are collected while the tasks run, and
+// the first success cancels a context shared by all of them: the others stop
+// at their next cancellation check and are reported as outrun. runTasks still
+// waits for every task, so no goroutine outlives the call, and each task
+// hands its pooled values back on the way out as on any cancellation.
+func computeTasks(ctx context.Context, tasks []task, n int, opts runOptions) []Result {
+	resultsCh := make(chan Result, len(tasks)) // One slot per task, so senders never block
+	raceCtx, cancelRace := context.WithCancel(ctx)
+	defer cancelRace()
+	go func() {
+		runTasks(raceCtx, tasks, n, resultsCh, opts)
+		close(resultsCh)
+	}()
+
+	results := make([]Result, 0, len(tasks))
+	for r := range resultsCh {
+		if opts.onlyFastest && r.Err == nil {
+			cancelRace()
+		}
+		// Only the race can have cancelled a task while ctx is still alive.
+		r.outrun = errors.Is(r.Err, context.Canceled) && ctx.Err() == nil
+		results = append(results, r)
+	}
+	return results
+}
+
+// computeWithRetries runs computeTasks under the global timeout and, when
+// that timeout expires before any task succeeds, runs them again with the
+// timeout multiplied by factor, up to retries more times. An interrupt, or a
+// single success, ends the attempts. It returns the results of the last
+// attempt along with its context, whose error tells the global timeout apart
+// in logTaskFailure.
+func computeWithRetries(parent context.Context, tasks []task, n int, opts runOptions, timeout time.Duration, retries int, factor float64) ([]Result, context.Context) {
+	for attempt := 0; ; attempt++ {
+		ctx, cancel := context.WithTimeout(parent, timeout)
+		results := computeTasks(ctx, tasks, n, opts)
+		cancel()
+		if attempt == retries || ctx.Err() != context.DeadlineExceeded || slices.ContainsFunc(results, func(r Result) bool { return r.Err == nil }) {
+			return results, ctx
+		}
+
+		if next := float64(timeout) * factor; next < math.MaxInt64 {
+			timeout = time.Duration(next)
+		} else {
+			timeout = math.MaxInt64
+		}
+		slog.Warn("global timeout exceeded with no result, retrying with a larger timeout", "retry", attempt+1, "of", retries, "timeout", timeout)
+	}
+}
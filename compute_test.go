@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestCompute checks that Compute returns the values of the selected
+// algorithms, reduced modulo m when requested, without printing anything.
+func TestCompute(t *testing.T) {
+	results, err := Compute(context.Background(), 100, WithAlgorithms("fast,matrix,lucas"), WithSequential())
+	if err != nil {
+		t.Fatalf("Compute failed: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(results))
+	}
+	f100, _ := new(big.Int).SetString("354224848179261915075", 10)
+	l100, _ := new(big.Int).SetString("792070839848372253127", 10)
+	for _, r := range results {
+		want := f100
+		if r.Name == "Lucas" {
+			want = l100
+		}
+		if r.Err != nil || r.Value.Cmp(want) != 0 {
+			t.Errorf("%s: got %v (error %v), expected %v", r.Name, r.Value, r.Err, want)
+		}
+	}
+
+	results, err = Compute(context.Background(), 100, WithModulus(1000))
+	if err != nil || len(results) != 1 || results[0].Value.Int64() != 75 {
+		t.Errorf("Compute mod 1000: got %+v, %v, expected F(100) mod 1000 = 75", results, err)
+	}
+}
+
+// TestComputeErrors checks that invalid options are reported as an error and
+// that a calculation failure is reported in the Result instead.
+func TestComputeErrors(t *testing.T) {
+	if _, err := Compute(context.Background(), 10, WithAlgorithms("nope")); err == nil {
+		t.Error("expected an error for an unknown algorithm")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results, err := Compute(ctx, 1000000, WithTaskTimeout(time.Second))
+	if err != nil {
+		t.Fatalf("Compute failed: %v", err)
+	}
+	if len(results) != 1 || results[0].Err != context.Canceled {
+		t.Errorf("expected a cancelled result, got %+v", results)
+	}
+}
+
+// TestComputeTasksOnlyFastest checks that with -only-fastest the first
+// success cancels a slow task, which is reported as outrun, and that without
+// it the slow task runs to completion.
+func TestComputeTasksOnlyFastest(t *testing.T) {
+	fast := func(ctx context.Context, n int, pool *sync.Pool) (*big.Int, error) {
+		return big.NewInt(int64(n)), nil
+	}
+	slow := func(ctx context.Context, n int, pool *sync.Pool) (*big.Int, error) {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(200 * time.Millisecond):
+			return big.NewInt(int64(n)), nil
+		}
+	}
+	tasks := []task{{name: "Slow", symbol: "F", fn: slow}, {name: "Fast", symbol: "F", fn: fast}}
+
+	start := time.Now()
+	results := computeTasks(context.Background(), tasks, 7, runOptions{onlyFastest: true})
+	if elapsed := time.Since(start); elapsed >= 200*time.Millisecond {
+		t.Errorf("expected the slow task to be cancelled, but the run took %v", elapsed)
+	}
+	if len(results) != 2 || results[0].Name != "Fast" || results[0].Err != nil {
+		t.Fatalf("expected the fast result first, got %+v", results)
+	}
+	if r := results[1]; r.Err != context.Canceled || !r.outrun || resultStatus(r) != "Cancelled" {
+		t.Errorf("expected the slow task to be outrun, got %+v", r)
+	}
+
+	for _, r := range computeTasks(context.Background(), tasks, 7, runOptions{}) {
+		if r.Err != nil || r.outrun {
+			t.Errorf("%s: expected every task to complete without -only-fastest, got %+v", r.Name, r)
+		}
+	}
+
+	// A cancellation of the caller is not a race: nothing is outrun.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, r := range computeTasks(ctx, tasks[:1], 7, runOptions{onlyFastest: true}) {
+		if r.Err != context.Canceled || r.outrun {
+			t.Errorf("expected a plain cancellation, got %+v", r)
+		}
+	}
+}
+
+// TestComputeWithRetries checks that a global timeout too short for the
+// task is retried with a larger one until the task succeeds, and that
+// without retries the timeout is reported.
+func TestComputeWithRetries(t *testing.T) {
+	var attempts atomic.Int32
+	task20ms := func(ctx context.Context, n int, pool *sync.Pool) (*big.Int, error) {
+		attempts.Add(1)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(20 * time.Millisecond):
+			return big.NewInt(int64(n)), nil
+		}
+	}
+	tasks := []task{{name: "Slow", symbol: "F", fn: task20ms}}
+
+	results, ctx := computeWithRetries(context.Background(), tasks, 7, runOptions{}, time.Millisecond, 3, 10)
+	if len(results) != 1 || results[0].Err != nil || ctx.Err() != context.Canceled {
+		t.Fatalf("expected a success within the retries, got %+v (context: %v)", results, ctx.Err())
+	}
+	if got := attempts.Load(); got != 3 { // 1ms, 10ms, then 100ms
+		t.Errorf("expected 3 attempts, got %d", got)
+	}
+
+	attempts.Store(0)
+	results, ctx = computeWithRetries(context.Background(), tasks, 7, runOptions{}, time.Millisecond, 0, 10)
+	if len(results) != 1 || results[0].Err != context.DeadlineExceeded || ctx.Err() != context.DeadlineExceeded {
+		t.Errorf("expected the global timeout without retries, got %+v (context: %v)", results, ctx.Err())
+	}
+	if got := attempts.Load(); got != 1 {
+		t.Errorf("expected a single attempt, got %d", got)
+	}
+}
+
+// recordingReporter is a ProgressReporter keeping the last percentage
+// reported by each task, as a library user's UI would.
+type recordingReporter struct {
+	mu   sync.Mutex
+	last map[string]float64
+}
+
+func (r *recordingReporter) Report(task string, pct float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.last[task] = pct
+}
+
+// TestComputeProgressReporter checks that the algorithms report their
+// progress to the ProgressReporter of the context, and run unchanged without
+// one.
+func TestComputeProgressReporter(t *testing.T) {
+	rec := &recordingReporter{last: make(map[string]float64)}
+	ctx := WithProgressReporter(context.Background(), rec)
+	results, err := Compute(ctx, 10000, WithAlgorithms("fast,matrix,lucas"))
+	if err != nil || len(results) != 3 {
+		t.Fatalf("unexpected results %v (err=%v)", results, err)
+	}
+	for _, name := range []string{"Fast Doubling", "Matrix", "Lucas"} {
+		if pct, ok := rec.last[name]; !ok || pct != 100 {
+			t.Errorf("%s: expected a last report of 100%%, got %v (reported: %v)", name, pct, ok)
+		}
+	}
+
+	if results, err := Compute(context.Background(), 10000); err != nil || results[0].Err != nil {
+		t.Errorf("expected Compute to run without a reporter, got %v (err=%v)", results, err)
+	}
+}
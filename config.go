@@ -0,0 +1,650 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"os"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/agbruneau/FibJule/fib"
+)
+
+// ------------------------------------------------------------
+// Command-Line Configuration
+// ------------------------------------------------------------
+
+// Output formats accepted by the `-format` flag.
+const (
+	formatTable = "table" // Human-readable table with progress animation (default)
+	formatJSON  = "json"  // Single JSON object on stdout, no progress animation
+	formatCSV   = "csv"   // Header row and one line per algorithm on stdout, no progress animation
+
+	formatProtobuf = "protobuf" // Length-delimited Report message (report.proto) on stdout or -output
+	formatValue    = "value"    // The bare value of the winning result, for shell command substitution
+	formatRaw      = "raw"      // Sign byte and big-endian bytes of the winning result, written to -output
+)
+
+// config gathers the validated command-line options of the program.
+type config struct {
+	n              int           // Index of the Fibonacci term to compute
+	bigN           *big.Int      // Index beyond the range of int, allowed with -mod only; n is then 0
+	timeout        time.Duration // Global maximum execution time
+	perAlgoTimeout time.Duration // Timeout of each algorithm taken separately, 0 when disabled
+	perDigit       time.Duration // Timeout per estimated digit of F(n), capped by timeout; 0 when disabled
+	retries        int           // Extra attempts after a global timeout with no success
+	retryFactor    float64       // Multiplier of the global timeout at each retry
+
+	algorithms string // Comma-separated algorithm names, or "all"
+	mod        uint64 // Modulus for modular mode, 0 when disabled
+	format     string // Output format: formatTable, formatJSON, formatCSV, formatProtobuf, formatValue or formatRaw
+	csvValues  bool   // Include the full value of the results in the CSV report
+	output     string // File receiving the full value, empty when disabled
+	base       int    // Radix used to display and write the value (2 to 36)
+	sciDigits  int    // Digits after the point of the scientific notation, 0 for the order of magnitude
+
+	sum        bool   // Compute F(0)+...+F(n) instead of F(n)
+	sumSquares bool   // Compute F(0)²+...+F(n)² instead of F(n)
+	seed       string // Starting values a,b of a generalized sequence, empty for F(n)
+	recurrence string // Coefficients c1,...,ck[:initial terms] of a linear recurrence, empty for F(n)
+
+	digitsOnly     bool // Report only the number of decimal digits of the results
+	estimateDigits bool // Estimate the digit count of F(n) analytically, without computing it
+	head           int  // Number of leading digits of F(n) to print, 0 when disabled
+	tail           int  // Number of trailing digits of F(n) to print, 0 when disabled
+
+	isFib          string // Integer tested for membership in the sequence, empty when disabled
+	zeckendorf     string // Integer decomposed into non-consecutive Fibonacci numbers, empty when disabled
+	indexOf        string // Integer whose nearest Fibonacci number is looked for, empty when disabled
+	crt            string // Comma-separated primes for F(n) mod p, empty when disabled
+	crtReconstruct bool   // Rebuild F(n) from the -crt residues
+	ratio          bool   // Print F(n+1)/F(n) and its distance to the golden ratio
+	word           int    // Index of the Fibonacci word to write, -1 when disabled
+
+	logFormat string // Log format on stderr: logFormatText or logFormatJSON
+	quiet     bool   // Keep only warnings and errors in the logs, and only the result on stdout
+
+	cacheDir  string // Directory of the on-disk result cache, empty when disabled
+	cacheSize int    // Capacity of the in-memory result cache of -serve, 0 when disabled
+	verify    bool   // Check F(n) results against the slow iterative reference
+	checkGCD  int    // Index m of the check gcd(F(m), F(n)) = F(gcd(m, n)), 0 when disabled
+
+	save        string // File receiving the winning result, empty when disabled
+	compareWith string // File of a saved result the winning one must equal, empty when disabled
+
+	noValidate            bool // Skip the cross-validation of the results
+	continueOnDiscrepancy bool // Report a discrepancy without a non-zero exit status
+
+	sequential  bool   // Run the selected algorithms one at a time
+	gcBetween   bool   // Collect garbage between sequential algorithms
+	concurrency int    // Maximum number of algorithms running at once, 0 for GOMAXPROCS
+	gomaxprocs  int    // GOMAXPROCS set at startup, 0 to keep the default
+	onlyFastest bool   // Stop the other algorithms once one succeeds
+	repeat      int    // Number of executions of each algorithm
+	warmup      int    // Index computed once per algorithm before timing, 0 when disabled
+	countOps    bool   // Count the big-integer multiplications and additions of each algorithm
+	barWidth    int    // Number of cells of each progress bar, 0 to show only percentages
+	progress    string // Progress display mode: progressAuto, progressAlways, progressLog or progressNever
+	progressFD  int    // File descriptor receiving progress as JSON Lines events, 0 when disabled
+	maxMemory   string // Memory budget of a calculation, e.g. "8GiB"; empty for half the physical memory, "0" disables
+	maxN        int    // Largest |index| accepted, from -max-n or FIBJULE_MAX_N, 0 for no limit
+	maxNSource  string // Origin of maxN in error messages: "-max-n" or maxNEnv
+
+	serve   string // Address served by server mode, empty when disabled
+	connect string // Server address of client mode, empty when disabled
+
+	rangeSpec         string // Range a:b of indices written by range mode, empty when disabled
+	selftest          bool   // Check every algorithm against known values and exit
+	compareAlgorithms bool   // Compare every pair of algorithms over a sweep of indices and exit
+	stdin             bool   // Batch mode: compute the indices read from stdin
+	batch             string // Comma-separated indices computed together, empty when disabled
+	nList             string // Comma-separated indices computed separately and concurrently, empty when disabled
+
+	benchmark     string // Range of indices swept in benchmark mode, empty when disabled
+	benchmarkRuns int    // Recorded runs per benchmark data point
+
+	parallelMul       bool // Run the independent products of Fast Doubling concurrently
+	parallelThreshold int  // Operand size, in bits, above which products run in parallel
+
+	precisionBits int // Fixed precision of Binet, in bits, 0 to derive it from n
+
+	cpuProfile string // File receiving the CPU profile, empty when disabled
+	memProfile string // File receiving the heap profile at the end of the run, empty when disabled
+	trace      string // File receiving the execution trace, empty when disabled
+}
+
+// parseConfig parses and validates the command-line arguments (without the
+// program name). Keeping it separate from `main` allows testing flag handling
+// without touching the global flag.CommandLine.
+func parseConfig(args []string, errOutput io.Writer) (config, error) {
+	var cfg config
+
+	fs := flag.NewFlagSet("fibapp", flag.ContinueOnError)
+	fs.SetOutput(errOutput)
+	cfg.n = 100000
+	fs.Var(indexFlag{&cfg.n, &cfg.bigN}, "n", "Index n of the Fibonacci term (negative values give negafibonacci numbers; beyond the range of `int`, such as 10^20 written in full, with -mod only)")
+	fs.DurationVar(&cfg.timeout, "timeout", 1*time.Minute, "Global maximum execution time")
+	fs.DurationVar(&cfg.perDigit, "timeout-per-digit", 0, "Derive the global timeout from n: this duration times the estimated digit count of F(n), at most -timeout (0 disables)")
+	fs.DurationVar(&cfg.perAlgoTimeout, "per-algo-timeout", 0, "Maximum execution time of each algorithm taken separately (0 disables)")
+	fs.IntVar(&cfg.retries, "retries", 0, "Number of times the calculation is run again, with a larger timeout, when the global timeout expires before any algorithm succeeds")
+	fs.Float64Var(&cfg.retryFactor, "retry-factor", 2, "Multiplier applied to the global timeout at each retry (see -retries)")
+	fs.StringVar(&cfg.algorithms, "algorithms", "fast", "Comma-separated algorithms to run: '"+strings.Join(registeredNames(), "', '")+"', or 'all'; 'auto' picks the best one for n")
+	fs.Uint64Var(&cfg.mod, "mod", 0, "Compute F(n) modulo m (0 disables modular mode)")
+	fs.StringVar(&cfg.format, "format", formatTable, "Output format: 'table', 'json', 'csv', 'protobuf', 'value' (only the number, in -base) or 'raw' (sign byte and big-endian bytes of the value, written to -output)")
+	fs.BoolVar(&cfg.csvValues, "csv-values", false, "Add a column with the full value of each result to the CSV report")
+	fs.StringVar(&cfg.logFormat, "log-format", logFormatText, "Format of the logs written to stderr: 'text' or 'json'")
+	fs.BoolVar(&cfg.quiet, "quiet", false, "Print only the result: no informational logs, no progress, and in table format no table, only the details of the result")
+	fs.StringVar(&cfg.output, "output", "", "Write the full value of the result to this file")
+	fs.IntVar(&cfg.base, "base", 10, "Radix used to display and write the result (2 to 36)")
+	fs.IntVar(&cfg.sciDigits, "sci-digits", defaultSciDigits, "Digits after the point of the scientific notation of large values (0 prints only the order of magnitude)")
+	fs.BoolVar(&cfg.parallelMul, "parallel-mul", false, "Run the independent multiplications of Fast Doubling in parallel goroutines")
+	fs.IntVar(&cfg.parallelThreshold, "parallel-mul-threshold", fib.DefaultParallelThreshold, "Operand size in bits above which -parallel-mul kicks in")
+	fs.IntVar(&cfg.precisionBits, "precision-bits", 0, "Precision of Binet in bits, in a single unverified pass (0 derives it from n: n·log2(φ)+20, verified)")
+	fs.StringVar(&cfg.cacheDir, "cache", "", "Directory of an on-disk cache of computed results")
+	fs.IntVar(&cfg.cacheSize, "cache-size", defaultResultCacheSize, "Number of values the -serve server keeps in memory to answer repeated requests, the least recently used being evicted (0 disables)")
+	fs.BoolVar(&cfg.verify, "verify", false, "Verify F(n) results against an independent O(n) iterative reference (slow for large n)")
+	fs.IntVar(&cfg.checkGCD, "check-gcd", 0, "Check gcd(F(m), F(n)) = F(gcd(m, n)) for this m against the F(n) results, print pass or fail, and exit with status 1 on failure (0 disables)")
+	fs.StringVar(&cfg.save, "save", "", "Save the winning result (gob-encoded, with its sequence, n and modulus) to this file")
+	fs.StringVar(&cfg.compareWith, "compare-with", "", "Compare the winning result with the one saved in this file by -save, and exit with status 4 if they differ")
+	fs.BoolVar(&cfg.noValidate, "no-validate", false, "Skip the cross-validation of results computing the same sequence")
+	fs.BoolVar(&cfg.continueOnDiscrepancy, "continue-on-discrepancy", false, "Report results that disagree without exiting with a non-zero status")
+	fs.BoolVar(&cfg.sequential, "sequential", false, "Run the selected algorithms one at a time instead of concurrently")
+	fs.IntVar(&cfg.concurrency, "concurrency", 0, fmt.Sprintf("Maximum number of algorithms running at the same time, the others waiting for a free slot (0 uses GOMAXPROCS, %d here)", runtime.GOMAXPROCS(0)))
+	fs.IntVar(&cfg.gomaxprocs, "gomaxprocs", 0, "Set GOMAXPROCS, the number of OS threads running Go code at once, at startup (0 keeps the default, the number of usable CPUs)")
+	fs.BoolVar(&cfg.onlyFastest, "only-fastest", false, "Race mode: cancel the other algorithms as soon as one returns a result")
+	fs.BoolVar(&cfg.gcBetween, "gc-between", false, "Run the garbage collector and release memory to the OS before each algorithm (requires -sequential)")
+	fs.BoolVar(&cfg.sum, "sum", false, "Compute the sum F(0)+F(1)+...+F(n) = F(n+2)-1 instead of F(n)")
+	fs.BoolVar(&cfg.sumSquares, "sum-squares", false, "Compute the sum of squares F(0)²+F(1)²+...+F(n)² = F(n)·F(n+1) instead of F(n)")
+	fs.StringVar(&cfg.seed, "seed", "", "Compute G(n) for the sequence starting with G(0)=a, G(1)=b (e.g. 2,1 for Lucas numbers) instead of F(n)")
+	fs.StringVar(&cfg.recurrence, "recurrence", "", "Compute G(n) for G(n)=c1·G(n-1)+...+ck·G(n-k), given as c1,...,ck[:G(0),...,G(k-1)] (initial terms 0,...,0,1 by default; e.g. 2,1 for Pell numbers) instead of F(n)")
+	fs.BoolVar(&cfg.digitsOnly, "digits-only", false, "Report only the number of decimal digits of the results, not their value")
+	fs.BoolVar(&cfg.estimateDigits, "estimate-digits", false, "Print the digit count of F(n) from Binet's formula, without computing F(n)")
+	fs.IntVar(&cfg.head, "head", 0, "Print the first k digits of F(n), derived from n·log10(φ) without computing F(n) (0 disables)")
+	fs.IntVar(&cfg.tail, "tail", 0, "Print the last k digits of F(n), computed exactly as F(n) mod 10^k (0 disables)")
+	fs.StringVar(&cfg.isFib, "is-fib", "", "Test whether the integer X is a Fibonacci number (5X²±4 is a perfect square) and print its index")
+	fs.StringVar(&cfg.zeckendorf, "zeckendorf", "", "Print the Zeckendorf representation of the integer X >= 0: the indices of the non-consecutive Fibonacci numbers summing to X")
+	fs.StringVar(&cfg.indexOf, "index-of", "", "Print the index n of the Fibonacci number F(n) closest to the integer X, and the difference X - F(n)")
+	fs.StringVar(&cfg.crt, "crt", "", "Print F(n) modulo each of the comma-separated primes, computed concurrently by modular Fast Doubling")
+	fs.BoolVar(&cfg.crtReconstruct, "crt-reconstruct", false, "With -crt, rebuild F(n) from its residues by the Chinese Remainder Theorem (the product of the primes must exceed |F(n)|)")
+	fs.IntVar(&cfg.word, "word", -1, "Write the n-th Fibonacci word (0→01, 1→0 from \"0\"), of F(n+2) symbols, to stdout or -output (-1 disables)")
+	fs.BoolVar(&cfg.ratio, "ratio", false, "Print F(n+1)/F(n), the golden ratio φ it approaches, their difference and the number of decimals they share")
+	fs.StringVar(&cfg.serve, "serve", "", "Server mode: listen on this TCP address (e.g. :7070) and compute the requests of -connect clients")
+	fs.StringVar(&cfg.connect, "connect", "", "Client mode: send the calculation to the -serve server at this address and display its results")
+	fs.StringVar(&cfg.rangeSpec, "range", "", "Range mode: write every F(i) for i in a:b, one per line, to stdout or -output")
+	fs.BoolVar(&cfg.selftest, "selftest", false, "Run every algorithm at n = 50 and 200, check the results against known values and exit (non-zero on failure)")
+	fs.BoolVar(&cfg.compareAlgorithms, "compare-algorithms", false, fmt.Sprintf("Run every algorithm at n = 0 to %d and a few spot indices, print a pass/fail matrix of each pair and exit (non-zero on any disagreement)", compareMaxN))
+	fs.BoolVar(&cfg.stdin, "stdin", false, "Batch mode: compute each index read from stdin, one per line, and write one result line per index (requires -format json or csv)")
+	fs.StringVar(&cfg.batch, "batch", "", "Compute F(n) for each of the comma-separated indices in one pass sharing their common doubling steps, and write one value per line")
+	fs.StringVar(&cfg.nList, "n-list", "", "Compute F(n) for each of the comma-separated indices as a separate task, concurrently up to -concurrency, and print one table keyed by n")
+	fs.StringVar(&cfg.benchmark, "benchmark", "", "Benchmark mode: sweep n over start:end:multiplier (e.g. 1000:1000000:10x) and write CSV timings")
+	fs.IntVar(&cfg.benchmarkRuns, "benchmark-runs", defaultBenchmarkRuns, "Recorded runs per benchmark data point, after one warmup run")
+	fs.StringVar(&cfg.progress, "progress", progressAuto, "Progress display: 'auto' (animate on a terminal, log lines otherwise), 'always', 'log' or 'never'")
+	fs.IntVar(&cfg.progressFD, "progress-fd", 0, "Write progress to this file descriptor (e.g. 3) as JSON Lines events, {\"task\":...,\"pct\":...}, instead of displaying it (0 disables)")
+	fs.IntVar(&cfg.repeat, "repeat", 1, "Run each algorithm k times and report the min, median and max durations")
+	fs.IntVar(&cfg.warmup, "warmup", 0, "Compute F(warmup) once per algorithm before the timed run, to fill the pools and grow the heap (0 disables)")
+	fs.BoolVar(&cfg.countOps, "count-ops", false, "Count the big-integer multiplications and additions of each algorithm and show them in the results")
+	fs.IntVar(&cfg.maxN, "max-n", 0, "Refuse indices whose absolute value exceeds this limit before any calculation, including the requests of -serve (default $"+maxNEnv+", 0 disables)")
+	fs.StringVar(&cfg.maxMemory, "max-memory", "", "Refuse calculations whose estimated memory (about 8 times the size of the result, n·log2(φ)/8 bytes) exceeds this size, e.g. 8GiB (default half of the physical memory, 0 disables)")
+	fs.IntVar(&cfg.barWidth, "bar-width", defaultBarWidth, "Number of cells of each progress bar (0 shows only percentages)")
+	fs.StringVar(&cfg.cpuProfile, "cpuprofile", "", "Write a CPU profile of the run to this file, for go tool pprof")
+	fs.StringVar(&cfg.memProfile, "memprofile", "", "Write a heap profile at the end of the run to this file, for go tool pprof")
+	fs.StringVar(&cfg.trace, "trace", "", "Write a runtime execution trace of the run to this file, for go tool trace")
+	if err := fs.Parse(args); err != nil {
+		return cfg, err
+	}
+	if err := resolveMaxN(fs, &cfg); err != nil {
+		return cfg, err
+	}
+
+	if cfg.perAlgoTimeout < 0 {
+		return cfg, fmt.Errorf("per-algorithm timeout must be non-negative. Received: %v", cfg.perAlgoTimeout)
+	}
+	if cfg.cacheSize < 0 {
+		return cfg, fmt.Errorf("cache size must be non-negative. Received: %d", cfg.cacheSize)
+	}
+	if cfg.perDigit < 0 {
+		return cfg, fmt.Errorf("timeout per digit must be non-negative. Received: %v", cfg.perDigit)
+	}
+	if cfg.retries < 0 {
+		return cfg, fmt.Errorf("retry count must be non-negative. Received: %d", cfg.retries)
+	}
+	if !(cfg.retryFactor > 1) { // Also rejects NaN
+		return cfg, fmt.Errorf("retry factor must be greater than 1. Received: %g", cfg.retryFactor)
+	}
+	if cfg.precisionBits < 0 {
+		return cfg, fmt.Errorf("precision must be non-negative. Received: %d", cfg.precisionBits)
+	}
+	if cfg.parallelThreshold < 0 {
+		return cfg, fmt.Errorf("parallel multiplication threshold must be non-negative. Received: %d", cfg.parallelThreshold)
+	}
+	if cfg.concurrency < 0 {
+		return cfg, fmt.Errorf("concurrency must be non-negative. Received: %d", cfg.concurrency)
+	}
+	if cfg.gomaxprocs < 0 {
+		return cfg, fmt.Errorf("gomaxprocs must be non-negative. Received: %d", cfg.gomaxprocs)
+	}
+	if cfg.gcBetween && !cfg.sequential {
+		return cfg, fmt.Errorf("-gc-between requires -sequential")
+	}
+	if cfg.head < 0 || cfg.tail < 0 {
+		return cfg, fmt.Errorf("-head and -tail must be non-negative. Received: %d and %d", cfg.head, cfg.tail)
+	}
+	if cfg.word < -1 {
+		return cfg, fmt.Errorf("-word requires n >= 0. Received: %d", cfg.word)
+	}
+	if err := checkExclusiveFlags(cfg); err != nil {
+		return cfg, err
+	}
+	if cfg.seed != "" {
+		if _, _, err := parseSeed(cfg.seed); err != nil {
+			return cfg, err
+		}
+		if cfg.algorithms != "fast" {
+			return cfg, fmt.Errorf("-seed always uses Fast Doubling and cannot be combined with -algorithms")
+		}
+	}
+	if cfg.recurrence != "" {
+		if _, _, err := parseRecurrence(cfg.recurrence); err != nil {
+			return cfg, err
+		}
+		if cfg.algorithms != "fast" {
+			return cfg, fmt.Errorf("-recurrence always uses matrix exponentiation and cannot be combined with -algorithms")
+		}
+	}
+	if cfg.isFib != "" {
+		if _, ok := new(big.Int).SetString(cfg.isFib, 10); !ok {
+			return cfg, fmt.Errorf("-is-fib expects a decimal integer. Received: %q", cfg.isFib)
+		}
+	}
+	if cfg.zeckendorf != "" {
+		if x, ok := new(big.Int).SetString(cfg.zeckendorf, 10); !ok || x.Sign() < 0 {
+			return cfg, fmt.Errorf("-zeckendorf expects a non-negative decimal integer. Received: %q", cfg.zeckendorf)
+		}
+	}
+	if cfg.indexOf != "" {
+		if _, ok := new(big.Int).SetString(cfg.indexOf, 10); !ok {
+			return cfg, fmt.Errorf("-index-of expects a decimal integer. Received: %q", cfg.indexOf)
+		}
+	}
+	if cfg.checkGCD < 0 && -cfg.checkGCD < 0 { // -math.MinInt overflows
+		return cfg, fmt.Errorf("-check-gcd index is out of range: %d", cfg.checkGCD)
+	}
+	if cfg.ratio && cfg.n < 1 {
+		return cfg, fmt.Errorf("-ratio requires n >= 1. Received: %d", cfg.n)
+	}
+	if cfg.crtReconstruct && cfg.crt == "" {
+		return cfg, fmt.Errorf("-crt-reconstruct requires -crt")
+	}
+	if cfg.crt != "" {
+		if _, err := parsePrimes(cfg.crt); err != nil {
+			return cfg, err
+		}
+		if cfg.algorithms != "fast" {
+			return cfg, fmt.Errorf("-crt always uses Fast Doubling and cannot be combined with -algorithms")
+		}
+	}
+	if cfg.stdin && cfg.format != formatJSON && cfg.format != formatCSV {
+		return cfg, fmt.Errorf("-stdin writes one line per index and requires -format json or csv")
+	}
+	if cfg.batch != "" {
+		if _, err := parseIndices("batch", cfg.batch); err != nil {
+			return cfg, err
+		}
+	}
+	if cfg.nList != "" {
+		if _, err := parseIndices("n-list", cfg.nList); err != nil {
+			return cfg, err
+		}
+		if cfg.format != formatTable {
+			return cfg, fmt.Errorf("-n-list prints a table keyed by n and requires -format table")
+		}
+	}
+	if cfg.rangeSpec != "" {
+		if _, _, err := parseRange(cfg.rangeSpec); err != nil {
+			return cfg, err
+		}
+	}
+	if cfg.benchmark != "" {
+		if _, err := parseSweep(cfg.benchmark); err != nil {
+			return cfg, err
+		}
+		if strings.EqualFold(strings.TrimSpace(cfg.algorithms), "auto") {
+			return cfg, fmt.Errorf("-algorithms auto chooses for a single n and cannot be combined with -benchmark")
+		}
+	}
+	if cfg.sum || cfg.sumSquares {
+		if cfg.n < 0 {
+			return cfg, fmt.Errorf("-sum and -sum-squares require a non-negative index. Received: %d", cfg.n)
+		}
+		if cfg.algorithms != "fast" {
+			return cfg, fmt.Errorf("-sum and -sum-squares always use Fast Doubling and cannot be combined with -algorithms")
+		}
+	}
+	if cfg.benchmarkRuns < 1 {
+		return cfg, fmt.Errorf("benchmark runs must be at least 1. Received: %d", cfg.benchmarkRuns)
+	}
+	switch cfg.progress {
+	case progressAuto, progressAlways, progressLog, progressNever:
+	default:
+		return cfg, fmt.Errorf("unknown progress mode %q (expected 'auto', 'always', 'log' or 'never')", cfg.progress)
+	}
+	if cfg.repeat < 1 {
+		return cfg, fmt.Errorf("repeat count must be at least 1. Received: %d", cfg.repeat)
+	}
+	if cfg.warmup < 0 {
+		return cfg, fmt.Errorf("warmup index must be non-negative. Received: %d", cfg.warmup)
+	}
+	if cfg.progressFD < 0 {
+		return cfg, fmt.Errorf("progress file descriptor must be non-negative. Received: %d", cfg.progressFD)
+	}
+	if cfg.progressFD > 0 && cfg.progress == progressNever {
+		return cfg, fmt.Errorf("-progress-fd cannot be combined with -progress never")
+	}
+	if cfg.barWidth < 0 {
+		return cfg, fmt.Errorf("progress bar width must be non-negative. Received: %d", cfg.barWidth)
+	}
+	if cfg.sciDigits < 0 {
+		return cfg, fmt.Errorf("scientific notation digits must be non-negative. Received: %d", cfg.sciDigits)
+	}
+	if cfg.base < 2 || cfg.base > 36 {
+		return cfg, fmt.Errorf("base must be between 2 and 36. Received: %d", cfg.base)
+	}
+	switch cfg.format {
+	case formatTable, formatJSON, formatCSV, formatProtobuf, formatValue, formatRaw:
+	default:
+		return cfg, fmt.Errorf("unknown output format %q (expected 'table', 'json', 'csv', 'protobuf', 'value' or 'raw')", cfg.format)
+	}
+	if cfg.format == formatRaw {
+		if cfg.output == "" {
+			return cfg, fmt.Errorf("-format raw writes binary data and requires -output")
+		}
+		if cfg.base != 10 {
+			return cfg, fmt.Errorf("-format raw writes the bytes of the value and cannot be combined with -base")
+		}
+	}
+	if cfg.format == formatValue && cfg.digitsOnly {
+		return cfg, fmt.Errorf("-format value prints the value and cannot be combined with -digits-only")
+	}
+	if cfg.csvValues && cfg.format != formatCSV {
+		return cfg, fmt.Errorf("-csv-values requires -format csv")
+	}
+	switch cfg.logFormat {
+	case logFormatText, logFormatJSON:
+	default:
+		return cfg, fmt.Errorf("unknown log format %q (expected 'text' or 'json')", cfg.logFormat)
+	}
+	if cfg.bigN != nil {
+		if cfg.mod == 0 {
+			return cfg, fmt.Errorf("index n is beyond the range of int (%s): F(n) would have about 0.694·n bits, so such an index requires -mod", cfg.bigN)
+		}
+		if cfg.algorithms != "fast" {
+			return cfg, fmt.Errorf("an index beyond the range of int is only supported by Fast Doubling (-algorithms fast)")
+		}
+		if cfg.format != formatTable && cfg.format != formatValue {
+			return cfg, fmt.Errorf("an index beyond the range of int requires -format table or value")
+		}
+	}
+	if cfg.perDigit > 0 {
+		cfg.timeout = adaptiveTimeout(cfg.n, cfg.perDigit, cfg.timeout)
+	}
+	if cfg.bigN != nil && cfg.maxN > 0 {
+		return cfg, fmt.Errorf("index %s exceeds the maximum index %d set by %s", cfg.bigN, cfg.maxN, cfg.maxNSource)
+	}
+	if err := checkMaxN(cfg, plannedIndex(cfg)); err != nil {
+		return cfg, err
+	}
+	budget, err := memoryBudget(cfg.maxMemory)
+	if err != nil {
+		return cfg, fmt.Errorf("-max-memory: %w", err)
+	}
+	if err := checkMemoryBudget(plannedBytes(cfg), budget); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+// ------------------------------------------------------------
+// Mutually Exclusive Flags
+// ------------------------------------------------------------
+//
+// Concept:
+// Most modes replace the calculation of F(n) by another one, and many options
+// only make sense for some of them. Instead of each mode checking, and
+// listing in its message, the flags it excludes, exclusiveFlags declares
+// every such flag once, with the flags it cannot be combined with. The
+// relation is symmetric: a pair is excluded when either of its flags lists
+// the other, so that a new mode only has to list the flags it excludes, and
+// each message lists every flag the reported one excludes.
+
+// exclusiveFlag is a flag, or a value of a flag, taking part in the
+// exclusivity checks of parseConfig.
+type exclusiveFlag struct {
+	name     string            // As written in the messages, e.g. "-range" or "-format raw"
+	set      func(config) bool // Reports whether the flag is in use
+	excludes []string          // Names of the flags it cannot be combined with
+}
+
+// standaloneModeExcludes lists the flags excluded by the modes that ignore
+// every other option but the timeout, `-selftest` and `-compare-algorithms`:
+// the other modes, each other included, and the options of a calculation.
+var standaloneModeExcludes = []string{
+	"-compare-algorithms", "-word", "-ratio", "-is-fib", "-zeckendorf", "-index-of", "-crt", "-batch", "-n-list", "-stdin",
+	"-range", "-benchmark", "-serve", "-connect", "an index beyond the range of int",
+	"-sum", "-sum-squares", "-seed", "-recurrence", "-estimate-digits", "-head", "-tail", "-check-gcd",
+	"-save", "-compare-with", "-format raw", "-timeout-per-digit", "-retries", "-only-fastest",
+	"-mod", "-digits-only", "-cache", "-verify", "-output",
+}
+
+// exclusiveFlags lists the flags checked by checkExclusiveFlags, modes first:
+// the order is that of the messages.
+var exclusiveFlags = []exclusiveFlag{
+	{"-selftest", func(c config) bool { return c.selftest }, standaloneModeExcludes},
+	{"-compare-algorithms", func(c config) bool { return c.compareAlgorithms }, standaloneModeExcludes},
+	{"-word", func(c config) bool { return c.word >= 0 }, []string{"-is-fib", "-zeckendorf", "-index-of", "-crt", "-ratio", "-mod", "-estimate-digits", "-head", "-tail", "-sum", "-sum-squares", "-seed", "-recurrence", "-range", "-batch", "-n-list", "-benchmark", "-stdin", "-serve", "-connect", "-save", "-compare-with"}},
+	{"-ratio", func(c config) bool { return c.ratio }, []string{"-is-fib", "-zeckendorf", "-index-of", "-crt", "-mod", "-estimate-digits", "-head", "-tail", "-sum", "-sum-squares", "-seed", "-recurrence", "-range", "-batch", "-benchmark", "-stdin", "-serve", "-connect", "-save", "-compare-with"}},
+	{"-is-fib", func(c config) bool { return c.isFib != "" }, []string{"-mod", "-estimate-digits", "-head", "-tail", "-sum", "-sum-squares", "-seed", "-recurrence", "-range", "-benchmark", "-serve", "-connect"}},
+	{"-zeckendorf", func(c config) bool { return c.zeckendorf != "" }, []string{"-is-fib", "-mod", "-estimate-digits", "-head", "-tail", "-sum", "-sum-squares", "-seed", "-recurrence", "-range", "-benchmark", "-serve", "-connect"}},
+	{"-index-of", func(c config) bool { return c.indexOf != "" }, []string{"-is-fib", "-zeckendorf", "-mod", "-estimate-digits", "-head", "-tail", "-sum", "-sum-squares", "-seed", "-recurrence", "-range", "-benchmark", "-serve", "-connect"}},
+	{"-crt", func(c config) bool { return c.crt != "" }, []string{"-is-fib", "-zeckendorf", "-index-of", "-mod", "-estimate-digits", "-head", "-tail", "-sum", "-sum-squares", "-seed", "-recurrence", "-range", "-benchmark", "-serve", "-connect"}},
+	{"-batch", func(c config) bool { return c.batch != "" }, []string{"-is-fib", "-zeckendorf", "-index-of", "-crt", "-mod", "-estimate-digits", "-head", "-tail", "-sum", "-sum-squares", "-seed", "-recurrence", "-range", "-benchmark", "-stdin", "-serve", "-connect", "-save", "-compare-with"}},
+	{"-n-list", func(c config) bool { return c.nList != "" }, []string{"-is-fib", "-zeckendorf", "-index-of", "-crt", "-ratio", "-batch", "-estimate-digits", "-head", "-tail", "-range", "-benchmark", "-stdin", "-serve", "-connect", "-save", "-compare-with", "-retries", "-cache", "-verify", "-check-gcd", "-output", "-only-fastest"}},
+	{"-stdin", func(c config) bool { return c.stdin }, []string{"-estimate-digits", "-head", "-tail", "-is-fib", "-zeckendorf", "-index-of", "-crt", "-range", "-benchmark", "-serve", "-connect", "-save", "-compare-with", "-retries", "-cache", "-verify", "-check-gcd", "-output"}},
+	{"-range", func(c config) bool { return c.rangeSpec != "" }, []string{"-benchmark"}},
+	{"-benchmark", func(c config) bool { return c.benchmark != "" }, nil},
+	{"-serve", func(c config) bool { return c.serve != "" }, []string{"-connect", "-range", "-benchmark", "-estimate-digits"}},
+	{"-connect", func(c config) bool { return c.connect != "" }, []string{"-range", "-benchmark", "-estimate-digits"}},
+	{"an index beyond the range of int", func(c config) bool { return c.bigN != nil }, []string{"-is-fib", "-zeckendorf", "-index-of", "-crt", "-ratio", "-batch", "-n-list", "-estimate-digits", "-head", "-tail", "-sum", "-sum-squares", "-seed", "-recurrence", "-range", "-benchmark", "-stdin", "-serve", "-connect", "-save", "-compare-with", "-cache", "-verify", "-check-gcd", "-output"}},
+	{"-sum", func(c config) bool { return c.sum }, []string{"-sum-squares", "-range", "-benchmark", "-estimate-digits", "-serve", "-connect"}},
+	{"-sum-squares", func(c config) bool { return c.sumSquares }, []string{"-range", "-benchmark", "-estimate-digits", "-serve", "-connect"}},
+	{"-seed", func(c config) bool { return c.seed != "" }, []string{"-sum", "-sum-squares", "-range", "-benchmark", "-estimate-digits", "-head", "-tail", "-serve", "-connect"}},
+	{"-recurrence", func(c config) bool { return c.recurrence != "" }, []string{"-sum", "-sum-squares", "-seed", "-range", "-benchmark", "-estimate-digits", "-head", "-tail", "-serve", "-connect"}},
+	{"-estimate-digits", func(c config) bool { return c.estimateDigits }, []string{"-mod"}},
+	{"-head", func(c config) bool { return c.head > 0 }, []string{"-mod", "-estimate-digits", "-sum", "-sum-squares", "-range", "-benchmark", "-serve", "-connect"}},
+	{"-tail", func(c config) bool { return c.tail > 0 }, []string{"-mod", "-estimate-digits", "-sum", "-sum-squares", "-range", "-benchmark", "-serve", "-connect"}},
+	{"-check-gcd", func(c config) bool { return c.checkGCD != 0 }, []string{"-mod", "-sum", "-sum-squares", "-seed", "-recurrence"}},
+	{"-save", func(c config) bool { return c.save != "" }, []string{"-estimate-digits", "-head", "-tail", "-is-fib", "-zeckendorf", "-index-of", "-crt", "-range", "-benchmark", "-serve"}},
+	{"-compare-with", func(c config) bool { return c.compareWith != "" }, []string{"-estimate-digits", "-head", "-tail", "-is-fib", "-zeckendorf", "-index-of", "-crt", "-range", "-benchmark", "-serve"}},
+	{"-format raw", func(c config) bool { return c.format == formatRaw }, []string{"-digits-only", "-estimate-digits", "-head", "-tail", "-is-fib", "-zeckendorf", "-index-of", "-crt", "-ratio", "-word", "-range", "-batch", "-benchmark", "-serve"}},
+	{"-timeout-per-digit", func(c config) bool { return c.perDigit > 0 }, []string{"-mod", "-range", "-batch", "-n-list", "-benchmark", "-stdin", "-serve", "-connect"}},
+	{"-retries", func(c config) bool { return c.retries > 0 }, []string{"-range", "-benchmark", "-serve", "-connect"}},
+	{"-only-fastest", func(c config) bool { return c.onlyFastest }, []string{"-benchmark", "-connect"}},
+	{"-mod", func(c config) bool { return c.mod > 0 }, nil},
+	{"-digits-only", func(c config) bool { return c.digitsOnly }, nil},
+	{"-cache", func(c config) bool { return c.cacheDir != "" }, nil},
+	{"-verify", func(c config) bool { return c.verify }, nil},
+	{"-output", func(c config) bool { return c.output != "" }, nil},
+}
+
+// excludedFlags returns the names of the flags f cannot be combined with, in
+// the order of exclusiveFlags: those it lists and those listing it, but f
+// itself, which a shared list such as standaloneModeExcludes may hold.
+func excludedFlags(f exclusiveFlag) []string {
+	var names []string
+	for _, g := range exclusiveFlags {
+		if g.name != f.name && (slices.Contains(f.excludes, g.name) || slices.Contains(g.excludes, f.name)) {
+			names = append(names, g.name)
+		}
+	}
+	return names
+}
+
+// checkExclusiveFlags returns an error when cfg combines two flags of
+// exclusiveFlags that exclude each other. The message names the first of
+// them in the table and every flag it excludes.
+func checkExclusiveFlags(cfg config) error {
+	for _, f := range exclusiveFlags {
+		if !f.set(cfg) {
+			continue
+		}
+		excluded := excludedFlags(f)
+		for _, g := range exclusiveFlags {
+			if g.set(cfg) && slices.Contains(excluded, g.name) {
+				return fmt.Errorf("%s cannot be combined with %s", f.name, joinFlags(excluded))
+			}
+		}
+	}
+	return nil
+}
+
+// joinFlags joins names as in "-a, -b or -c".
+func joinFlags(names []string) string {
+	if len(names) == 1 {
+		return names[0]
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " or " + names[len(names)-1]
+}
+
+// maxNEnv is the environment variable giving the limit of `-max-n` when the
+// flag is not set, e.g. for every run of a shared deployment.
+const maxNEnv = "FIBJULE_MAX_N"
+
+// resolveMaxN sets cfg.maxN and cfg.maxNSource: the `-max-n` flag when set,
+// even to 0, or else the maxNEnv environment variable, or else no limit.
+func resolveMaxN(fs *flag.FlagSet, cfg *config) error {
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "max-n" {
+			cfg.maxNSource = "-max-n"
+		}
+	})
+	if cfg.maxNSource == "" {
+		value, ok := os.LookupEnv(maxNEnv)
+		if !ok || value == "" {
+			return nil
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%s expects an integer. Received: %q", maxNEnv, value)
+		}
+		cfg.maxN, cfg.maxNSource = n, maxNEnv
+	}
+	if cfg.maxN < 0 {
+		return fmt.Errorf("%s must be non-negative. Received: %d", cfg.maxNSource, cfg.maxN)
+	}
+	return nil
+}
+
+// plannedIndex returns the largest |index| a run of cfg computes, known
+// before it starts: that of n, `-check-gcd` and `-warmup`, or the largest
+// index of a range, a batch, `-n-list`, a benchmark sweep or `-word`. It returns 0 for the modes
+// whose input is not an index, and for those whose indices are only known
+// later: -stdin checks each of them (see runBatch), and -serve each request
+// (see handleRemote).
+func plannedIndex(cfg config) int {
+	largest := func(ns []int) int {
+		var k int
+		for _, n := range ns {
+			k = max(k, absIndex(n))
+		}
+		return k
+	}
+	switch {
+	case cfg.isFib != "", cfg.zeckendorf != "", cfg.indexOf != "", cfg.selftest, cfg.compareAlgorithms, cfg.stdin, cfg.serve != "":
+		return 0
+	case cfg.word >= 0:
+		return cfg.word
+	case cfg.rangeSpec != "":
+		_, b, _ := parseRange(cfg.rangeSpec) // Validated by parseConfig
+		return b
+	case cfg.batch != "":
+		ns, _ := parseIndices("batch", cfg.batch) // Validated by parseConfig
+		return largest(ns)
+	case cfg.nList != "":
+		ns, _ := parseIndices("n-list", cfg.nList) // Validated by parseConfig
+		return largest(ns)
+	case cfg.benchmark != "":
+		ns, _ := parseSweep(cfg.benchmark) // Validated by parseConfig
+		return ns[len(ns)-1]
+	}
+	return largest([]int{cfg.n, cfg.checkGCD, cfg.warmup})
+}
+
+// checkMaxN returns an error when the index n exceeds the `-max-n` limit of
+// cfg in absolute value. A limit of 0 disables the check.
+func checkMaxN(cfg config, n int) error {
+	if cfg.maxN > 0 && absIndex(n) > cfg.maxN {
+		return fmt.Errorf("index %d exceeds the maximum index %d set by %s", n, cfg.maxN, cfg.maxNSource)
+	}
+	return nil
+}
+
+// absIndex returns |n|, saturated at math.MaxInt for math.MinInt.
+func absIndex(n int) int {
+	if n == math.MinInt {
+		return math.MaxInt
+	}
+	return abs(n)
+}
+
+// minAdaptiveTimeout is the smallest timeout derived from `-timeout-per-digit`:
+// the few digits of a small F(n) would otherwise leave only microseconds.
+const minAdaptiveTimeout = 100 * time.Millisecond
+
+// adaptiveTimeout returns the timeout of `-timeout-per-digit` for F(n): its
+// digit count estimated by Binet's formula (see fib.EstimateDigits) times
+// perDigit, within [minAdaptiveTimeout, limit].
+func adaptiveTimeout(n int, perDigit, limit time.Duration) time.Duration {
+	timeout := float64(fib.EstimateDigits(n)) * float64(perDigit) // float64: no overflow
+	if timeout >= float64(limit) {
+		return limit
+	}
+	return max(time.Duration(timeout), min(minAdaptiveTimeout, limit))
+}
+
+// indexFlag is the `-n` flag. It parses the index like an int flag, and keeps
+// an index beyond the range of int, which only modular mode can compute, as
+// a *big.Int.
+type indexFlag struct {
+	n   *int
+	big **big.Int
+}
+
+func (f indexFlag) String() string {
+	switch {
+	case f.n == nil: // Zero value inspected by the flag package
+		return "0"
+	case *f.big != nil:
+		return (*f.big).String()
+	default:
+		return strconv.Itoa(*f.n)
+	}
+}
+
+func (f indexFlag) Set(s string) error {
+	v, ok := new(big.Int).SetString(s, 0)
+	if !ok {
+		return fmt.Errorf("invalid index %q", s)
+	}
+	// math.MinInt stays a *big.Int: its absolute value overflows int.
+	if v.IsInt64() && int64(int(v.Int64())) == v.Int64() && int(v.Int64()) != math.MinInt {
+		*f.n, *f.big = int(v.Int64()), nil
+		return nil
+	}
+	*f.n, *f.big = 0, v
+	return nil
+}
@@ -0,0 +1,370 @@
+// config_test.go
+
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestParseConfig verifies default values, overrides, and validation errors.
+func TestParseConfig(t *testing.T) {
+	cfg, err := parseConfig(nil, io.Discard)
+	if err != nil {
+		t.Fatalf("unexpected error with default arguments: %v", err)
+	}
+	if cfg.n != 100000 || cfg.timeout != time.Minute || cfg.mod != 0 || cfg.format != formatTable || cfg.base != 10 || cfg.algorithms != "fast" || cfg.barWidth != defaultBarWidth || cfg.progress != progressAuto || cfg.repeat != 1 || cfg.logFormat != logFormatText {
+		t.Errorf("unexpected default configuration: %+v", cfg)
+	}
+
+	cfg, err = parseConfig([]string{"-n", "42", "-timeout", "5s", "-mod", "7", "-format", "json", "-output", "fib.txt", "-base", "16"}, io.Discard)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.n != 42 || cfg.timeout != 5*time.Second || cfg.mod != 7 || cfg.format != formatJSON || cfg.output != "fib.txt" || cfg.base != 16 {
+		t.Errorf("unexpected configuration: %+v", cfg)
+	}
+
+	// Negative indices select negafibonacci numbers.
+	if cfg, err := parseConfig([]string{"-n", "-5"}, io.Discard); err != nil || cfg.n != -5 {
+		t.Errorf("expected n=-5 to be accepted, got n=%d (err=%v)", cfg.n, err)
+	}
+
+	// An index beyond the range of int is kept as a *big.Int for -mod.
+	if cfg, err := parseConfig([]string{"-n", "100000000000000000000", "-mod", "1000"}, io.Discard); err != nil || cfg.bigN == nil || cfg.bigN.String() != "100000000000000000000" || cfg.n != 0 {
+		t.Errorf("expected a big index, got n=%d, bigN=%v (err=%v)", cfg.n, cfg.bigN, err)
+	}
+	if cfg, err := parseConfig([]string{"-n", "100000000000000000000", "-n", "7"}, io.Discard); err != nil || cfg.bigN != nil || cfg.n != 7 {
+		t.Errorf("expected the last -n to replace the big index, got n=%d, bigN=%v (err=%v)", cfg.n, cfg.bigN, err)
+	}
+
+	invalid := [][]string{
+		{"-format", "xml"},
+		{"-format", "value", "-digits-only"},
+		{"-format", "raw"},
+		{"-format", "raw", "-output", "f.bin", "-base", "16"},
+		{"-format", "raw", "-output", "f.bin", "-range", "0:10"},
+		{"-csv-values"},
+		{"-log-format", "logfmt"},
+		{"-base", "1"},
+		{"-base", "37"},
+		{"-bar-width", "-1"},
+		{"-repeat", "0"},
+		{"-warmup", "-1"},
+		{"-per-algo-timeout", "-1s"},
+		{"-progress", "sometimes"},
+		{"-benchmark", "1:10"},
+		{"-range", "10:5"},
+		{"-estimate-digits", "-mod", "7"},
+		{"-range", "0:5", "-benchmark", "1:10:2x"},
+		{"-benchmark-runs", "0"},
+		{"-gc-between"},
+		{"-concurrency", "-1"},
+		{"-gomaxprocs", "-1"},
+		{"-only-fastest", "-connect", "localhost:7070"},
+		{"-algorithms", "auto", "-benchmark", "10:1000:10x"},
+		{"-retries", "-1"},
+		{"-crt-reconstruct"},
+		{"-precision-bits", "-1"},
+		{"-crt", "7,x"},
+		{"-crt", "7,9"},
+		{"-crt", "7,7"},
+		{"-crt", "7", "-mod", "5"},
+		{"-crt", "7", "-algorithms", "all"},
+		{"-retry-factor", "1"},
+		{"-retry-factor", "NaN"},
+		{"-retries", "2", "-benchmark", "10:1000:10x"},
+		{"-retries", "2", "-connect", "localhost:7070"},
+		{"-serve", ":7070", "-connect", "localhost:7070"},
+		{"-connect", "localhost:7070", "-range", "0:5"},
+		{"-sum", "-sum-squares"},
+		{"-sum", "-n", "-5"},
+		{"-sum-squares", "-algorithms", "all"},
+		{"-sum", "-range", "0:5"},
+		{"-head", "-1"},
+		{"-tail", "5", "-mod", "7"},
+		{"-head", "5", "-range", "0:5"},
+		{"-seed", "2"},
+		{"-seed", "2,x"},
+		{"-seed", "2,1", "-sum"},
+		{"-seed", "2,1", "-algorithms", "all"},
+		{"-recurrence", "2,x"},
+		{"-recurrence", "1,1:0"},
+		{"-recurrence", "1,1:0,1,1"},
+		{"-recurrence", "2,1", "-seed", "2,1"},
+		{"-recurrence", "2,1", "-algorithms", "all"},
+		{"-recurrence", "2,1", "-range", "0:10"},
+		{"-recurrence", "2,1", "-batch", "1,2"},
+		{"-is-fib", "12x"},
+		{"-is-fib", "144", "-head", "3"},
+		{"-zeckendorf", "-5"},
+		{"-zeckendorf", "100", "-is-fib", "8"},
+		{"-save", "f.gob", "-range", "0:5"},
+		{"-compare-with", "f.gob", "-serve", ":7070"},
+		{"-stdin"},
+		{"-stdin", "-format", "csv", "-range", "0:5"},
+		{"-sci-digits", "-1"},
+		{"-index-of", "1e6"},
+		{"-index-of", "100", "-zeckendorf", "100"},
+		{"-ratio", "-n", "0"},
+		{"-ratio", "-mod", "7"},
+		{"-batch", "1,,3"},
+		{"-batch", "1,2", "-mod", "7"},
+		{"-batch", "1,2", "-range", "0:5"},
+		{"-batch", "1,2000000000000", "-max-memory", "1GiB"},
+		{"-check-gcd", "10", "-mod", "7"},
+		{"-check-gcd", "10", "-sum"},
+		{"-check-gcd", "10", "-stdin", "-format", "json"},
+		{"-word", "-2"},
+		{"-word", "10", "-mod", "7"},
+		{"-word", "10", "-range", "0:5"},
+		{"-n-list", "1,x"},
+		{"-n-list", "1,2", "-format", "json"},
+		{"-n-list", "1,2", "-batch", "3"},
+		{"-n-list", "1,2", "-only-fastest"},
+		{"-n-list", "1,2000000000000", "-max-memory", "1GiB"},
+		{"-n", "12x"},
+		{"-n", "100000000000000000000"},
+		{"-n", "100000000000000000000", "-mod", "7", "-algorithms", "all"},
+		{"-n", "100000000000000000000", "-mod", "7", "-format", "json"},
+		{"-n", "100000000000000000000", "-mod", "7", "-sum"},
+		{"-timeout-per-digit", "-1us"},
+		{"-timeout-per-digit", "1us", "-mod", "7"},
+		{"-timeout-per-digit", "1us", "-range", "0:5"},
+		{"-progress-fd", "-1"},
+		{"-progress-fd", "3", "-progress", "never"},
+		{"-max-memory", "lots"},
+		{"-n", "1000000000000", "-max-memory", "1GiB"},
+		{"-range", "0:100000000", "-max-memory", "10MiB"},
+		{"-unknown"},
+	}
+	for _, args := range invalid {
+		if _, err := parseConfig(args, io.Discard); err == nil {
+			t.Errorf("expected an error for arguments %v, but got none", args)
+		}
+	}
+}
+
+// TestAdaptiveTimeout checks the timeout of -timeout-per-digit for several n,
+// within its floor and the -timeout cap, and that parseConfig replaces the
+// global timeout, from which every mode builds its context.
+func TestAdaptiveTimeout(t *testing.T) {
+	const perDigit = 10 * time.Microsecond
+	testCases := []struct {
+		n    int
+		want time.Duration
+	}{
+		{10, minAdaptiveTimeout},       // 2 digits
+		{100000, 20899 * perDigit},     // 20 899 digits
+		{-1000000, 208988 * perDigit},  // |F(-n)| = F(n)
+		{10000000, 2089877 * perDigit}, // 20.9s
+		{1000000000, time.Minute},      // Capped by -timeout
+	}
+	for _, tc := range testCases {
+		if got := adaptiveTimeout(tc.n, perDigit, time.Minute); got != tc.want {
+			t.Errorf("n=%d: expected a timeout of %v, got %v", tc.n, tc.want, got)
+		}
+	}
+	if got := adaptiveTimeout(10, perDigit, time.Millisecond); got != time.Millisecond {
+		t.Errorf("expected the cap to win over the floor, got %v", got)
+	}
+
+	cfg, err := parseConfig([]string{"-n", "1000000", "-timeout-per-digit", "10us"}, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.timeout != 208988*perDigit {
+		t.Errorf("expected the global timeout to be %v, got %v", 208988*perDigit, cfg.timeout)
+	}
+}
+
+// TestMaxN checks the precedence of the index limit, -max-n over
+// FIBJULE_MAX_N over no limit, and that an index above it is refused before
+// any calculation: by parseConfig, for each line of -stdin, and for each
+// request of -serve.
+func TestMaxN(t *testing.T) {
+	t.Setenv(maxNEnv, "")
+	for _, c := range []struct {
+		env     string
+		args    []string
+		wantMax int
+		wantErr bool
+	}{
+		{"", []string{"-n", "1000000000"}, 0, false},  // No limit
+		{"5000", []string{"-n", "5000"}, 5000, false}, // The environment sets the limit
+		{"5000", []string{"-n", "-5001"}, 5000, true}, // |n| counts
+		{"5000", []string{"-n", "100", "-warmup", "9000"}, 5000, true},
+		{"5000", []string{"-n", "9000", "-max-n", "10000"}, 10000, false}, // The flag overrides it
+		{"5000", []string{"-n", "9000", "-max-n", "0"}, 0, false},         // Even to lift the limit
+		{"5000", []string{"-n", "100", "-range", "0:6000"}, 5000, true},
+		{"5000", []string{"-n-list", "10,-6000"}, 5000, true},
+		{"5000", []string{"-n", "100", "-check-gcd", "6000"}, 5000, true},
+		{"5000", []string{"-is-fib", "55"}, 5000, false}, // Not an index
+		{"", []string{"-n", "10", "-max-n", "-1"}, 0, true},
+		{"x", []string{"-n", "10"}, 0, true},
+		{"x", []string{"-n", "10", "-max-n", "100"}, 100, false}, // The environment is not read
+	} {
+		t.Setenv(maxNEnv, c.env)
+		cfg, err := parseConfig(c.args, io.Discard)
+		if (err != nil) != c.wantErr {
+			t.Errorf("%s=%q %v: expected an error: %v, got %v", maxNEnv, c.env, c.args, c.wantErr, err)
+		}
+		if err == nil && cfg.maxN != c.wantMax {
+			t.Errorf("%s=%q %v: expected a limit of %d, got %d", maxNEnv, c.env, c.args, c.wantMax, cfg.maxN)
+		}
+	}
+	t.Setenv(maxNEnv, "")
+
+	cfg, err := parseConfig([]string{"-stdin", "-format", "json", "-max-n", "100"}, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out strings.Builder
+	status, err := runBatch(context.Background(), strings.NewReader("10\n101\n-100\n"), &out, cfg)
+	if err != nil || status != 1 || strings.Count(out.String(), "\n") != 2 {
+		t.Errorf("expected the index 101 to be skipped with status 1, got status %d (err=%v):\n%s", status, err, out.String())
+	}
+
+	client, server := net.Pipe()
+	defer client.Close()
+	go func() {
+		defer server.Close()
+		_ = handleRemote(context.Background(), server, cfg, nil, 0)
+	}()
+	_, err = requestRemote(client, remoteRequest{N: 1000, Algorithms: "fast", Timeout: time.Minute, Repeat: 1})
+	if err == nil || !strings.Contains(err.Error(), "maximum index 100 set by -max-n") {
+		t.Errorf("expected the server to refuse n = 1000, got %v", err)
+	}
+}
+
+// exclusiveFlagSamples returns arguments setting each flag of exclusiveFlags,
+// valid on their own, keyed by its name. The files they name are in dir.
+func exclusiveFlagSamples(dir string) map[string][]string {
+	return map[string][]string{
+		"-selftest":                        {"-selftest"},
+		"-compare-algorithms":              {"-compare-algorithms"},
+		"-word":                            {"-word", "5"},
+		"-ratio":                           {"-ratio"},
+		"-is-fib":                          {"-is-fib", "144"},
+		"-zeckendorf":                      {"-zeckendorf", "100"},
+		"-index-of":                        {"-index-of", "144"},
+		"-crt":                             {"-crt", "7,11"},
+		"-batch":                           {"-batch", "1,2"},
+		"-n-list":                          {"-n-list", "1,2"},
+		"-stdin":                           {"-stdin", "-format", "json"},
+		"-range":                           {"-range", "0:5"},
+		"-benchmark":                       {"-benchmark", "10:1000:10x"},
+		"-serve":                           {"-serve", ":0"},
+		"-connect":                         {"-connect", "localhost:7070"},
+		"an index beyond the range of int": {"-n", "100000000000000000000", "-mod", "7"},
+		"-sum":                             {"-sum"},
+		"-sum-squares":                     {"-sum-squares"},
+		"-seed":                            {"-seed", "2,1"},
+		"-recurrence":                      {"-recurrence", "2,1"},
+		"-estimate-digits":                 {"-estimate-digits"},
+		"-head":                            {"-head", "3"},
+		"-tail":                            {"-tail", "3"},
+		"-check-gcd":                       {"-check-gcd", "10"},
+		"-save":                            {"-save", dir + "/save.bin"},
+		"-compare-with":                    {"-compare-with", dir + "/compare.bin"},
+		"-format raw":                      {"-format", "raw", "-output", dir + "/raw.bin"},
+		"-timeout-per-digit":               {"-timeout-per-digit", "1us"},
+		"-retries":                         {"-retries", "1"},
+		"-only-fastest":                    {"-only-fastest"},
+		"-mod":                             {"-mod", "7"},
+		"-digits-only":                     {"-digits-only"},
+		"-cache":                           {"-cache", dir},
+		"-verify":                          {"-verify"},
+		"-output":                          {"-output", dir + "/out.txt"},
+	}
+}
+
+// TestExclusiveFlags verifies every pair of exclusiveFlags: parseConfig
+// rejects the pairs the table excludes, naming both flags, and only those. A
+// flag added to the table without sample arguments fails the test.
+func TestExclusiveFlags(t *testing.T) {
+	samples := exclusiveFlagSamples(t.TempDir())
+
+	// setBy[i] lists the flags set by the sample of exclusiveFlags[i], which
+	// may need others: the index beyond int needs -mod.
+	setBy := make([][]string, len(exclusiveFlags))
+	for i, f := range exclusiveFlags {
+		for _, name := range f.excludes {
+			if !slices.ContainsFunc(exclusiveFlags, func(g exclusiveFlag) bool { return g.name == name }) {
+				t.Errorf("%s excludes %s, which is not in exclusiveFlags", f.name, name)
+			}
+		}
+		args, ok := samples[f.name]
+		if !ok {
+			t.Errorf("no sample arguments for %s", f.name)
+			continue
+		}
+		cfg, err := parseConfig(args, io.Discard)
+		if err != nil {
+			t.Errorf("%v: unexpected error: %v", args, err)
+			continue
+		}
+		for _, g := range exclusiveFlags {
+			if g.set(cfg) {
+				setBy[i] = append(setBy[i], g.name)
+			}
+		}
+		if !slices.Contains(setBy[i], f.name) {
+			t.Errorf("%v does not set %s", args, f.name)
+		}
+	}
+	if t.Failed() {
+		return
+	}
+
+	excludes := func(a, b string) bool {
+		for _, f := range exclusiveFlags {
+			if f.name == a && slices.Contains(excludedFlags(f), b) {
+				return true
+			}
+		}
+		return false
+	}
+	for i, f := range exclusiveFlags {
+		for j := i + 1; j < len(exclusiveFlags); j++ {
+			g := exclusiveFlags[j]
+			var want bool
+			for _, a := range setBy[i] {
+				for _, b := range setBy[j] {
+					want = want || excludes(a, b)
+				}
+			}
+			args := append(slices.Clone(samples[f.name]), samples[g.name]...)
+			_, err := parseConfig(args, io.Discard)
+			got := err != nil && strings.Contains(err.Error(), " cannot be combined with ")
+			if got != want {
+				t.Errorf("%v: expected an exclusivity error: %v, got %v", args, want, err)
+			}
+			if got && excludes(f.name, g.name) && (!strings.Contains(err.Error(), f.name) || !strings.Contains(err.Error(), g.name)) {
+				t.Errorf("%v: expected the error to name %s and %s, got %v", args, f.name, g.name, err)
+			}
+		}
+	}
+}
+
+// TestStandaloneModes verifies that the modes ignoring every other option
+// reject each flag of standaloneModeExcludes, each other included.
+func TestStandaloneModes(t *testing.T) {
+	samples := exclusiveFlagSamples(t.TempDir())
+	for _, mode := range []string{"-selftest", "-compare-algorithms"} {
+		for _, name := range standaloneModeExcludes {
+			if name == mode {
+				continue
+			}
+			args := append([]string{mode}, samples[name]...)
+			if _, err := parseConfig(args, io.Discard); err == nil || !strings.Contains(err.Error(), mode+" cannot be combined with") || !strings.Contains(err.Error(), name) {
+				t.Errorf("%v: expected %s to be rejected with %s, got %v", args, mode, name, err)
+			}
+		}
+	}
+}
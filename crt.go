@@ -0,0 +1,146 @@
+// crt.go
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/agbruneau/FibJule/fib"
+)
+
+// ------------------------------------------------------------
+// CRT Mode: F(n) Modulo Several Primes
+// ------------------------------------------------------------
+//
+// F(n) mod p costs O(log n) products of numbers below p, whatever the size of
+// F(n). Computing it for several primes lets machines check each other's
+// results cheaply, and the Chinese Remainder Theorem turns the residues back
+// into F(n) once the product of the primes exceeds |F(n)|.
+
+// parsePrimes parses the comma-separated `-crt` list: distinct primes, each
+// fitting in a uint64.
+func parsePrimes(spec string) ([]*big.Int, error) {
+	var primes []*big.Int
+	seen := make(map[uint64]bool)
+	for _, field := range strings.Split(spec, ",") {
+		field = strings.TrimSpace(field)
+		p, err := strconv.ParseUint(field, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid prime %q in -crt (expected a comma-separated list, e.g. 1000000007,998244353)", field)
+		}
+		v := new(big.Int).SetUint64(p)
+		if !v.ProbablyPrime(20) {
+			return nil, fmt.Errorf("-crt expects primes: %d is not prime", p)
+		}
+		if seen[p] {
+			return nil, fmt.Errorf("-crt lists the prime %d twice", p)
+		}
+		seen[p] = true
+		primes = append(primes, v)
+	}
+	return primes, nil
+}
+
+// crtResidues computes F(n) mod p for every prime with modular Fast
+// Doubling, one goroutine per prime. The residues follow the order of primes.
+func crtResidues(ctx context.Context, n int, primes []*big.Int) ([]*big.Int, error) {
+	residues := make([]*big.Int, len(primes))
+	errs := make([]error, len(primes))
+	var wg sync.WaitGroup
+	for i, p := range primes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			residues[i], errs[i] = fib.FastDoublingMod(ctx, n, p, fib.WithPool(newIntPool()))
+		}()
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("F(%d) mod %s: %w", n, primes[i], err)
+		}
+	}
+	return residues, nil
+}
+
+// crtBoundBits returns a number of bits b such that |F(n)| < 2^b. Binet's
+// formula gives |F(n)| < φ^|n|, whose bit length is at most ⌊|n|·log2(φ)⌋ + 1;
+// one more bit absorbs the rounding of the float64 product.
+func crtBoundBits(n int) int {
+	return int(math.Abs(float64(n))*math.Log2(math.Phi)) + 2
+}
+
+// crtReconstruct returns F(n) from its residues modulo pairwise coprime
+// moduli whose product M must exceed |F(n)| (see crtBoundBits). The residues
+// are combined one at a time, Garner-style: x ≡ r (mod M) is extended to the
+// next modulus p by adding the multiple of M fixing x mod p. F(n) is negative
+// for an even negative n, and is then the representative x − M.
+func crtReconstruct(n int, residues, moduli []*big.Int) (*big.Int, error) {
+	x := new(big.Int)
+	m := big.NewInt(1)
+	t := new(big.Int)
+	for i, p := range moduli {
+		inv := new(big.Int).ModInverse(new(big.Int).Mod(m, p), p)
+		if inv == nil {
+			return nil, fmt.Errorf("the moduli are not pairwise coprime: %s", p)
+		}
+		t.Sub(residues[i], x)
+		t.Mul(t, inv).Mod(t, p)
+		x.Add(x, t.Mul(t, m))
+		m.Mul(m, p)
+	}
+	if n < 0 && n%2 == 0 && x.Sign() != 0 {
+		x.Sub(x, m)
+	}
+	return x, nil
+}
+
+// runCRTMode runs the `-crt` mode: it prints F(n) modulo each prime and, with
+// `-crt-reconstruct`, F(n) itself rebuilt from these residues.
+func runCRTMode(cfg config) {
+	primes, _ := parsePrimes(cfg.crt) // Validated by parseConfig
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.timeout)
+	defer cancel()
+	stopSignals := handleSignals(cancel)
+	defer stopSignals()
+
+	modulusBits := 0
+	if cfg.crtReconstruct {
+		product := big.NewInt(1)
+		for _, p := range primes {
+			product.Mul(product, p)
+		}
+		// M > |F(n)| as soon as M has more bits than the bound.
+		modulusBits = product.BitLen() - 1
+		if need := crtBoundBits(cfg.n); modulusBits < need {
+			fatal("the product of the primes is too small to reconstruct F(n): add primes",
+				"n", cfg.n, "product_bits", modulusBits, "needed_bits", need)
+		}
+	}
+
+	slog.Info("computing residues", "n", cfg.n, "primes", len(primes))
+	residues, err := crtResidues(ctx, cfg.n, primes)
+	if err != nil {
+		fatal("failed to compute the residues", "error", err)
+	}
+	for i, p := range primes {
+		fmt.Printf("F(%d) mod %s = %s\n", cfg.n, p, residues[i])
+	}
+
+	if cfg.crtReconstruct {
+		v, err := crtReconstruct(cfg.n, residues, primes)
+		if err != nil {
+			fatal("CRT reconstruction failed", "error", err)
+		}
+		fmt.Printf("\n📊 F(%d) reconstructed from %d residues (product of the primes ≥ 2^%d)\n", cfg.n, len(primes), modulusBits)
+		printFibResultDetails(v, v.Text(cfg.base), "F", cfg.n, 0, cfg.base, cfg.sciDigits)
+	}
+}
@@ -0,0 +1,64 @@
+// crt_test.go
+
+package main
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/agbruneau/FibJule/fib"
+)
+
+// TestParsePrimes verifies the parsing of the -crt list.
+func TestParsePrimes(t *testing.T) {
+	primes, err := parsePrimes("1000000007, 998244353,2")
+	if err != nil || len(primes) != 3 || primes[1].Int64() != 998244353 {
+		t.Fatalf("expected 3 primes, got %v (err=%v)", primes, err)
+	}
+	for _, spec := range []string{"", "7,", "x", "-7", "1", "9", "7,7", "18446744073709551616"} {
+		if _, err := parsePrimes(spec); err == nil {
+			t.Errorf("expected an error for %q, but got none", spec)
+		}
+	}
+}
+
+// TestCRTReconstruct checks that F(n), rebuilt from its residues modulo
+// primes whose product covers crtBoundBits(n), matches Fast Doubling, for
+// positive and negative n of moderate size.
+func TestCRTReconstruct(t *testing.T) {
+	// Primes just below 2^61, enough for the largest n below.
+	var primes []*big.Int
+	for p := new(big.Int).Lsh(big.NewInt(1), 61); len(primes) < 30; {
+		if p.Sub(p, big.NewInt(1)); p.ProbablyPrime(20) {
+			primes = append(primes, new(big.Int).Set(p))
+		}
+	}
+
+	ctx := context.Background()
+	for _, n := range []int{0, 1, 2, 3, 80, 1000, 2500, -1, -2, -99, -100, -2500} {
+		want, err := fib.FastDoubling(ctx, n)
+		if err != nil {
+			t.Fatalf("FastDoubling(%d): %v", n, err)
+		}
+		if bound := crtBoundBits(n); want.BitLen() >= bound {
+			t.Errorf("crtBoundBits(%d) = %d, but F(%d) has %d bits", n, bound, n, want.BitLen())
+		}
+
+		count := crtBoundBits(n)/60 + 1 // Each prime is at least 2^60
+		residues, err := crtResidues(ctx, n, primes[:count])
+		if err != nil {
+			t.Fatalf("crtResidues(%d): %v", n, err)
+		}
+		got, err := crtReconstruct(n, residues, primes[:count])
+		if err != nil || got.Cmp(want) != 0 {
+			t.Errorf("crtReconstruct(%d) = %v (err=%v), expected %v", n, got, err, want)
+		}
+	}
+
+	// Moduli sharing a factor cannot be combined.
+	residues := []*big.Int{big.NewInt(1), big.NewInt(1)}
+	if _, err := crtReconstruct(5, residues, []*big.Int{big.NewInt(6), big.NewInt(9)}); err == nil {
+		t.Error("expected an error for moduli that are not coprime, but got none")
+	}
+}
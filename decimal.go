@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"math/big"
+)
+
+// ------------------------------------------------------------
+// Streaming Decimal Conversion
+// ------------------------------------------------------------
+//
+// Concept:
+// `big.Int.Text(10)` builds the whole decimal string at once: for F(n) with
+// hundreds of millions of digits, that is one gigantic allocation on top of
+// the number itself. writeDecimal instead splits the number by powers of ten,
+// v = hi·10^k + lo, writes hi and then lo (zero-padded to exactly k digits),
+// recursing until the pieces are small enough for Text(10).
+// Digits are thus produced most-significant first and written out as they go.
+// The extra memory is the quotients and remainders along one recursion path
+// plus the powers 10^(leaf·2^i), i.e. a few times the binary size of v, never
+// its (2.4 times larger) decimal string.
+//
+// This saves memory, not time: math/big already converts large numbers by
+// the same divide-and-conquer over cached powers of ten, with its recursive
+// division, and Text(10) remains about 1.3 to 2 times faster than
+// writeDecimal on F(10^6) and F(10^7) (see BenchmarkDecimalConversion).
+
+// decimalLeafDigits is the size, in digits, of the pieces converted directly
+// with Text(10). The powers used for splitting are 10^(decimalLeafDigits·2^i).
+const decimalLeafDigits = 512
+
+// writeDecimal writes the decimal representation of v to w. The output is
+// byte-identical to v.Text(10), but never holds the full string in memory.
+func writeDecimal(w io.Writer, v *big.Int) error {
+	bw := bufio.NewWriter(w) // Returns w itself if it is already a large enough *bufio.Writer
+
+	if v.Sign() < 0 {
+		if err := bw.WriteByte('-'); err != nil {
+			return err
+		}
+		v = new(big.Int).Neg(v)
+	}
+
+	// powers[i] = 10^(decimalLeafDigits·2^i), up to the first one whose square exceeds v.
+	powers := []*big.Int{new(big.Int).Exp(big.NewInt(10), big.NewInt(decimalLeafDigits), nil)}
+	level := -1
+	if v.Cmp(powers[0]) >= 0 {
+		level = 0
+		for {
+			next := new(big.Int).Mul(powers[level], powers[level])
+			if next.Cmp(v) > 0 {
+				break
+			}
+			powers = append(powers, next)
+			level++
+		}
+	}
+
+	if err := writeDecimalChunk(bw, v, powers, level, 0); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// writeDecimalChunk writes v, which must be lower than powers[level]², using
+// exactly width digits (zero-padded on the left), or without padding when
+// width is 0. A level of -1 means v < powers[0] and is converted directly.
+func writeDecimalChunk(w *bufio.Writer, v *big.Int, powers []*big.Int, level, width int) error {
+	if level < 0 {
+		text := v.Text(10)
+		if width > 0 && len(text) < width {
+			if err := writeZeros(w, width-len(text)); err != nil {
+				return err
+			}
+		}
+		_, err := w.WriteString(text)
+		return err
+	}
+
+	hi, lo := new(big.Int).QuoRem(v, powers[level], new(big.Int))
+	loWidth := decimalLeafDigits << level // lo < powers[level] has at most this many digits
+
+	if width == 0 && hi.Sign() == 0 {
+		// Leading chunk with no high part: no digits and no padding for hi.
+		return writeDecimalChunk(w, lo, powers, level-1, 0)
+	}
+	hiWidth := 0
+	if width > 0 {
+		hiWidth = width - loWidth
+	}
+	if err := writeDecimalChunk(w, hi, powers, level-1, hiWidth); err != nil {
+		return err
+	}
+	return writeDecimalChunk(w, lo, powers, level-1, loWidth)
+}
+
+// writeZeros writes count '0' characters to w.
+func writeZeros(w *bufio.Writer, count int) error {
+	for ; count > 0; count-- {
+		if err := w.WriteByte('0'); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decimalDigits returns the number of decimal digits of |v|, like
+// len(v.Text(10)) without the sign, but without building the string.
+//
+// With b = |v|.BitLen(), |v| lies in [2^(b-1), 2^b), so its digit count is
+// d = ⌊(b-1)·log10(2)⌋ + 1 or d+1; a single comparison with 10^d decides.
+// The loops only correct a floating-point estimate landing on the wrong side.
+func decimalDigits(v *big.Int) int {
+	if v.Sign() == 0 {
+		return 1
+	}
+	abs := new(big.Int).Abs(v)
+	ten := big.NewInt(10)
+	d := int(float64(abs.BitLen()-1)*math.Log10(2)) + 1
+	p := new(big.Int).Exp(ten, big.NewInt(int64(d-1)), nil) // 10^(d-1)
+	for abs.Cmp(p) < 0 {
+		p.Quo(p, ten)
+		d--
+	}
+	for p.Mul(p, ten); abs.Cmp(p) >= 0; p.Mul(p, ten) {
+		d++
+	}
+	return d
+}
@@ -0,0 +1,86 @@
+// decimal_test.go
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+// TestWriteDecimal compares writeDecimal with Text(10) on edge cases and on
+// random large values, including values with long runs of inner zeros.
+func TestWriteDecimal(t *testing.T) {
+	ten := big.NewInt(10)
+	values := []*big.Int{
+		big.NewInt(0),
+		big.NewInt(7),
+		big.NewInt(-12345),
+		new(big.Int).Exp(ten, big.NewInt(decimalLeafDigits), nil),                                    // 1 followed by zeros
+		new(big.Int).Sub(new(big.Int).Exp(ten, big.NewInt(4*decimalLeafDigits), nil), big.NewInt(1)), // all nines
+		new(big.Int).Add(new(big.Int).Exp(ten, big.NewInt(5000), nil), big.NewInt(3)),                // inner zeros
+	}
+
+	rng := rand.New(rand.NewSource(1)) // Deterministic seed
+	for i := 0; i < 20; i++ {
+		bits := 1 + rng.Intn(200000)
+		v := new(big.Int).Rand(rng, new(big.Int).Lsh(big.NewInt(1), uint(bits)))
+		if i%5 == 0 {
+			v.Neg(v)
+		}
+		values = append(values, v)
+	}
+
+	for _, v := range values {
+		var buf bytes.Buffer
+		if err := writeDecimal(&buf, v); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if want := v.Text(10); buf.String() != want {
+			t.Errorf("writeDecimal differs from Text(10) for a %d-bit value (got %d bytes, expected %d)", v.BitLen(), buf.Len(), len(want))
+		}
+	}
+}
+
+// TestDecimalDigits compares decimalDigits with the length of Text(10),
+// especially around powers of ten.
+func TestDecimalDigits(t *testing.T) {
+	ten := big.NewInt(10)
+	for _, e := range []int64{0, 1, 2, 15, 16, 300, 4000} {
+		p := new(big.Int).Exp(ten, big.NewInt(e), nil)
+		for _, delta := range []int64{-1, 0, 1} {
+			v := new(big.Int).Add(p, big.NewInt(delta))
+			want := len(new(big.Int).Abs(v).Text(10))
+			if got := decimalDigits(v); got != want {
+				t.Errorf("for %s, expected %d digits, but got %d", abbreviate(v.Text(10)), want, got)
+			}
+			if got := decimalDigits(v.Neg(v)); got != want {
+				t.Errorf("for -%s, expected %d digits, but got %d", abbreviate(v.Text(10)), want, got)
+			}
+		}
+	}
+}
+
+// BenchmarkDecimalConversion compares writeDecimal with Text(10) on
+// F(10^6): go test -run '^$' -bench DecimalConversion
+func BenchmarkDecimalConversion(b *testing.B) {
+	v, err := fibFastDoubling(context.Background(), 1000000, newIntPool())
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Run("Text", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_ = v.Text(10)
+		}
+	})
+	b.Run("writeDecimal", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if err := writeDecimal(io.Discard, v); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
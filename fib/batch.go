@@ -0,0 +1,161 @@
+package fib
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"slices"
+)
+
+// batchStepLimit is the largest gap between two requested indices that Batch
+// bridges with additions, one per index, instead of doubling steps. A
+// doubling step costs three squarings, each worth many additions once the
+// values span a few hundred words.
+const batchStepLimit = 64
+
+// Batch calculates F(n) for every index of ns, in the same order, sharing
+// the work between indices.
+//
+// Concept:
+// Fast Doubling reaches n through the prefixes of its binary representation:
+// from F(k), F(k+1), one doubling step gives F(2k), F(2k+1), and one more
+// addition F(2k+2). Indices whose binary representations share a prefix
+// share the steps up to it: those of 2000000 extend those of 1000000 by
+// one. The prefixes of all the indices form a binary tree, walked depth
+// first so that each step is taken once: a node computes the doubling step
+// once for both of its children. An index within batchStepLimit of the previous one is instead
+// reached by additions from it, one per unit of the gap.
+//
+// Implementation:
+// The walk holds the pair of values of each node on the current path, and
+// a copy of the doubled pair where the path branches, so its memory stays
+// within a few times the size of the largest value. Duplicates are computed
+// once, and a negative index is derived from |n| with F(-n) = (-1)^(n+1)·F(n).
+func Batch(ctx context.Context, ns []int, opts ...Option) ([]*big.Int, error) {
+	c := newConfig(opts)
+
+	targets := make([]int, 0, len(ns))
+	for _, n := range ns {
+		if n < 0 && -n < 0 { // -math.MinInt overflows
+			return nil, fmt.Errorf("%w: %d", ErrIndexOutOfRange, n)
+		}
+		targets = append(targets, abs(n))
+	}
+	slices.Sort(targets)
+	targets = slices.Compact(targets)
+
+	// Split the targets into anchors, computed by doubling, and the indices
+	// following them closely, reached by additions.
+	var anchors []int
+	for i, t := range targets {
+		if i == 0 || t-targets[i-1] > batchStepLimit {
+			anchors = append(anchors, t)
+		}
+	}
+
+	// pairs holds F(t) and F(t+1) for every anchor, then every target.
+	pairs := make(map[int][2]*big.Int, len(targets))
+	if err := batchWalk(ctx, c, anchors, pairs); err != nil {
+		return nil, err
+	}
+	for i, t := range targets {
+		if _, ok := pairs[t]; ok {
+			continue
+		}
+		prev := pairs[targets[i-1]]
+		a, b := new(big.Int).Set(prev[0]), new(big.Int).Set(prev[1])
+		for k := targets[i-1]; k < t; k++ {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			a.Add(a, b)
+			a, b = b, a
+			c.count(0, 1)
+		}
+		pairs[t] = [2]*big.Int{a, b}
+	}
+
+	results := make([]*big.Int, len(ns))
+	for i, n := range ns {
+		v := new(big.Int).Set(pairs[abs(n)][0])
+		if n < 0 {
+			negate(v, -n, nil)
+		}
+		results[i] = v
+	}
+	c.report(100.0)
+	return results, nil
+}
+
+// batchWalk stores in pairs F(t) and F(t+1) for every index of the sorted
+// anchors, walking the tree of their binary prefixes depth first.
+func batchWalk(ctx context.Context, c *config, anchors []int, pairs map[int][2]*big.Int) error {
+	record := func(k int, a, b *big.Int) {
+		if _, found := slices.BinarySearch(anchors, k); found {
+			pairs[k] = [2]*big.Int{new(big.Int).Set(a), new(big.Int).Set(b)}
+		}
+	}
+	record(0, big.NewInt(0), big.NewInt(1))
+	if len(anchors) == 0 || anchors[len(anchors)-1] == 0 {
+		return nil
+	}
+
+	// The nodes of the tree are the prefixes k of the anchors; the children
+	// of k are 2k and 2k+1 when they are prefixes too. The tree is rooted at
+	// 1, the first bit of every positive index.
+	prefixes := make(map[int]bool)
+	for _, t := range anchors {
+		for k := t; k > 0; k >>= 1 {
+			prefixes[k] = true
+		}
+	}
+
+	t1 := c.pool.Get().(*big.Int)
+	t2 := c.pool.Get().(*big.Int)
+	defer putInt(c.pool, t1)
+	defer putInt(c.pool, t2)
+
+	var walk func(k int, a, b *big.Int) error
+	walk = func(k int, a, b *big.Int) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		record(k, a, b)
+		even, odd := prefixes[2*k], prefixes[2*k+1]
+		if !even && !odd {
+			return nil
+		}
+
+		// Doubling step, as in doublingPair: a = F(2k), b = F(2k+1).
+		t1.Sub(b, a)   // t1 = F(k−1)
+		t2.Mul(t1, t1) // t2 = F(k−1)²
+		t1.Mul(a, a)   // t1 = F(k)²
+		a.Mul(b, b)    // a = F(k+1)²
+		b.Add(a, t1)   // b = F(2k+1)
+		a.Sub(a, t2)   // a = F(2k)
+		c.count(3, 3)
+
+		if even {
+			if !odd {
+				return walk(2*k, a, b)
+			}
+			// The odd child still needs the doubled pair.
+			ea, eb := new(big.Int).Set(a), new(big.Int).Set(b)
+			if err := walk(2*k, ea, eb); err != nil {
+				return err
+			}
+		}
+		a.Add(a, b) // F(2k+2)
+		c.count(0, 1)
+		return walk(2*k+1, b, a)
+	}
+	return walk(1, big.NewInt(1), big.NewInt(1))
+}
+
+// abs returns |n|. The caller rules out math.MinInt.
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
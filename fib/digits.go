@@ -0,0 +1,195 @@
+package fib
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"math/bits"
+)
+
+// EstimateDigits returns the number of decimal digits of F(n) without
+// computing F(n).
+//
+// Concept:
+// Binet's formula gives F(n) = round(φⁿ/√5), so for n >= 2 the number of
+// digits is ⌊n·log10(φ) − log10(√5)⌋ + 1. This costs a few floating-point
+// operations regardless of n. For astronomically large n, where the
+// logarithm falls within float64 rounding error of an integer, the result
+// may be off by one.
+func EstimateDigits(n int) int {
+	if n < 0 {
+		n = -n // |F(-n)| = F(n)
+	}
+	if n < 2 {
+		return 1 // F(0) = 0 and F(1) = 1
+	}
+	phi := (1 + math.Sqrt(5)) / 2
+	return int(math.Floor(float64(n)*math.Log10(phi)-math.Log10(math.Sqrt(5)))) + 1
+}
+
+// EstimateBytes returns an upper bound, within one byte, of the size of |F(n)|
+// in bytes, without computing F(n): since |F(n)| < φ^|n|, its bit length is
+// at most ⌊|n|·log2(φ)⌋ + 1. The product is taken in float64, so that no n,
+// down to math.MinInt, overflows.
+func EstimateBytes(n int) uint64 {
+	return uint64(math.Abs(float64(n))*math.Log2(math.Phi))/8 + 1
+}
+
+// TrailingDigits returns the last k decimal digits of |F(n)|, with leading
+// zeros, or all of its digits when it has at most k.
+//
+// Concept:
+// The last k digits are F(n) mod 10^k, which FastDoublingMod computes
+// exactly on numbers of k digits, however large n is.
+func TrailingDigits(ctx context.Context, n, k int, opts ...Option) (string, error) {
+	n, err := digitsIndex(n, k)
+	if err != nil {
+		return "", err
+	}
+	if EstimateDigits(n) <= k+1 { // The estimate may be off by one
+		s, err := decimalValue(ctx, n, opts)
+		return s[max(len(s)-k, 0):], err
+	}
+	m := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(k)), nil)
+	v, err := FastDoublingMod(ctx, n, m, opts...)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*s", k, v.Text(10)), nil
+}
+
+// LeadingDigits returns the first k decimal digits of |F(n)|, or all of its
+// digits when it has at most k.
+//
+// Concept:
+// F(n) ≈ φⁿ/√5 = 10^(n·log10(φ) − log10(√5)), so its leading digits are
+// those of 10^frac(n·log10(φ) − log10(√5)), whatever the size of F(n).
+// Since big.Float has no logarithm, this power of ten is obtained as
+// φⁿ/√5 / 10^(d−k), d being the number of digits, both evaluated at a
+// precision of k digits plus guard bits rather than the full size of F(n).
+// As with Binet, the result is checked by a second pass at higher precision,
+// and the precision doubled while the two disagree.
+func LeadingDigits(ctx context.Context, n, k int, opts ...Option) (string, error) {
+	n, err := digitsIndex(n, k)
+	if err != nil {
+		return "", err
+	}
+	d := EstimateDigits(n)
+	if d <= k+1 { // The estimate may be off by one
+		s, err := decimalValue(ctx, n, opts)
+		return s[:min(k, len(s))], err
+	}
+
+	prec := uint(float64(k)*math.Log2(10)) + 2*uint(bits.Len(uint(n))) + 64
+	for attempt := 1; attempt <= binetMaxAttempts; attempt++ {
+		head, err := leadingDigits(ctx, n, k, d, prec)
+		if err != nil {
+			return "", err
+		}
+		check, err := leadingDigits(ctx, n, k, d, prec+binetCheckBits)
+		if err != nil {
+			return "", err
+		}
+		if head == check {
+			return head, nil
+		}
+		prec *= 2
+	}
+	return "", fmt.Errorf("leading digits of F(%d) %w after %d attempts", n, ErrUnstable, binetMaxAttempts)
+}
+
+// leadingDigits evaluates ⌊φⁿ/√5 / 10^(d−k)⌋ at the given precision, d
+// being the estimated number of digits of F(n), corrected when the estimate
+// is off.
+func leadingDigits(ctx context.Context, n, k, d int, prec uint) (string, error) {
+	sqrt5 := new(big.Float).SetPrec(prec).SetInt64(5)
+	sqrt5.Sqrt(sqrt5)
+	phi := new(big.Float).SetPrec(prec).SetInt64(1)
+	phi.Add(phi, sqrt5).Quo(phi, big.NewFloat(2))
+
+	x, xExp, err := floatPow(ctx, phi, n)
+	if err != nil {
+		return "", err
+	}
+	x.Quo(x, sqrt5)
+	ten := new(big.Float).SetPrec(prec).SetInt64(10)
+	q := new(big.Float)
+	for {
+		scale, scaleExp, err := floatPow(ctx, ten, d-k)
+		if err != nil {
+			return "", err
+		}
+		q.Quo(x, scale)
+		q.SetMantExp(q, xExp-scaleExp) // About k digits: the exponent is small again
+
+		// The float64 estimate of d drifts for huge n. q is now a number of
+		// moderate size whose magnitude gives the correction.
+		mant := new(big.Float)
+		exp := q.MantExp(mant)
+		m, _ := mant.Float64()
+		digits := int(math.Floor((float64(exp)+math.Log2(m))*math.Log10(2))) + 1
+		if digits >= k-1 && digits <= k+1 {
+			break
+		}
+		d += digits - k
+	}
+
+	v, _ := q.Int(nil)
+	s := v.Text(10)
+	if len(s) > k {
+		s = s[:k]
+	}
+	if len(s) < k { // d was overestimated: one more digit is needed
+		q.Mul(q, big.NewFloat(10))
+		v, _ = q.Int(nil)
+		s = v.Text(10)
+	}
+	return s, nil
+}
+
+// floatPow returns x^e, for e >= 0, as mant·2^exp by square-and-multiply at
+// the precision of x. The exponent is kept apart from mant, in [0.5, 1),
+// because x^e may overflow the exponent range of big.Float for large e.
+func floatPow(ctx context.Context, x *big.Float, e int) (mant *big.Float, exp int, err error) {
+	mant = new(big.Float).SetPrec(x.Prec()).SetInt64(1)
+	for i := bits.Len(uint(e)) - 1; i >= 0; i-- {
+		// Cooperative context cancellation check
+		select {
+		case <-ctx.Done():
+			return nil, 0, ctx.Err()
+		default:
+		}
+		mant.Mul(mant, mant)
+		exp *= 2
+		if (uint(e)>>i)&1 == 1 {
+			mant.Mul(mant, x)
+		}
+		exp += mant.MantExp(mant)
+	}
+	return mant, exp, nil
+}
+
+// digitsIndex validates k and returns |n|, since |F(-n)| = F(n).
+func digitsIndex(n, k int) (int, error) {
+	if k < 1 {
+		return 0, fmt.Errorf("%w: number of digits must be at least 1. Received: %d", ErrInvalidArgument, k)
+	}
+	if n < 0 {
+		if -n < 0 { // -math.MinInt overflows
+			return 0, fmt.Errorf("%w: %d", ErrIndexOutOfRange, n)
+		}
+		n = -n
+	}
+	return n, nil
+}
+
+// decimalValue returns the decimal representation of F(n), computed in full
+// since it has few digits.
+func decimalValue(ctx context.Context, n int, opts []Option) (string, error) {
+	v, err := FastDoubling(ctx, n, opts...)
+	if err != nil {
+		return "", err
+	}
+	return v.Text(10), nil
+}
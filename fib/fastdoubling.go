@@ -0,0 +1,263 @@
+package fib
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// ------------------------------------------------------------
+// Fibonacci Calculation Algorithms
+// ------------------------------------------------------------
+
+// FastDoubling calculates F(n) using the "Fast Doubling" algorithm.
+//
+// Concept:
+// A very efficient algorithm based on mathematical identities that allow
+// transitioning from F(k) and F(k+1) to F(2k) and F(2k+1) in a few operations:
+// F(2k)   = F(k) * [2*F(k+1) – F(k)]
+// F(2k+1) = F(k)² + F(k+1)²
+//
+// Implementation:
+// The algorithm iterates through the bits of index `n` from left to right (most
+// significant to least significant). At each step, it applies the "doubling" formulas.
+// If the current bit of `n` is 1, it takes an additional step to advance.
+// F(2k) is evaluated as F(k+1)² − F(k−1)², which equals the identity above
+// with the ×2 folded into the subtraction: each step then costs three
+// squarings instead of two squarings and a general product, and needs no
+// shift (about 20% faster at n = 10⁶ and 10⁷).
+//
+// Strengths/Weaknesses:
+// Extremely fast and efficient (O(log n) complexity). It's one of the best
+// algorithms for this problem. It heavily uses the `sync.Pool` to optimize
+// `big.Int` allocations.
+//
+// With WithParallelMultiplication, the independent products of each doubling
+// step run concurrently once the operands are large enough.
+//
+// Negative indices are supported through F(-n) = (-1)^(n+1)·F(n).
+func FastDoubling(ctx context.Context, n int, opts ...Option) (*big.Int, error) {
+	result := new(big.Int)
+	if err := fastDoublingInto(ctx, n, nil, newConfig(opts), result, nil); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// FastDoublingInto calculates F(n) like FastDoubling, but stores it in dst
+// instead of allocating a new result.
+//
+// Callers computing many values (benchmark loops, library users) can reuse
+// the same dst, whose backing array then grows once and is recycled.
+func FastDoublingInto(ctx context.Context, dst *big.Int, n int, opts ...Option) error {
+	return fastDoublingInto(ctx, n, nil, newConfig(opts), dst, nil)
+}
+
+// FastDoublingPair calculates the consecutive pair F(n), F(n+1) in a single pass.
+//
+// The algorithm already tracks a = F(k) and b = F(k+1) at every step, so
+// returning both costs nothing more than FastDoubling, whereas calling it twice
+// would double the work. FastDoubling is itself a thin wrapper over the same
+// loop that skips the second value.
+func FastDoublingPair(ctx context.Context, n int, opts ...Option) (*big.Int, *big.Int, error) {
+	fn, fn1 := new(big.Int), new(big.Int)
+	if err := fastDoublingInto(ctx, n, nil, newConfig(opts), fn, fn1); err != nil {
+		return nil, nil, err
+	}
+	return fn, fn1, nil
+}
+
+// FastDoublingMod calculates F(n) mod m using the "Fast Doubling" algorithm.
+//
+// Concept:
+// The doubling identities hold in modular arithmetic, so every intermediate
+// product and sum can be reduced modulo m. The `big.Int` values then never
+// exceed m² in size, no matter how large n is.
+// The sequence F(i) mod m is periodic (the Pisano period π(m)). For small m,
+// the period is computed first so that n can be replaced by n mod π(m),
+// further reducing the number of iterations.
+func FastDoublingMod(ctx context.Context, n int, m *big.Int, opts ...Option) (*big.Int, error) {
+	if err := checkModulus(m); err != nil {
+		return nil, err
+	}
+	result := new(big.Int)
+	if err := fastDoublingInto(ctx, reduceByPisano(n, m), m, newConfig(opts), result, nil); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// FastDoublingModBig calculates F(n) mod m like FastDoublingMod, for an
+// index n of any size, e.g. 10^20, beyond the range of int.
+//
+// Concept:
+// F(n) itself would have about 0.694·n bits, far too many to compute, but
+// its residue stays below m. The doubling loop only reads the bits of n, so
+// it runs unchanged over those of a *big.Int: about log2(n) steps, each
+// costing three products of numbers below m.
+//
+// Implementation:
+// As in FastDoublingMod, a small m first reduces n modulo the Pisano period
+// π(m), leaving an int index. Otherwise, an n within int is handed to
+// FastDoublingMod, and a larger one to the doubling loop over its bits, as
+// is math.MinInt, whose absolute value FastDoublingMod cannot take. A
+// negative n is computed from |n| with F(-k) = (-1)^(k+1)·F(k).
+func FastDoublingModBig(ctx context.Context, n, m *big.Int, opts ...Option) (*big.Int, error) {
+	if err := checkModulus(m); err != nil {
+		return nil, err
+	}
+	if n == nil {
+		return nil, fmt.Errorf("%w: index n must not be nil", ErrInvalidArgument)
+	}
+	if m.IsUint64() && m.Uint64() <= pisanoMaxModulus {
+		// Euclidean modulus: a negative n maps to a non-negative index.
+		p := new(big.Int).SetUint64(PisanoPeriod(m.Uint64()))
+		return FastDoublingMod(ctx, int(new(big.Int).Mod(n, p).Int64()), m, opts...)
+	}
+	if n.IsInt64() && int64(int(n.Int64())) == n.Int64() && int(n.Int64()) != math.MinInt {
+		return FastDoublingMod(ctx, int(n.Int64()), m, opts...)
+	}
+
+	c := newConfig(opts)
+	be := bigIntBackend{c.pool}
+	k := new(big.Int).Abs(n)
+	a, b, err := doublingPairIndex(ctx, k, be, c, func(v *big.Int) { v.Mod(v, m) })
+	if err != nil {
+		return nil, err
+	}
+	defer be.Put(a)
+	defer be.Put(b)
+
+	result := new(big.Int).Set(a)
+	if n.Sign() < 0 && k.Bit(0) == 0 {
+		result.Neg(result).Mod(result, m)
+	}
+	return result, nil
+}
+
+// checkModulus validates the modulus of the modular variants.
+func checkModulus(m *big.Int) error {
+	if m == nil || m.Sign() <= 0 {
+		return fmt.Errorf("%w: %v", ErrInvalidModulus, m)
+	}
+	return nil
+}
+
+// reduceByPisano replaces n by n mod π(m) when m is small enough for the
+// Pisano period to be computed cheaply. Any sequence following the Fibonacci
+// recurrence is periodic modulo m with a period dividing π(m). The recurrence
+// runs backwards too, so this holds for negative indices as well, which are
+// mapped to a non-negative one.
+func reduceByPisano(n int, m *big.Int) int {
+	if m.IsUint64() && m.Uint64() <= pisanoMaxModulus {
+		// π(m) <= 6m, so the reduction can only help when |n| exceeds that bound.
+		mod := m.Uint64()
+		if n > 0 && uint64(n) > 6*mod {
+			return int(uint64(n) % PisanoPeriod(mod))
+		}
+		if n < 0 && uint64(-n) > 6*mod {
+			p := PisanoPeriod(mod)
+			return int((p - uint64(-n)%p) % p)
+		}
+	}
+	return n
+}
+
+// negate turns v = F(k), k >= 0, into F(-k) in place, using the
+// negafibonacci identity F(-k) = (-1)^(k+1)·F(k): the sign flips for even k.
+// When m is not nil, the result is brought back into [0, m).
+func negate(v *big.Int, k int, m *big.Int) {
+	if k%2 == 0 {
+		v.Neg(v)
+	}
+	if m != nil {
+		v.Mod(v, m)
+	}
+}
+
+// fastDoublingInto is the shared implementation behind the Fast Doubling
+// family. It stores F(n) in fn and, when fn1 is not nil, F(n+1) in fn1.
+// When m is not nil, both values are reduced modulo m.
+//
+// A negative n is computed from k = -n: F(k) and F(k+1) − F(k) = F(k-1)
+// give F(n) = F(-k) and F(n+1) = F(-(k-1)) once their signs are applied.
+//
+// The doubling loop itself is doublingPair, written against the Integer
+// interface and run here on `*big.Int` through bigIntBackend.
+func fastDoublingInto(ctx context.Context, n int, m *big.Int, c *config, fn, fn1 *big.Int) error {
+	if n < 0 {
+		k := -n
+		if k < 0 { // -math.MinInt overflows
+			return fmt.Errorf("%w: %d", ErrIndexOutOfRange, n)
+		}
+		if err := fastDoublingInto(ctx, k, m, c, fn, fn1); err != nil {
+			return err
+		}
+		if fn1 != nil {
+			fn1.Sub(fn1, fn) // F(k-1)
+			negate(fn1, k-1, m)
+		}
+		negate(fn, k, m)
+		return nil
+	}
+	if n <= 1 {
+		c.report(100.0)
+		fn.SetInt64(int64(n))
+		if m != nil {
+			fn.Mod(fn, m)
+		}
+		if fn1 != nil {
+			fn1.SetInt64(1) // F(1) = F(2) = 1
+			if m != nil {
+				fn1.Mod(fn1, m)
+			}
+		}
+		return nil
+	}
+
+	be := bigIntBackend{c.pool}
+	var reduce func(*big.Int)
+	if m != nil {
+		// big.Int.Mod uses Euclidean modulus, so a negative value is handled too.
+		reduce = func(v *big.Int) { v.Mod(v, m) }
+	}
+	a, b, err := doublingPair(ctx, n, be, c, reduce)
+	if err != nil {
+		return err
+	}
+	defer be.Put(a)
+	defer be.Put(b)
+
+	// Copy into the caller's values to avoid handing out pooled objects.
+	fn.Set(a)
+	if fn1 != nil {
+		fn1.Set(b)
+	}
+	return nil
+}
+
+// pisanoMaxModulus is the largest modulus for which the Pisano period is
+// computed. Finding π(m) costs up to 6m iterations on machine words, which
+// stays well under a few milliseconds below this bound.
+const pisanoMaxModulus = 1 << 20
+
+// PisanoPeriod returns π(m), the period of the Fibonacci sequence modulo m.
+//
+// Concept:
+// The pairs (F(i) mod m, F(i+1) mod m) can take at most m² values, so the
+// sequence must eventually repeat. It is known that π(m) <= 6m; the period
+// ends as soon as the starting pair (0, 1) reappears.
+func PisanoPeriod(m uint64) uint64 {
+	if m <= 1 {
+		return 1
+	}
+	var prev, curr uint64 = 0, 1
+	for i := uint64(1); i <= 6*m; i++ {
+		prev, curr = curr, (prev+curr)%m
+		if prev == 0 && curr == 1 {
+			return i
+		}
+	}
+	return 6 * m // Unreachable in theory, kept as a safe upper bound.
+}
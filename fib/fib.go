@@ -0,0 +1,231 @@
+// Package fib provides the Fibonacci algorithms behind the FibJule CLI as an
+// importable library.
+//
+// The functions are usable on their own, without flag parsing or console
+// output. Memory reuse and progress reporting are optional and configured
+// through functional options:
+//
+//	v, err := fib.FastDoubling(ctx, 100000)
+//	v, err := fib.FastDoubling(ctx, 100000, fib.WithPool(pool), fib.WithProgress(fn))
+package fib
+
+import (
+	"errors"
+	"math/big"
+	"sync"
+)
+
+// ------------------------------------------------------------
+// Errors
+// ------------------------------------------------------------
+//
+// The errors of the package wrap one of the sentinels below with %w, so that
+// callers classify them with errors.Is rather than by their text. A cancelled
+// calculation returns the error of its context, context.Canceled or
+// context.DeadlineExceeded, which matches none of them.
+
+var (
+	// ErrIndexOutOfRange reports an index the function does not accept: a
+	// negative one where only n >= 0 is defined (sums, words, ratios), or
+	// one whose negation or successors overflow int.
+	ErrIndexOutOfRange = errors.New("index n is out of range")
+	// ErrInvalidModulus reports a nil, zero or negative modulus.
+	ErrInvalidModulus = errors.New("modulus must be a positive integer")
+	// ErrInvalidArgument reports any other argument outside its domain.
+	ErrInvalidArgument = errors.New("invalid argument")
+	// ErrUnstable reports a floating-point result that did not settle within
+	// the precision budget of the algorithm.
+	ErrUnstable = errors.New("still unstable")
+)
+
+// ------------------------------------------------------------
+// Functional Options
+// ------------------------------------------------------------
+
+// Option configures an algorithm call.
+type Option func(*config)
+
+// config holds the settings shared by all algorithms.
+type config struct {
+	pool              *sync.Pool        // Pool of *big.Int objects for memory reuse
+	progress          func(pct float64) // Optional progress callback, may be nil
+	parallelMul       bool              // Run independent multiplications concurrently
+	parallelThreshold int               // Minimum operand size, in bits, for parallel products
+	checkInterval     int               // Steps between context checks of the O(n) loops, 0 to derive it from n
+	ops               *OpCounts         // Optional operation counters, may be nil
+	binetPrec         uint              // Fixed precision of Binet, in bits, 0 to derive it from n
+}
+
+// WithPool makes the algorithm take its temporary *big.Int values from pool.
+// The pool must produce *big.Int objects, as returned by NewIntPool.
+func WithPool(pool *sync.Pool) Option {
+	return func(c *config) {
+		c.pool = pool
+	}
+}
+
+// WithProgress registers a callback receiving the progress percentage
+// (0 to 100) as the calculation advances. It is called from the goroutine
+// running the algorithm.
+func WithProgress(fn func(pct float64)) Option {
+	return func(c *config) {
+		c.progress = fn
+	}
+}
+
+// DefaultParallelThreshold is a reasonable operand size, in bits, above which
+// running big.Int multiplications in separate goroutines pays off. Below it,
+// the goroutine scheduling overhead outweighs the gain.
+const DefaultParallelThreshold = 1 << 16
+
+// WithParallelMultiplication runs the independent multiplications of each
+// step concurrently once the operands reach thresholdBits bits. A threshold of
+// 0 parallelizes every step, which is mostly useful for testing.
+func WithParallelMultiplication(thresholdBits int) Option {
+	return func(c *config) {
+		c.parallelMul = true
+		c.parallelThreshold = thresholdBits
+	}
+}
+
+// WithCheckInterval makes the O(n) algorithms check the context for
+// cancellation only every k steps instead of a value derived from n. A k of 1
+// checks at every step; k <= 0 keeps the derived value.
+func WithCheckInterval(k int) Option {
+	return func(c *config) {
+		c.checkInterval = k
+	}
+}
+
+// WithBinetPrecision makes Binet work at exactly bits bits of precision, in a
+// single pass: the verification pass, and the doubling of the precision when
+// it disagrees, are skipped, so too few bits silently yield a wrong value. It
+// is meant for studying the precision-versus-speed tradeoff; bits = 0 keeps
+// the precision derived from n (see BinetPrecision). Other algorithms ignore it.
+func WithBinetPrecision(bits uint) Option {
+	return func(c *config) {
+		c.binetPrec = bits
+	}
+}
+
+// OpCounts tallies the big-integer operations of a calculation, as collected
+// through WithOpCounts.
+type OpCounts struct {
+	Mul int64 // Multiplications, squarings included
+	Add int64 // Additions, subtractions and shifts
+}
+
+// WithOpCounts makes the algorithm add the big-integer operations it performs
+// to counts, which is not reset beforehand. FastDoubling and the functions
+// built on it, Matrix, MatrixFast, BinetExact and Iterative are instrumented;
+// modular reductions and copies are not counted. Each step adds its known
+// cost once, so the counting is negligible next to the arithmetic, and
+// skipped entirely without this option.
+func WithOpCounts(counts *OpCounts) Option {
+	return func(c *config) {
+		c.ops = counts
+	}
+}
+
+// defaultPool is used when no pool is provided through WithPool.
+var defaultPool = NewIntPool()
+
+// newConfig applies opts on top of the default settings.
+func newConfig(opts []Option) *config {
+	c := &config{pool: defaultPool}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.pool == nil {
+		c.pool = defaultPool
+	}
+	return c
+}
+
+// count adds mul multiplications and add additions to the counters, if any.
+func (c *config) count(mul, add int64) {
+	if c.ops != nil {
+		c.ops.Mul += mul
+		c.ops.Add += add
+	}
+}
+
+// report forwards a progress percentage to the callback, if any.
+func (c *config) report(pct float64) {
+	if c.progress != nil {
+		c.progress(pct)
+	}
+}
+
+// ------------------------------------------------------------
+// *big.Int Object Pool for Memory Reuse
+// ------------------------------------------------------------
+//
+// Memory Optimization Concept (sync.Pool):
+// Calculations for large Fibonacci numbers require handling integers
+// that exceed the capacity of standard types (e.g., int64). Go's `math/big.Int` is used.
+// The problem: Creating numerous `big.Int` objects, especially in loops for complex
+// algorithms, puts significant pressure on the Garbage Collector (GC). Frequent GC cycles
+// can pause the program and degrade performance.
+// The solution: A `sync.Pool` provides a way to reuse objects that are otherwise
+// short-lived. Instead of allocating a new `big.Int` each time one is needed,
+// the program requests one from the pool. After the object is used, it's returned
+// to the pool. This drastically reduces the number of allocations and, consequently,
+// the GC overhead, leading to improved performance for memory-intensive operations.
+
+// NewIntPool creates a new sync.Pool specifically for *big.Int objects.
+// The New function in the pool is called when Get is invoked on an empty pool.
+func NewIntPool() *sync.Pool {
+	return &sync.Pool{
+		New: func() interface{} {
+			// Allocate a new *big.Int instance when the pool is empty.
+			return new(big.Int)
+		},
+	}
+}
+
+// NewSizedIntPool creates a pool like NewIntPool, whose new *big.Int objects
+// already have room for F(n+1).
+//
+// A fresh big.Int has no capacity: during the first calculation with a new
+// pool, each temporary is reallocated every time its value outgrows it, about
+// once per doubling step. Allocating the final size up front replaces these
+// reallocations by one per object. The objects all take the size of F(n),
+// even those holding smaller values: this pool is meant for one calculation
+// of F(n), not for modular mode, whose values stay below the modulus.
+// Beyond F(3000000), the objects exceed pooledMaxWords and are dropped
+// rather than put back (see putInt).
+func NewSizedIntPool(n int) *sync.Pool {
+	// F(n+1) takes at most one bit more than F(n).
+	capBits := int(EstimateBytes(n))*8 + 1
+	return &sync.Pool{
+		New: func() interface{} {
+			z := new(big.Int)
+			// Setting the top bit grows the backing array to capBits in a
+			// single allocation; resetting z to 0 keeps the capacity.
+			return z.SetBit(z, capBits, 1).SetInt64(0)
+		},
+	}
+}
+
+// pooledMaxWords is the largest capacity, in words, of a *big.Int handed
+// back to a pool: 2^15 words hold 2^21 bits, about F(3000000).
+const pooledMaxWords = 1 << 15
+
+// putInt hands x back to pool, unless its backing array exceeds
+// pooledMaxWords.
+//
+// A big.Int never shrinks: resetting it to 0 keeps its backing array. Once a
+// calculation of a large F(n) returns, normally or cancelled midway by its
+// context, its temporaries would otherwise stay in the pool with arrays of
+// megabytes, handed to every later calculation however small, and kept
+// alive until two garbage collections empty the pool. Dropping them leaves
+// the garbage collector to reclaim them; the next large calculation
+// allocates its temporaries again, once each, which costs little next to
+// multiplications of that size.
+func putInt(pool *sync.Pool, x *big.Int) {
+	if cap(x.Bits()) > pooledMaxWords {
+		return
+	}
+	pool.Put(x)
+}
@@ -0,0 +1,1598 @@
+// fib_test.go
+
+package fib
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"math"
+	"math/big"
+	"math/bits"
+	"math/rand"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"testing/quick"
+)
+
+// TestFastDoubling verifies FastDoubling with and without options.
+func TestFastDoubling(t *testing.T) {
+	testCases := []struct {
+		n    int
+		want int64
+	}{
+		{0, 0}, {1, 1}, {2, 1}, {7, 13}, {10, 55}, {20, 6765}, {90, 2880067194370816120},
+	}
+
+	ctx := context.Background()
+	for _, tc := range testCases {
+		// Without options: default pool and no progress reporting.
+		got, err := FastDoubling(ctx, tc.n)
+		if err != nil {
+			t.Fatalf("unexpected error for F(%d): %v", tc.n, err)
+		}
+		if got.Cmp(big.NewInt(tc.want)) != 0 {
+			t.Errorf("for F(%d), expected %d, but got %s", tc.n, tc.want, got)
+		}
+
+		// With a private pool.
+		got, err = FastDoubling(ctx, tc.n, WithPool(NewIntPool()))
+		if err != nil {
+			t.Fatalf("unexpected error for F(%d): %v", tc.n, err)
+		}
+		if got.Cmp(big.NewInt(tc.want)) != 0 {
+			t.Errorf("for F(%d) with a pool, expected %d, but got %s", tc.n, tc.want, got)
+		}
+	}
+
+	if _, err := FastDoubling(ctx, math.MinInt); err == nil {
+		t.Error("expected an error for n=math.MinInt, but got none")
+	}
+}
+
+// TestFastDoublingProgress checks that progress is reported and ends at 100%.
+func TestFastDoublingProgress(t *testing.T) {
+	var reports []float64
+	_, err := FastDoubling(context.Background(), 1000, WithProgress(func(pct float64) {
+		reports = append(reports, pct)
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(reports) == 0 {
+		t.Fatal("expected progress reports, but got none")
+	}
+	if last := reports[len(reports)-1]; last != 100.0 {
+		t.Errorf("expected final progress of 100%%, but got %.2f%%", last)
+	}
+}
+
+// TestFastDoublingCancelled checks that a cancelled context stops the calculation.
+func TestFastDoublingCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := FastDoubling(ctx, 1000); err != context.Canceled {
+		t.Errorf("expected context.Canceled, but got %v", err)
+	}
+}
+
+// TestPisanoPeriod checks PisanoPeriod against well-known values.
+func TestPisanoPeriod(t *testing.T) {
+	testCases := []struct {
+		m    uint64
+		want uint64
+	}{
+		{1, 1}, {2, 3}, {3, 8}, {5, 20}, {10, 60}, {1000, 1500},
+	}
+	for _, tc := range testCases {
+		if got := PisanoPeriod(tc.m); got != tc.want {
+			t.Errorf("PisanoPeriod(%d) = %d, expected %d", tc.m, got, tc.want)
+		}
+	}
+}
+
+// TestFastDoublingModBig checks F(n) mod m for indices around and beyond the
+// range of int against the reduction of n by a multiple of the Pisano period:
+// PisanoPeriod for small m, and for a prime p the classic bounds, π(p)
+// dividing p−1 when p ≡ ±1 (mod 5) and 2(p+1) when p ≡ ±2 (mod 5).
+func TestFastDoublingModBig(t *testing.T) {
+	ctx := context.Background()
+	e18, _ := new(big.Int).SetString("1000000000000000000", 10)
+	e20, _ := new(big.Int).SetString("100000000000000000000", 10)
+	indices := []*big.Int{
+		e18,
+		e20,
+		new(big.Int).Add(e20, big.NewInt(1)),
+		new(big.Int).Neg(e20),
+		new(big.Int).Neg(new(big.Int).Add(e20, big.NewInt(1))),
+		big.NewInt(math.MinInt64), // |n| overflows int64
+	}
+	moduli := []struct {
+		m, period uint64
+	}{
+		{10, PisanoPeriod(10)},
+		{1000, PisanoPeriod(1000)},
+		{1000000007, 2 * (1000000007 + 1)}, // ≡ 2 (mod 5)
+		{998244353, 2 * (998244353 + 1)},   // ≡ 3 (mod 5)
+		{1000000009, 1000000009 - 1},       // ≡ 4 (mod 5)
+	}
+	for _, tc := range moduli {
+		m := new(big.Int).SetUint64(tc.m)
+		period := new(big.Int).SetUint64(tc.period)
+		for _, n := range indices {
+			got, err := FastDoublingModBig(ctx, n, m)
+			if err != nil {
+				t.Fatalf("unexpected error for F(%s) mod %d: %v", n, tc.m, err)
+			}
+			want, err := FastDoublingMod(ctx, int(new(big.Int).Mod(n, period).Int64()), m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Cmp(want) != 0 {
+				t.Errorf("F(%s) mod %d = %s, expected %s", n, tc.m, got, want)
+			}
+		}
+	}
+
+	if _, err := FastDoublingModBig(ctx, e20, big.NewInt(0)); err == nil {
+		t.Error("expected an error for a zero modulus")
+	}
+	if _, err := FastDoublingModBig(ctx, nil, big.NewInt(7)); err == nil {
+		t.Error("expected an error for a nil index")
+	}
+}
+
+// TestFastDoublingParallel checks that the parallel multiplication path gives
+// the same results as the sequential one, including in modular mode.
+func TestFastDoublingParallel(t *testing.T) {
+	ctx := context.Background()
+	m := big.NewInt(1000000007)
+	for _, n := range []int{0, 1, 2, 3, 10, 100, 1001, 25000} {
+		want, err := FastDoubling(ctx, n)
+		if err != nil {
+			t.Fatalf("unexpected error for F(%d): %v", n, err)
+		}
+		// A threshold of 0 forces the parallel path at every step.
+		got, err := FastDoubling(ctx, n, WithParallelMultiplication(0))
+		if err != nil {
+			t.Fatalf("unexpected error for F(%d): %v", n, err)
+		}
+		if got.Cmp(want) != 0 {
+			t.Errorf("parallel F(%d) differs from the sequential result", n)
+		}
+
+		gotMod, err := FastDoublingMod(ctx, n, m, WithParallelMultiplication(0))
+		if err != nil {
+			t.Fatalf("unexpected error for F(%d) mod m: %v", n, err)
+		}
+		if wantMod := new(big.Int).Mod(want, m); gotMod.Cmp(wantMod) != 0 {
+			t.Errorf("parallel F(%d) mod m = %s, expected %s", n, gotMod, wantMod)
+		}
+	}
+}
+
+// ------------------------------------------------------------
+// Benchmarks
+// ------------------------------------------------------------
+
+// benchmarkLargeN is large enough for the operands to exceed
+// DefaultParallelThreshold during most of the calculation.
+const benchmarkLargeN = 1000000
+
+// benchmarkMidN is an index for which the O(n) algorithms run in well under
+// a second.
+const benchmarkMidN = 100000
+
+// BenchmarkFastDoublingSequential is the baseline for the parallel variant.
+func BenchmarkFastDoublingSequential(b *testing.B) {
+	ctx := context.Background()
+	pool := NewIntPool()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = FastDoubling(ctx, benchmarkLargeN, WithPool(pool))
+	}
+}
+
+// BenchmarkFastDoublingParallel measures Fast Doubling with concurrent products.
+func BenchmarkFastDoublingParallel(b *testing.B) {
+	ctx := context.Background()
+	pool := NewIntPool()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = FastDoubling(ctx, benchmarkLargeN, WithPool(pool), WithParallelMultiplication(DefaultParallelThreshold))
+	}
+}
+
+// TestNewSizedIntPool checks that the pooled values start at zero with the
+// capacity of F(n+1), and that the algorithms give the same results with it
+// as with the default pool.
+func TestNewSizedIntPool(t *testing.T) {
+	ctx := context.Background()
+	for _, n := range []int{0, 1, 2, 93, 1000, -1001, 100000} {
+		pool := NewSizedIntPool(n)
+		z := pool.Get().(*big.Int)
+		if z.Sign() != 0 || len(z.Bits()) != 0 {
+			t.Fatalf("n=%d: expected a zero value from the pool, got %s", n, z)
+		}
+		want, _ := FastDoubling(ctx, n+1)
+		if capBits := cap(z.Bits()) * bits.UintSize; capBits < want.BitLen() {
+			t.Errorf("n=%d: expected room for the %d bits of F(n+1), got %d", n, want.BitLen(), capBits)
+		}
+		pool.Put(z)
+
+		for name, fn := range map[string]func(context.Context, int, ...Option) (*big.Int, error){
+			"FastDoubling": FastDoubling, "Lucas": Lucas, "Matrix": Matrix, "MatrixFast": MatrixFast,
+		} {
+			want, _ := fn(ctx, n)
+			if got, err := fn(ctx, n, WithPool(pool)); err != nil || got.Cmp(want) != 0 {
+				t.Errorf("%s(%d) with a sized pool = %v (err=%v), expected %s", name, n, got, err, want)
+			}
+		}
+	}
+}
+
+// BenchmarkFastDoublingFreshPool and BenchmarkFastDoublingFreshSizedPool
+// compare the allocations of a calculation with a new pool, as each task of
+// the CLI gets: the temporaries of an empty NewIntPool grow step by step,
+// those of NewSizedIntPool start at their final size.
+func BenchmarkFastDoublingFreshPool(b *testing.B) {
+	benchmarkFreshPool(b, func() *sync.Pool { return NewIntPool() })
+}
+
+func BenchmarkFastDoublingFreshSizedPool(b *testing.B) {
+	benchmarkFreshPool(b, func() *sync.Pool { return NewSizedIntPool(benchmarkLargeN) })
+}
+
+func benchmarkFreshPool(b *testing.B, newPool func() *sync.Pool) {
+	ctx := context.Background()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = FastDoubling(ctx, benchmarkLargeN, WithPool(newPool()))
+	}
+}
+
+// TestPutInt checks that putInt keeps small values in the pool and drops
+// those whose backing array exceeds pooledMaxWords, as left by a cancelled
+// calculation of a large F(n).
+func TestPutInt(t *testing.T) {
+	fresh := 0
+	pool := &sync.Pool{New: func() interface{} { fresh++; return new(big.Int) }}
+
+	small := new(big.Int).Lsh(big.NewInt(1), 1000)
+	putInt(pool, small.SetInt64(0))
+	if z := pool.Get().(*big.Int); z != small || fresh != 0 {
+		t.Errorf("expected the small value back from the pool, got a fresh one")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	// Near the end, the temporaries hold values of millions of bits.
+	_, err := FastDoubling(ctx, 8000000, WithPool(pool), WithProgress(func(pct float64) {
+		if pct >= 95 {
+			cancel()
+		}
+	}))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected a cancellation, got %v", err)
+	}
+	for i := 0; i < 8; i++ {
+		if z := pool.Get().(*big.Int); cap(z.Bits()) > pooledMaxWords {
+			t.Fatalf("the pool returned a value of %d words after the cancellation", cap(z.Bits()))
+		}
+	}
+}
+
+// BenchmarkPoolMixedSizes alternates one large calculation with many small
+// ones on the same pool, and reports the capacity left in the pool after
+// them: putInt keeps it bounded by the small values, instead of the
+// megabytes of the large temporaries.
+func BenchmarkPoolMixedSizes(b *testing.B) {
+	ctx := context.Background()
+	pool := NewIntPool()
+	b.ReportAllocs()
+	var pooled int
+	for i := 0; i < b.N; i++ {
+		_, _ = FastDoubling(ctx, 4000000, WithPool(pool))
+		for j := 0; j < 100; j++ {
+			_, _ = FastDoubling(ctx, 1000, WithPool(pool))
+		}
+
+		// Drain the pool until it allocates, summing the capacities.
+		b.StopTimer()
+		pooled = 0
+		var held []*big.Int
+		for len(held) < 64 {
+			z := pool.Get().(*big.Int)
+			if cap(z.Bits()) == 0 {
+				break
+			}
+			pooled += cap(z.Bits()) * bits.UintSize / 8
+			held = append(held, z)
+		}
+		for _, z := range held {
+			pool.Put(z)
+		}
+		b.StartTimer()
+	}
+	b.ReportMetric(float64(pooled), "pooled-B")
+}
+
+// TestLucas verifies Lucas and LucasMod against well-known values.
+func TestLucas(t *testing.T) {
+	testCases := []struct {
+		n    int
+		want int64
+	}{
+		{0, 2}, {1, 1}, {2, 3}, {5, 11}, {10, 123}, {20, 15127},
+	}
+
+	ctx := context.Background()
+	m := big.NewInt(97)
+	for _, tc := range testCases {
+		got, err := Lucas(ctx, tc.n)
+		if err != nil {
+			t.Fatalf("unexpected error for L(%d): %v", tc.n, err)
+		}
+		if got.Cmp(big.NewInt(tc.want)) != 0 {
+			t.Errorf("for L(%d), expected %d, but got %s", tc.n, tc.want, got)
+		}
+
+		gotMod, err := LucasMod(ctx, tc.n, m)
+		if err != nil {
+			t.Fatalf("unexpected error for L(%d) mod 97: %v", tc.n, err)
+		}
+		if want := tc.want % 97; gotMod.Int64() != want {
+			t.Errorf("for L(%d) mod 97, expected %d, but got %s", tc.n, want, gotMod)
+		}
+	}
+
+	// L(-n) = (-1)^n·L(n)
+	for n, want := range map[int]int64{-1: -1, -2: 3, -5: -11, -10: 123} {
+		if got, err := Lucas(ctx, n); err != nil || got.Int64() != want {
+			t.Errorf("for L(%d), expected %d, but got %v (err=%v)", n, want, got, err)
+		}
+	}
+}
+
+// TestSums checks Sum and SumSquares, and their modular variants, against
+// brute-force summation of the terms.
+func TestSums(t *testing.T) {
+	ctx := context.Background()
+	m := big.NewInt(1000)
+	sum, squares := new(big.Int), new(big.Int)
+	a, b := big.NewInt(0), big.NewInt(1) // a = F(n), b = F(n+1)
+	for n := 0; n <= 300; n++ {
+		sum.Add(sum, a)
+		squares.Add(squares, new(big.Int).Mul(a, a))
+		a.Add(a, b)
+		a, b = b, a
+
+		for _, tc := range []struct {
+			name    string
+			compute func(context.Context, int, ...Option) (*big.Int, error)
+			mod     func(context.Context, int, *big.Int, ...Option) (*big.Int, error)
+			want    *big.Int
+		}{
+			{"Sum", Sum, SumMod, sum},
+			{"SumSquares", SumSquares, SumSquaresMod, squares},
+		} {
+			if got, err := tc.compute(ctx, n); err != nil || got.Cmp(tc.want) != 0 {
+				t.Fatalf("%s(%d): expected %s, got %v (err=%v)", tc.name, n, tc.want, got, err)
+			}
+			want := new(big.Int).Mod(tc.want, m)
+			if got, err := tc.mod(ctx, n, m); err != nil || got.Cmp(want) != 0 {
+				t.Fatalf("%sMod(%d, 1000): expected %s, got %v (err=%v)", tc.name, n, want, got, err)
+			}
+		}
+	}
+
+	// Beyond 6m, the modular variants reduce n by the Pisano period first.
+	seven := big.NewInt(7)
+	full, _ := Sum(ctx, 1000)
+	if got, err := SumMod(ctx, 1000, seven); err != nil || got.Cmp(full.Mod(full, seven)) != 0 {
+		t.Errorf("SumMod(1000, 7): expected %s, got %v (err=%v)", full, got, err)
+	}
+	full, _ = SumSquares(ctx, 1000)
+	if got, err := SumSquaresMod(ctx, 1000, seven); err != nil || got.Cmp(full.Mod(full, seven)) != 0 {
+		t.Errorf("SumSquaresMod(1000, 7): expected %s, got %v (err=%v)", full, got, err)
+	}
+
+	if _, err := Sum(ctx, -1); err == nil {
+		t.Error("expected an error for a negative index")
+	}
+	if _, err := SumSquares(ctx, -1); err == nil {
+		t.Error("expected an error for a negative index")
+	}
+}
+
+// TestGeneralized checks that seeds (0, 1) and (2, 1) reproduce F(n) and
+// L(n), and seed (3, 7) the brute-force sequence, on both sides of 0.
+func TestGeneralized(t *testing.T) {
+	ctx := context.Background()
+	m := big.NewInt(97)
+	for n := -30; n <= 30; n++ {
+		f, _ := FastDoubling(ctx, n)
+		l, _ := Lucas(ctx, n)
+		if got, err := Generalized(ctx, n, big.NewInt(0), big.NewInt(1)); err != nil || got.Cmp(f) != 0 {
+			t.Errorf("G(%d) with seed 0,1: expected F(%d) = %s, got %v (err=%v)", n, n, f, got, err)
+		}
+		if got, err := Generalized(ctx, n, big.NewInt(2), big.NewInt(1)); err != nil || got.Cmp(l) != 0 {
+			t.Errorf("G(%d) with seed 2,1: expected L(%d) = %s, got %v (err=%v)", n, n, l, got, err)
+		}
+	}
+
+	// Brute force for seed 3,7, forwards from G(0), G(1) and backwards with
+	// G(n-2) = G(n) - G(n-1).
+	want := map[int]*big.Int{0: big.NewInt(3), 1: big.NewInt(7)}
+	for n := 2; n <= 100; n++ {
+		want[n] = new(big.Int).Add(want[n-1], want[n-2])
+	}
+	for n := -1; n >= -100; n-- {
+		want[n] = new(big.Int).Sub(want[n+2], want[n+1])
+	}
+	for n, w := range want {
+		if got, err := Generalized(ctx, n, big.NewInt(3), big.NewInt(7)); err != nil || got.Cmp(w) != 0 {
+			t.Errorf("G(%d) with seed 3,7: expected %s, got %v (err=%v)", n, w, got, err)
+		}
+		wantMod := new(big.Int).Mod(w, m)
+		if got, err := GeneralizedMod(ctx, n, big.NewInt(3), big.NewInt(7), m); err != nil || got.Cmp(wantMod) != 0 {
+			t.Errorf("G(%d) mod 97 with seed 3,7: expected %s, got %v (err=%v)", n, wantMod, got, err)
+		}
+	}
+}
+
+// TestIterative verifies Iterative and IterativeMod against Fast Doubling.
+func TestIterative(t *testing.T) {
+	ctx := context.Background()
+	m := big.NewInt(1000)
+	for _, n := range []int{0, 1, 2, 3, 10, 93, 500, 2001} {
+		want, _ := FastDoubling(ctx, n)
+		got, err := Iterative(ctx, n)
+		if err != nil {
+			t.Fatalf("unexpected error for F(%d): %v", n, err)
+		}
+		if got.Cmp(want) != 0 {
+			t.Errorf("for F(%d), expected %s, but got %s", n, want, got)
+		}
+
+		gotMod, err := IterativeMod(ctx, n, m)
+		if err != nil {
+			t.Fatalf("unexpected error for F(%d) mod 1000: %v", n, err)
+		}
+		if wantMod := new(big.Int).Mod(want, m); gotMod.Cmp(wantMod) != 0 {
+			t.Errorf("for F(%d) mod 1000, expected %s, but got %s", n, wantMod, gotMod)
+		}
+	}
+
+	if _, err := Iterative(ctx, math.MinInt); err == nil {
+		t.Error("expected an error for n=math.MinInt, but got none")
+	}
+
+	// An explicit check interval changes nothing but the cancellation latency.
+	want, _ := FastDoubling(ctx, 2001)
+	if got, err := Iterative(ctx, 2001, WithCheckInterval(7)); err != nil || got.Cmp(want) != 0 {
+		t.Errorf("with a check interval of 7, expected %s, but got %v (err=%v)", want, got, err)
+	}
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := Iterative(cancelled, 100000); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled with the derived check interval, but got %v", err)
+	}
+}
+
+// countingBackend is a Backend over *big.Int counting the values in use,
+// to check that doublingPair hands back everything it takes.
+type countingBackend struct {
+	inUse int
+}
+
+func (b *countingBackend) Get() *big.Int  { b.inUse++; return new(big.Int) }
+func (b *countingBackend) Put(x *big.Int) { b.inUse-- }
+
+// TestDoublingPairBackend runs the generic doubling loop on a custom backend,
+// sequentially and in parallel, and checks the values and their release.
+func TestDoublingPairBackend(t *testing.T) {
+	ctx := context.Background()
+	for _, opts := range [][]Option{nil, {WithParallelMultiplication(0)}} {
+		for _, n := range []int{2, 3, 10, 93, 1000, 4097} {
+			want, _ := FastDoubling(ctx, n)
+			be := &countingBackend{}
+			fn, fn1, err := doublingPair[*big.Int](ctx, n, be, newConfig(opts), nil)
+			if err != nil {
+				t.Fatalf("unexpected error for F(%d): %v", n, err)
+			}
+			if fn.Cmp(want) != 0 {
+				t.Errorf("for F(%d), expected %s, but got %s", n, want, fn)
+			}
+			be.Put(fn)
+			be.Put(fn1)
+			if be.inUse != 0 {
+				t.Errorf("for F(%d), %d value(s) were not handed back", n, be.inUse)
+			}
+		}
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	be := &countingBackend{}
+	if _, _, err := doublingPair[*big.Int](cancelled, 1000, be, newConfig(nil), nil); !errors.Is(err, context.Canceled) || be.inUse != 0 {
+		t.Errorf("expected context.Canceled with every value handed back, got %v (%d in use)", err, be.inUse)
+	}
+}
+
+// TestCheckInterval checks that the derived interval shrinks as the operands
+// grow, stays within its bounds, and follows the modulus in modular mode.
+func TestCheckInterval(t *testing.T) {
+	if k := checkInterval(100, nil); k != maxCheckInterval {
+		t.Errorf("expected the maximum interval for small n, got %d", k)
+	}
+	if k := checkInterval(1<<40, nil); k != 1 {
+		t.Errorf("expected an interval of 1 for huge n, got %d", k)
+	}
+	small, large := checkInterval(10000000, nil), checkInterval(100000000, nil)
+	if !(large < small && small < maxCheckInterval) {
+		t.Errorf("expected the interval to shrink with n, got %d then %d", small, large)
+	}
+	if k := checkInterval(100000000, big.NewInt(1000)); k != maxCheckInterval {
+		t.Errorf("expected the modulus to bound the operands, got %d", k)
+	}
+}
+
+// BenchmarkIterativeCheckEveryStep and BenchmarkIterativeDerivedCheck show
+// the overhead of checking a cancellable context at every addition for a
+// mid-size n.
+func BenchmarkIterativeCheckEveryStep(b *testing.B) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for i := 0; i < b.N; i++ {
+		_, _ = IterativeMod(ctx, benchmarkMidN, big.NewInt(1000000007), WithCheckInterval(1))
+	}
+}
+
+func BenchmarkIterativeDerivedCheck(b *testing.B) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for i := 0; i < b.N; i++ {
+		_, _ = IterativeMod(ctx, benchmarkMidN, big.NewInt(1000000007))
+	}
+}
+
+// TestMemo verifies Memo and MemoMod against Fast Doubling, including an
+// index deep enough to overflow a naively recursive implementation.
+func TestMemo(t *testing.T) {
+	ctx := context.Background()
+	m := big.NewInt(1000)
+	for _, n := range []int{0, 1, 2, 3, 10, 93, 500, 20000} {
+		want, _ := FastDoubling(ctx, n)
+		got, err := Memo(ctx, n)
+		if err != nil {
+			t.Fatalf("unexpected error for F(%d): %v", n, err)
+		}
+		if got.Cmp(want) != 0 {
+			t.Errorf("for F(%d), expected %s, but got %s", n, want, got)
+		}
+
+		gotMod, err := MemoMod(ctx, n, m)
+		if err != nil {
+			t.Fatalf("unexpected error for F(%d) mod 1000: %v", n, err)
+		}
+		if wantMod := new(big.Int).Mod(want, m); gotMod.Cmp(wantMod) != 0 {
+			t.Errorf("for F(%d) mod 1000, expected %s, but got %s", n, wantMod, gotMod)
+		}
+	}
+
+	// Modular values stay small, so a very deep index is cheap to check.
+	if _, err := MemoMod(ctx, 1000000, big.NewInt(1<<40)); err != nil {
+		t.Errorf("unexpected error for a deep index: %v", err)
+	}
+
+	if _, err := Memo(ctx, math.MinInt); err == nil {
+		t.Error("expected an error for n=math.MinInt, but got none")
+	}
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := Memo(cancelled, 1000); err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+// TestRange verifies Range and RangeMod against Fast Doubling.
+func TestRange(t *testing.T) {
+	ctx := context.Background()
+	m := big.NewInt(97)
+	for _, r := range [][2]int{{0, 0}, {0, 20}, {5, 6}, {1000, 1030}} {
+		next := r[0]
+		err := Range(ctx, r[0], r[1], func(i int, v *big.Int) error {
+			if i != next {
+				t.Fatalf("expected index %d, got %d", next, i)
+			}
+			next++
+			if want, _ := FastDoubling(ctx, i); v.Cmp(want) != 0 {
+				t.Errorf("for F(%d), expected %s, but got %s", i, want, v)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error for range %v: %v", r, err)
+		}
+		if next != r[1]+1 {
+			t.Errorf("range %v stopped at %d", r, next)
+		}
+
+		err = RangeMod(ctx, r[0], r[1], m, func(i int, v *big.Int) error {
+			if want, _ := FastDoublingMod(ctx, i, m); v.Cmp(want) != 0 {
+				t.Errorf("for F(%d) mod 97, expected %s, but got %s", i, want, v)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error for range %v mod 97: %v", r, err)
+		}
+	}
+
+	stop := errors.New("stop")
+	calls := 0
+	if err := Range(ctx, 0, 10, func(int, *big.Int) error { calls++; return stop }); err != stop || calls != 1 {
+		t.Errorf("expected the yield error after 1 call, got %v after %d", err, calls)
+	}
+	for _, r := range [][2]int{{-1, 5}, {5, 4}} {
+		if err := Range(ctx, r[0], r[1], func(int, *big.Int) error { return nil }); err == nil {
+			t.Errorf("expected an error for range %v, but got none", r)
+		}
+	}
+}
+
+// TestEstimateDigits compares the analytic digit count with the exact one.
+func TestEstimateDigits(t *testing.T) {
+	ctx := context.Background()
+	for n := 0; n <= 5000; n++ {
+		v, _ := FastDoubling(ctx, n)
+		if want, got := len(v.Text(10)), EstimateDigits(n); got != want {
+			t.Fatalf("for F(%d), expected %d digits, but got %d", n, want, got)
+		}
+	}
+}
+
+// TestEstimateBytes checks that the estimated size bounds the exact one from
+// above by at most one byte, and that the extreme indices do not overflow.
+func TestEstimateBytes(t *testing.T) {
+	ctx := context.Background()
+	for n := -500; n <= 5000; n++ {
+		v, _ := FastDoubling(ctx, n)
+		exact := uint64(v.BitLen()+7) / 8
+		if got := EstimateBytes(n); got < exact || got > exact+1 {
+			t.Fatalf("for F(%d) of %d bytes, estimated %d bytes", n, exact, got)
+		}
+	}
+	for _, n := range []int{math.MaxInt, math.MinInt} {
+		if got := EstimateBytes(n); got < uint64(math.MaxInt/16) {
+			t.Errorf("EstimateBytes(%d) = %d, expected about |n|·log2(φ)/8", n, got)
+		}
+	}
+}
+
+// TestLeadingAndTrailingDigits checks that LeadingDigits and TrailingDigits
+// match the prefix and suffix of the full value, including values with fewer
+// than k digits and trailing digits starting with a zero.
+func TestLeadingAndTrailingDigits(t *testing.T) {
+	ctx := context.Background()
+	for _, n := range []int{0, 1, 10, 100, 1000, 4321, 10000, 12345, 100000, 200000, -99999} { // F(200000) ends with 03125
+		v, _ := FastDoubling(ctx, n)
+		full := new(big.Int).Abs(v).Text(10)
+		for _, k := range []int{1, 5, 50, 200} {
+			want := full[:min(k, len(full))]
+			if got, err := LeadingDigits(ctx, n, k); err != nil || got != want {
+				t.Errorf("LeadingDigits(%d, %d): expected %s, got %s (err=%v)", n, k, want, got, err)
+			}
+			want = full[max(len(full)-k, 0):]
+			if got, err := TrailingDigits(ctx, n, k); err != nil || got != want {
+				t.Errorf("TrailingDigits(%d, %d): expected %s, got %s (err=%v)", n, k, want, got, err)
+			}
+		}
+	}
+
+	// F(10^12) has about 2·10^11 digits: references computed independently
+	// with decimal logarithms and modular exponentiation.
+	if got, err := LeadingDigits(ctx, 1000000000000, 20); err != nil || got != "42584226889958835886" {
+		t.Errorf("unexpected leading digits of F(10^12): %s (err=%v)", got, err)
+	}
+	if got, err := TrailingDigits(ctx, 1000000000000, 20); err != nil || got != "47722470299560546875" {
+		t.Errorf("unexpected trailing digits of F(10^12): %s (err=%v)", got, err)
+	}
+
+	if _, err := LeadingDigits(ctx, 10, 0); err == nil {
+		t.Error("expected an error for k = 0")
+	}
+}
+
+// TestOpCounts pins the operations counted for F(1000), whose index has 10
+// bits: Fast Doubling does 3 products per bit, where Matrix does 8 per product
+// and MatrixFast 3, for the 17 products of the exponentiation of Q to 999.
+func TestOpCounts(t *testing.T) {
+	ctx := context.Background()
+	testCases := []struct {
+		name string
+		fn   func(ctx context.Context, n int, opts ...Option) (*big.Int, error)
+		want OpCounts
+	}{
+		{"FastDoubling", FastDoubling, OpCounts{Mul: 30, Add: 36}}, // 1000 has 6 bits set
+		{"Lucas", Lucas, OpCounts{Mul: 30, Add: 38}},
+		{"Matrix", Matrix, OpCounts{Mul: 112, Add: 56}},        // Q^1000: 9 squarings, 5 products by Q
+		{"MatrixFast", MatrixFast, OpCounts{Mul: 51, Add: 75}}, // 8 products, 9 squarings
+		{"Iterative", Iterative, OpCounts{Add: 1000}},
+	}
+	for _, tc := range testCases {
+		var counts OpCounts
+		if _, err := tc.fn(ctx, 1000, WithOpCounts(&counts)); err != nil {
+			t.Fatalf("%s failed: %v", tc.name, err)
+		}
+		if counts != tc.want {
+			t.Errorf("%s: expected %+v, got %+v", tc.name, tc.want, counts)
+		}
+		// The counters accumulate across calls.
+		tc.fn(ctx, 1000, WithOpCounts(&counts))
+		if counts.Mul != 2*tc.want.Mul || counts.Add != 2*tc.want.Add {
+			t.Errorf("%s: expected the counts to double, got %+v", tc.name, counts)
+		}
+	}
+
+	var counts OpCounts
+	if _, err := FastDoubling(ctx, 1000, WithOpCounts(&counts), WithParallelMultiplication(0)); err != nil || counts.Mul != 30 {
+		t.Errorf("parallel FastDoubling: expected 30 products, got %+v (err=%v)", counts, err)
+	}
+}
+
+// TestIsFibonacci checks IsFibonacci and Index on the terms of the sequence,
+// negafibonacci included, and on their neighbours, which are not terms.
+func TestIsFibonacci(t *testing.T) {
+	ctx := context.Background()
+	for n := -300; n <= 1500; n++ {
+		v, _ := FastDoubling(ctx, n)
+		if !IsFibonacci(v) {
+			t.Fatalf("F(%d) = %s not recognized as a Fibonacci number", n, v)
+		}
+		got, ok := Index(v)
+		if g, _ := FastDoubling(ctx, got); !ok || g.Cmp(v) != 0 {
+			t.Fatalf("Index(F(%d)) = %d, %v", n, got, ok)
+		}
+		if n > 2 && got != n {
+			t.Errorf("Index(F(%d)): expected %d, got %d", n, n, got)
+		}
+		if n < 5 {
+			continue
+		}
+		// Near misses: both neighbours of a term past F(4) = 3 fall strictly
+		// between two terms.
+		for _, d := range []int64{-1, 1} {
+			x := new(big.Int).Add(v, big.NewInt(d))
+			if IsFibonacci(x) {
+				t.Errorf("F(%d)%+d = %s wrongly recognized as a Fibonacci number", n, d, x)
+			}
+			if _, ok := Index(x); ok {
+				t.Errorf("Index(F(%d)%+d) unexpectedly succeeded", n, d)
+			}
+		}
+	}
+
+	// Signs: F(-1) = 1, F(-2) = -1, F(-3) = 2, F(-4) = -3, but -2 = -F(3) and
+	// -5 = -F(5) are not terms.
+	for _, tc := range []struct {
+		x    int64
+		want int
+		ok   bool
+	}{{0, 0, true}, {1, 1, true}, {-1, -2, true}, {-3, -4, true}, {-2, 0, false}, {-5, 0, false}, {4, 0, false}} {
+		got, ok := Index(big.NewInt(tc.x))
+		if got != tc.want || ok != tc.ok || IsFibonacci(big.NewInt(tc.x)) != tc.ok {
+			t.Errorf("Index(%d): expected %d, %v, got %d, %v", tc.x, tc.want, tc.ok, got, ok)
+		}
+	}
+
+	// A large term, whose index the float64 estimate must still find.
+	v, _ := FastDoubling(ctx, 1000003)
+	if got, ok := Index(v); !ok || got != 1000003 {
+		t.Errorf("Index(F(1000003)): expected 1000003, got %d, %v", got, ok)
+	}
+}
+
+// TestBatch checks Batch against Matrix, an independent algorithm, on indices
+// sharing prefixes, close to each other, repeated or negative, and that the
+// indices extending another one cost no extra squaring.
+func TestBatch(t *testing.T) {
+	ctx := context.Background()
+	ns := []int{1000, 0, 1, 2, 3, 100, -7, -8, 1001, 1064, 1065, 2000, 4000, 1 << 20, 1<<20 + 1, 3 << 19, 100, 999}
+	got, err := Batch(ctx, ns)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != len(ns) {
+		t.Fatalf("expected %d values, got %d", len(ns), len(got))
+	}
+	for i, n := range ns {
+		want, _ := Matrix(ctx, n)
+		if got[i].Cmp(want) != 0 {
+			t.Errorf("Batch: F(%d) differs from Matrix", n)
+		}
+	}
+	if got[0] == got[len(got)-1] || got[5] == got[16] {
+		t.Error("expected a distinct value for each requested index")
+	}
+
+	var alone, shared OpCounts
+	if _, err := Batch(ctx, []int{4000}, WithOpCounts(&alone)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Batch(ctx, []int{1000, 2000, 4000}, WithOpCounts(&shared)); err != nil {
+		t.Fatal(err)
+	}
+	if shared.Mul != alone.Mul {
+		t.Errorf("expected F(1000) and F(2000) on the way to F(4000): %d products instead of %d", shared.Mul, alone.Mul)
+	}
+
+	if v, err := Batch(ctx, nil); err != nil || len(v) != 0 {
+		t.Errorf("expected no value and no error for no index, got %v (err=%v)", v, err)
+	}
+	if _, err := Batch(ctx, []int{5, math.MinInt}); err == nil {
+		t.Error("expected an error for math.MinInt")
+	}
+}
+
+// TestRatio checks that F(n+1)/F(n) approaches φ from alternate sides, with
+// a difference shrinking at each step, and that n < 1 is rejected.
+func TestRatio(t *testing.T) {
+	ctx := context.Background()
+	var prev *big.Float
+	for _, n := range []int{1, 2, 3, 4, 5, 10, 20, 50, 100, 500, 1000} {
+		ratio, diff, err := Ratio(ctx, n)
+		if err != nil {
+			t.Fatalf("Ratio(%d): unexpected error: %v", n, err)
+		}
+		if want := 1 - 2*(n%2); diff.Sign() != want { // The sign of ψⁿ, (−1)ⁿ
+			t.Errorf("Ratio(%d): expected a difference of sign %d, got %s", n, want, diff.Text('e', 5))
+		}
+		if r, _ := ratio.Float64(); math.Abs(r-math.Phi) > 1 {
+			t.Errorf("Ratio(%d) = %g, far from φ", n, r)
+		}
+		d := new(big.Float).Abs(diff)
+		if prev != nil && d.Cmp(prev) >= 0 {
+			t.Errorf("Ratio(%d): |difference| %s did not shrink from %s", n, d.Text('e', 5), prev.Text('e', 5))
+		}
+		prev = d
+	}
+	if _, _, err := Ratio(ctx, 0); err == nil {
+		t.Error("expected an error for n = 0")
+	}
+}
+
+// TestNearestIndex checks exact terms, which give a zero delta, and every
+// integer of [−1000, 5000] against a search over the terms F(−30)..F(30):
+// same nearest term, tie broken toward zero. It also checks a large term and
+// its neighbours.
+func TestNearestIndex(t *testing.T) {
+	ctx := context.Background()
+	for n := -300; n <= 1500; n++ {
+		v, _ := FastDoubling(ctx, n)
+		got, delta, err := NearestIndex(ctx, v)
+		if err != nil || delta.Sign() != 0 {
+			t.Fatalf("NearestIndex(F(%d)) = %d, delta %v, err %v", n, got, delta, err)
+		}
+		if want, _ := Index(v); got != want {
+			t.Errorf("NearestIndex(F(%d)): expected the index %d of Index, got %d", n, want, got)
+		}
+	}
+
+	var terms []*big.Int
+	for n := -30; n <= 30; n++ {
+		v, _ := FastDoubling(ctx, n)
+		terms = append(terms, v)
+	}
+	for i := int64(-1000); i <= 5000; i++ {
+		x := big.NewInt(i)
+		var best *big.Int
+		dist := func(v *big.Int) *big.Int { return new(big.Int).Abs(new(big.Int).Sub(x, v)) }
+		for _, v := range terms {
+			if best == nil {
+				best = v
+				continue
+			}
+			if d := dist(v).Cmp(dist(best)); d < 0 || (d == 0 && new(big.Int).Abs(v).Cmp(new(big.Int).Abs(best)) < 0) {
+				best = v
+			}
+		}
+		n, delta, err := NearestIndex(ctx, x)
+		if err != nil {
+			t.Fatalf("NearestIndex(%d): unexpected error: %v", i, err)
+		}
+		term, _ := FastDoubling(ctx, n)
+		if term.Cmp(best) != 0 || new(big.Int).Add(term, delta).Cmp(x) != 0 {
+			t.Fatalf("NearestIndex(%d): expected the term %s, got F(%d) = %s with delta %s", i, best, n, term, delta)
+		}
+	}
+
+	// Between F(1000003) and F(1000004), on either side of the midpoint.
+	lo, _ := FastDoubling(ctx, 1000003)
+	hi, _ := FastDoubling(ctx, 1000004)
+	mid := new(big.Int).Add(lo, hi)
+	mid.Rsh(mid, 1)
+	for _, tc := range []struct {
+		x    *big.Int
+		want int
+	}{{new(big.Int).Add(lo, big.NewInt(12)), 1000003}, {mid, 1000003}, {new(big.Int).Add(mid, big.NewInt(1)), 1000004}, {new(big.Int).Sub(hi, big.NewInt(1)), 1000004}} {
+		n, delta, err := NearestIndex(ctx, tc.x)
+		want, _ := FastDoubling(ctx, tc.want)
+		if err != nil || n != tc.want || new(big.Int).Sub(tc.x, want).Cmp(delta) != 0 {
+			t.Errorf("NearestIndex near F(1000003): expected %d, got %d (err=%v)", tc.want, n, err)
+		}
+	}
+}
+
+// TestZeckendorf checks that the representations sum back to x, use
+// indices k >= 2 in decreasing order with no two consecutive, and match the
+// known decomposition of 100.
+func TestZeckendorf(t *testing.T) {
+	ctx := context.Background()
+	check := func(x *big.Int) []int {
+		t.Helper()
+		indices, err := Zeckendorf(ctx, x)
+		if err != nil {
+			t.Fatalf("Zeckendorf(%s) failed: %v", x, err)
+		}
+		sum := new(big.Int)
+		for i, k := range indices {
+			if k < 2 || (i > 0 && indices[i-1]-k < 2) {
+				t.Fatalf("Zeckendorf(%s): invalid indices %v", x, indices)
+			}
+			f, _ := FastDoubling(ctx, k)
+			sum.Add(sum, f)
+		}
+		if sum.Cmp(x) != 0 {
+			t.Fatalf("Zeckendorf(%s) = %v sums to %s", x, indices, sum)
+		}
+		return indices
+	}
+
+	for x := int64(0); x <= 3000; x++ {
+		check(big.NewInt(x))
+	}
+	if got := check(big.NewInt(100)); !slices.Equal(got, []int{11, 6, 4}) { // 89 + 8 + 3
+		t.Errorf("Zeckendorf(100): expected [11 6 4], got %v", got)
+	}
+
+	// Around large terms, where the float64 estimate of the first index is
+	// at its least accurate: F(k) itself, and F(k) − 1 = F(k−1) + F(k−3) + ...
+	rng := rand.New(rand.NewSource(59))
+	for _, k := range []int{100, 1000, 20000} {
+		f, _ := FastDoubling(ctx, k)
+		if got := check(f); !slices.Equal(got, []int{k}) {
+			t.Errorf("Zeckendorf(F(%d)): expected [%d], got %v", k, k, got)
+		}
+		check(new(big.Int).Sub(f, big.NewInt(1)))
+		check(new(big.Int).Rand(rng, f))
+	}
+
+	if _, err := Zeckendorf(ctx, big.NewInt(-1)); err == nil {
+		t.Error("expected an error for a negative number")
+	}
+}
+
+// TestNegativeIndices verifies the negafibonacci values F(-n) = (-1)^(n+1)·F(n)
+// and that every algorithm agrees with Fast Doubling on them.
+func TestNegativeIndices(t *testing.T) {
+	ctx := context.Background()
+	for n, want := range map[int]int64{-1: 1, -2: -1, -3: 2, -6: -8, -7: 13} {
+		if got, err := FastDoubling(ctx, n); err != nil || got.Int64() != want {
+			t.Errorf("for F(%d), expected %d, but got %v (err=%v)", n, want, got, err)
+		}
+	}
+
+	m := big.NewInt(1000)
+	for _, n := range []int{-1, -2, -3, -50, -93, -1001, -6001, -7777} {
+		want, _ := FastDoubling(ctx, n)
+		wantMod := new(big.Int).Mod(want, m)
+		checks := []struct {
+			name string
+			fn   func() (*big.Int, error)
+			want *big.Int
+		}{
+			{"Iterative", func() (*big.Int, error) { return Iterative(ctx, n) }, want},
+			{"Memo", func() (*big.Int, error) { return Memo(ctx, n) }, want},
+			{"FastDoublingMod", func() (*big.Int, error) { return FastDoublingMod(ctx, n, m) }, wantMod},
+			{"IterativeMod", func() (*big.Int, error) { return IterativeMod(ctx, n, m) }, wantMod},
+		}
+		for _, c := range checks {
+			got, err := c.fn()
+			if err != nil {
+				t.Fatalf("%s: unexpected error for n=%d: %v", c.name, n, err)
+			}
+			if got.Cmp(c.want) != 0 {
+				t.Errorf("%s: for n=%d, expected %s, but got %s", c.name, n, c.want, got)
+			}
+		}
+
+		fn, fn1, err := FastDoublingPair(ctx, n)
+		wantNext, _ := FastDoubling(ctx, n+1)
+		if err != nil || fn.Cmp(want) != 0 || fn1.Cmp(wantNext) != 0 {
+			t.Errorf("FastDoublingPair: for n=%d, expected (%s, %s), but got (%v, %v)", n, want, wantNext, fn, fn1)
+		}
+	}
+}
+
+// TestBinet verifies Binet against Fast Doubling, and that the verification
+// pass recovers from a starting precision known to be too low.
+func TestBinet(t *testing.T) {
+	ctx := context.Background()
+	for _, n := range []int{0, 1, 2, 3, 10, 93, 500, 4102, 20000, -7} {
+		want, _ := FastDoubling(ctx, n)
+		got, err := Binet(ctx, n)
+		if err != nil {
+			t.Fatalf("unexpected error for F(%d): %v", n, err)
+		}
+		if got.Cmp(want) != 0 {
+			t.Errorf("for F(%d), expected %s, but got %s", n, want, got)
+		}
+	}
+
+	// With only 8 bits of margin, round(φⁿ/√5) is first wrong at n = 265.
+	const n = 265
+	lowPrec := uint(float64(n)*math.Log2(math.Phi)) + 8
+	want, _ := FastDoubling(ctx, n)
+	if v, _ := binetRound(ctx, n, lowPrec, newConfig(nil), 0); v.Cmp(want) == 0 {
+		t.Fatalf("expected an 8-bit margin to give a wrong F(%d)", n)
+	}
+	got, err := binet(ctx, n, lowPrec, newConfig(nil))
+	if err != nil || got.Cmp(want) != 0 {
+		t.Errorf("expected the retry to recover F(%d) = %s, got %v (err=%v)", n, want, got, err)
+	}
+
+	// WithBinetPrecision skips the verification: the same low precision now
+	// returns the wrong value, while a sufficient one is exact.
+	if v, err := Binet(ctx, n, WithBinetPrecision(lowPrec)); err != nil || v.Cmp(want) == 0 {
+		t.Errorf("expected a fixed %d-bit precision to give a wrong F(%d), got %v (err=%v)", lowPrec, n, v, err)
+	}
+	if v, err := Binet(ctx, n, WithBinetPrecision(BinetPrecision(n))); err != nil || v.Cmp(want) != 0 {
+		t.Errorf("expected a fixed %d-bit precision to give F(%d) = %s, got %v (err=%v)", BinetPrecision(n), n, want, v, err)
+	}
+
+	if v, err := BinetMod(ctx, 100, big.NewInt(1000)); err != nil || v.Int64() != 75 {
+		t.Errorf("for F(100) mod 1000, expected 75, but got %v (err=%v)", v, err)
+	}
+}
+
+// TestBinetThreshold checks that the threshold is where the working
+// precision reaches binetSlowPrec.
+func TestBinetThreshold(t *testing.T) {
+	n := BinetThreshold()
+	if p := BinetPrecision(n); p > binetSlowPrec || BinetPrecision(n+2) <= binetSlowPrec {
+		t.Errorf("threshold %d gives a precision of %d bits, expected just under %d", n, p, binetSlowPrec)
+	}
+}
+
+// TestBinetExact verifies BinetExact and BinetExactMod against Fast Doubling,
+// including moduli divisible by 5 and an index past BinetThreshold, and
+// where a single floating-point pass with too small a margin goes wrong.
+func TestBinetExact(t *testing.T) {
+	ctx := context.Background()
+	for _, n := range []int{0, 1, 2, 3, 4, 10, 93, 265, 4097, 20000, -1, -6, -93, BinetThreshold() + 1000} {
+		want, _ := FastDoubling(ctx, n)
+		if got, err := BinetExact(ctx, n); err != nil || got.Cmp(want) != 0 {
+			t.Errorf("for F(%d), BinetExact differs from Fast Doubling (err=%v)", n, err)
+		}
+		for _, m := range []int64{1, 5, 10, 1000, 1000000007} {
+			wantMod := new(big.Int).Mod(want, big.NewInt(m))
+			if got, err := BinetExactMod(ctx, n, big.NewInt(m)); err != nil || got.Cmp(wantMod) != 0 {
+				t.Errorf("for F(%d) mod %d, expected %s, got %v (err=%v)", n, m, wantMod, got, err)
+			}
+		}
+	}
+
+	// The 8-bit margin of TestBinet is wrong at n = 265; there is no
+	// precision to get wrong here.
+	const n = 265
+	want, _ := FastDoubling(ctx, n)
+	lowPrec := uint(float64(n)*math.Log2(math.Phi)) + 8
+	if v, _ := binetRound(ctx, n, lowPrec, newConfig(nil), 0); v.Cmp(want) == 0 {
+		t.Fatalf("expected an 8-bit margin to give a wrong F(%d)", n)
+	}
+	if got, _ := BinetExact(ctx, n); got.Cmp(want) != 0 {
+		t.Errorf("for F(%d), expected %s, got %s", n, want, got)
+	}
+}
+
+// TestMatrix verifies Matrix and MatrixFast, with their modular variants,
+// against Fast Doubling.
+func TestMatrix(t *testing.T) {
+	ctx := context.Background()
+	m := big.NewInt(1000)
+	for _, n := range []int{0, 1, 2, 3, 4, 10, 64, 93, 500, 4097, 20000, -1, -6, -93} {
+		want, _ := FastDoubling(ctx, n)
+		wantMod := new(big.Int).Mod(want, m)
+		checks := []struct {
+			name string
+			fn   func() (*big.Int, error)
+			want *big.Int
+		}{
+			{"Matrix", func() (*big.Int, error) { return Matrix(ctx, n) }, want},
+			{"MatrixFast", func() (*big.Int, error) { return MatrixFast(ctx, n) }, want},
+			{"MatrixMod", func() (*big.Int, error) { return MatrixMod(ctx, n, m) }, wantMod},
+			{"MatrixFastMod", func() (*big.Int, error) { return MatrixFastMod(ctx, n, m) }, wantMod},
+		}
+		for _, c := range checks {
+			got, err := c.fn()
+			if err != nil {
+				t.Fatalf("%s: unexpected error for n=%d: %v", c.name, n, err)
+			}
+			if got.Cmp(c.want) != 0 {
+				t.Errorf("%s: for n=%d, expected %s, but got %s", c.name, n, c.want, got)
+			}
+		}
+	}
+}
+
+// TestMatPow checks matPow against Qᵏ = [[F(k+1), F(k)], [F(k), F(k-1)]],
+// from the identity at k = 0 and Q itself at k = 1, in modular mode too, and
+// that the base is left unchanged.
+func TestMatPow(t *testing.T) {
+	ctx := context.Background()
+	m := big.NewInt(1000)
+	for _, k := range []uint{0, 1, 2, 3, 10, 64, 100, 1023} {
+		for _, mod := range []*big.Int{nil, m} {
+			c := newConfig(nil)
+			q := newMat2(c.pool, 1, 1, 1, 0)
+			p, err := matPow(ctx, q, k, mod, c)
+			if err != nil {
+				t.Fatalf("k=%d: unexpected error: %v", k, err)
+			}
+			want := make([]*big.Int, 3) // F(k-1), F(k), F(k+1), with F(-1) = 1
+			for i := range want {
+				want[i], _ = FastDoubling(ctx, int(k)+i-1)
+				if mod != nil {
+					want[i].Mod(want[i], mod)
+				}
+			}
+			got := []*big.Int{p.d, p.b, p.a}
+			if p.b.Cmp(p.c) != 0 {
+				t.Errorf("k=%d, m=%v: expected a symmetric matrix, got %v and %v", k, mod, p.b, p.c)
+			}
+			for i := range want {
+				if got[i].Cmp(want[i]) != 0 {
+					t.Errorf("k=%d, m=%v: entry %d is %v, expected %v", k, mod, i, got[i], want[i])
+				}
+			}
+			if q.a.Int64() != 1 || q.b.Int64() != 1 || q.c.Int64() != 1 || q.d.Int64() != 0 {
+				t.Errorf("k=%d: matPow modified its base", k)
+			}
+		}
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	c := newConfig(nil)
+	if _, err := matPow(cancelled, newMat2(c.pool, 1, 1, 1, 0), 1000, nil, c); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+// TestMatrixProgress records the progress of both matrix algorithms for
+// exponents of various bit patterns, including the powers of two and their
+// predecessors, and checks that it never decreases and ends exactly at 100%.
+func TestMatrixProgress(t *testing.T) {
+	ctx := context.Background()
+	algorithms := map[string]func(context.Context, int, ...Option) (*big.Int, error){
+		"Matrix":     Matrix,
+		"MatrixFast": MatrixFast,
+	}
+	for name, fn := range algorithms {
+		for _, n := range []int{0, 1, 2, 3, 4, 5, 9, 16, 17, 1000, 1025, 4096, 4097, 65535} {
+			var reports []float64
+			if _, err := fn(ctx, n, WithProgress(func(pct float64) {
+				reports = append(reports, pct)
+			})); err != nil {
+				t.Fatalf("%s: unexpected error for n=%d: %v", name, n, err)
+			}
+			if len(reports) == 0 {
+				t.Fatalf("%s: no progress reported for n=%d", name, n)
+			}
+			for i, pct := range reports {
+				if pct < 0 || pct > 100 || (i > 0 && pct < reports[i-1]) {
+					t.Fatalf("%s: progress for n=%d is not monotonic within [0, 100]: %v", name, n, reports)
+				}
+			}
+			if last := reports[len(reports)-1]; last != 100.0 {
+				t.Errorf("%s: expected final progress of exactly 100%% for n=%d, got %v", name, n, last)
+			}
+		}
+	}
+}
+
+// BenchmarkMatrix and BenchmarkMatrixFast compare the general 2x2 product
+// (8 multiplications) with the symmetric one (3 multiplications).
+func BenchmarkMatrix(b *testing.B) {
+	ctx := context.Background()
+	pool := NewIntPool()
+	for i := 0; i < b.N; i++ {
+		_, _ = Matrix(ctx, benchmarkLargeN, WithPool(pool))
+	}
+}
+
+func BenchmarkMatrixFast(b *testing.B) {
+	ctx := context.Background()
+	pool := NewIntPool()
+	for i := 0; i < b.N; i++ {
+		_, _ = MatrixFast(ctx, benchmarkLargeN, WithPool(pool))
+	}
+}
+
+// BenchmarkBinet and BenchmarkBinetExact compare the floating-point formula,
+// with its verification pass, and its exact evaluation through Lucas numbers.
+func BenchmarkBinet(b *testing.B) {
+	ctx := context.Background()
+	pool := NewIntPool()
+	for i := 0; i < b.N; i++ {
+		_, _ = Binet(ctx, benchmarkLargeN, WithPool(pool))
+	}
+}
+
+func BenchmarkBinetExact(b *testing.B) {
+	ctx := context.Background()
+	pool := NewIntPool()
+	for i := 0; i < b.N; i++ {
+		_, _ = BinetExact(ctx, benchmarkLargeN, WithPool(pool))
+	}
+}
+
+// loadGolden reads the golden values of testdata/golden.txt: "n value" lines,
+// with # comments.
+func loadGolden(t *testing.T) map[int]*big.Int {
+	t.Helper()
+	f, err := os.Open("testdata/golden.txt")
+	if err != nil {
+		t.Fatalf("cannot open golden file: %v", err)
+	}
+	defer f.Close()
+
+	golden := make(map[int]*big.Int)
+	sc := bufio.NewScanner(f)
+	sc.Buffer(nil, 1<<20) // F(100000) has about 21,000 digits
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			t.Fatalf("malformed golden line: %.40q", line)
+		}
+		n, err := strconv.Atoi(fields[0])
+		v, ok := new(big.Int).SetString(fields[1], 10)
+		if err != nil || !ok {
+			t.Fatalf("malformed golden line: %.40q", line)
+		}
+		golden[n] = v
+	}
+	if err := sc.Err(); err != nil {
+		t.Fatalf("cannot read golden file: %v", err)
+	}
+	return golden
+}
+
+// TestGoldenValues checks every algorithm against large golden values, which
+// exercise the big-number paths (multiplication, Binet's precision) that the
+// small table cases do not reach. Each algorithm must match the stored value,
+// not merely agree with the others.
+func TestGoldenValues(t *testing.T) {
+	ctx := context.Background()
+	algorithms := []struct {
+		name string
+		fn   func(context.Context, int, ...Option) (*big.Int, error)
+		maxN int // Memo keeps every F(k): O(n²) bits of memory
+	}{
+		{"FastDoubling", FastDoubling, math.MaxInt},
+		{"FastDoublingParallel", func(ctx context.Context, n int, opts ...Option) (*big.Int, error) {
+			return FastDoubling(ctx, n, append(opts, WithParallelMultiplication(0))...)
+		}, math.MaxInt},
+		{"Matrix", Matrix, math.MaxInt},
+		{"MatrixFast", MatrixFast, math.MaxInt},
+		{"Binet", Binet, math.MaxInt},
+		{"BinetExact", BinetExact, math.MaxInt},
+		{"Iterative", Iterative, math.MaxInt},
+		{"Memo", Memo, 10000},
+	}
+
+	golden := loadGolden(t)
+	if len(golden) == 0 {
+		t.Fatal("no golden values loaded")
+	}
+	for n, want := range golden {
+		for _, a := range algorithms {
+			if n > a.maxN {
+				continue
+			}
+			got, err := a.fn(ctx, n)
+			if err != nil {
+				t.Fatalf("%s: unexpected error for F(%d): %v", a.name, n, err)
+			}
+			if got.Cmp(want) != 0 {
+				t.Errorf("%s: F(%d) differs from the golden value", a.name, n)
+			}
+		}
+	}
+}
+
+// TestAlgorithmsAgreeOnRandomN is a property-based test: for random n in
+// [0, 5000], drawn from a fixed seed, every algorithm computes the same F(n),
+// and F(n+2) = F(n+1) + F(n) holds. Failing indices are logged.
+func TestAlgorithmsAgreeOnRandomN(t *testing.T) {
+	ctx := context.Background()
+	algorithms := []struct {
+		name string
+		fn   func(context.Context, int, ...Option) (*big.Int, error)
+	}{
+		{"Matrix", Matrix},
+		{"MatrixFast", MatrixFast},
+		{"Binet", Binet},
+		{"BinetExact", BinetExact},
+		{"Memo", Memo},
+		{"Iterative", Iterative},
+	}
+
+	property := func(draw uint16) bool {
+		n := int(draw) % 5001
+		fn, fn1, err := FastDoublingPair(ctx, n)
+		if err != nil {
+			t.Logf("n=%d: FastDoublingPair failed: %v", n, err)
+			return false
+		}
+		fn2, err := FastDoubling(ctx, n+2)
+		if err != nil || fn2.Cmp(new(big.Int).Add(fn1, fn)) != 0 {
+			t.Logf("n=%d: F(n+2) != F(n+1) + F(n) (err=%v)", n, err)
+			return false
+		}
+		for _, a := range algorithms {
+			got, err := a.fn(ctx, n)
+			if err != nil || got.Cmp(fn) != 0 {
+				t.Logf("n=%d: %s disagrees with Fast Doubling (err=%v)", n, a.name, err)
+				return false
+			}
+		}
+		return true
+	}
+
+	cfg := &quick.Config{MaxCount: 200, Rand: rand.New(rand.NewSource(1))}
+	if err := quick.Check(property, cfg); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestWriteWord checks the first Fibonacci words, then, past the words
+// built in memory, that S(n) has F(n+2) symbols and equals the n-th iterate
+// of the substitution 0 → 01, 1 → 0.
+func TestWriteWord(t *testing.T) {
+	ctx := context.Background()
+	for n, want := range []string{"0", "01", "010", "01001", "01001010", "0100101001001"} {
+		var sb strings.Builder
+		if err := WriteWord(ctx, &sb, n); err != nil || sb.String() != want {
+			t.Errorf("S(%d) = %q (err=%v), expected %q", n, sb.String(), err, want)
+		}
+	}
+
+	substituted := []byte("0")
+	for n := 1; n <= 30; n++ {
+		next := make([]byte, 0, len(substituted)*2)
+		for _, s := range substituted {
+			if s == '0' {
+				next = append(next, '0', '1')
+			} else {
+				next = append(next, '0')
+			}
+		}
+		substituted = next
+	}
+	var sb strings.Builder
+	var last float64
+	if err := WriteWord(ctx, &sb, 30, WithProgress(func(pct float64) { last = pct })); err != nil {
+		t.Fatal(err)
+	}
+	length, _ := FastDoubling(ctx, 32)
+	if int64(sb.Len()) != length.Int64() || sb.String() != string(substituted) || last != 100 {
+		t.Errorf("S(30): %d symbols, expected F(32) = %s equal to the substitution, with a final progress of 100%% (got %.1f)", sb.Len(), length, last)
+	}
+
+	if err := WriteWord(ctx, io.Discard, -1); err == nil {
+		t.Error("expected an error for n = -1")
+	}
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := WriteWord(cancelled, io.Discard, 40); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+// TestErrors checks that each category of error matches its sentinel with
+// errors.Is, and that a cancelled calculation matches its context error only.
+func TestErrors(t *testing.T) {
+	ctx := context.Background()
+	rangeErr := Range(ctx, 5, 2, func(int, *big.Int) error { return nil })
+	_, _, ratioErr := Ratio(ctx, 0)
+	_, fastErr := FastDoubling(ctx, math.MinInt)
+	_, matrixErr := Matrix(ctx, math.MinInt)
+	_, sumErr := Sum(ctx, -1)
+	_, modErr := FastDoublingMod(ctx, 10, big.NewInt(0))
+	_, nilErr := FastDoublingModBig(ctx, nil, big.NewInt(7))
+	_, digitsErr := LeadingDigits(ctx, 10, 0)
+	_, zeckendorfErr := Zeckendorf(ctx, big.NewInt(-1))
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, cancelErr := FastDoubling(cancelled, 1000000)
+
+	sentinels := []error{ErrIndexOutOfRange, ErrInvalidModulus, ErrInvalidArgument, ErrUnstable, context.Canceled}
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"FastDoubling(MinInt)", fastErr, ErrIndexOutOfRange},
+		{"Matrix(MinInt)", matrixErr, ErrIndexOutOfRange},
+		{"Sum(-1)", sumErr, ErrIndexOutOfRange},
+		{"Ratio(0)", ratioErr, ErrIndexOutOfRange},
+		{"WriteWord(-1)", WriteWord(ctx, io.Discard, -1), ErrIndexOutOfRange},
+		{"FastDoublingMod(m=0)", modErr, ErrInvalidModulus},
+		{"FastDoublingModBig(nil)", nilErr, ErrInvalidArgument},
+		{"LeadingDigits(k=0)", digitsErr, ErrInvalidArgument},
+		{"Range(5, 2)", rangeErr, ErrInvalidArgument},
+		{"Zeckendorf(-1)", zeckendorfErr, ErrInvalidArgument},
+		{"cancelled FastDoubling", cancelErr, context.Canceled},
+	}
+	for _, tt := range tests {
+		for _, s := range sentinels {
+			if got := errors.Is(tt.err, s); got != (s == tt.want) {
+				t.Errorf("%s: errors.Is(%v, %v) = %v", tt.name, tt.err, s, got)
+			}
+		}
+	}
+}
+
+// TestTribonacci checks the first tribonacci numbers, then the matrix power
+// against the recurrence computed term by term up to n = 1500, and backwards
+// down to n = −300, in modular mode too.
+func TestTribonacci(t *testing.T) {
+	ctx := context.Background()
+	for n, want := range []int64{0, 0, 1, 1, 2, 4, 7, 13, 24, 44, 81, 149, 274} {
+		got, err := Tribonacci(ctx, n)
+		if err != nil {
+			t.Fatalf("unexpected error for n=%d: %v", n, err)
+		}
+		if got.Int64() != want {
+			t.Errorf("T(%d): expected %d, got %s", n, want, got)
+		}
+	}
+
+	m := big.NewInt(1000000007)
+	terms := []*big.Int{big.NewInt(0), big.NewInt(0), big.NewInt(1)}
+	for n := 3; n <= 1500; n++ {
+		v := new(big.Int).Add(terms[n-1], terms[n-2])
+		terms = append(terms, v.Add(v, terms[n-3]))
+	}
+	// backward[k] = T(−k), from T(n) = T(n+3) − T(n+2) − T(n+1).
+	backward := []*big.Int{big.NewInt(0)}
+	at := func(n int) *big.Int {
+		if n >= 0 {
+			return terms[n]
+		}
+		return backward[-n]
+	}
+	for n := -1; n >= -300; n-- {
+		v := new(big.Int).Sub(at(n+3), at(n+2))
+		backward = append(backward, v.Sub(v, at(n+1)))
+	}
+
+	for _, n := range []int{3, 64, 100, 511, 512, 1000, 1500, -1, -2, -3, -4, -5, -64, -300} {
+		want := at(n)
+		got, err := Tribonacci(ctx, n)
+		if err != nil || got.Cmp(want) != 0 {
+			t.Errorf("T(%d): expected %s, got %v (error %v)", n, want, got, err)
+		}
+		gotMod, err := TribonacciMod(ctx, n, m)
+		if wantMod := new(big.Int).Mod(want, m); err != nil || gotMod.Cmp(wantMod) != 0 {
+			t.Errorf("T(%d) mod %s: expected %s, got %v (error %v)", n, m, wantMod, gotMod, err)
+		}
+	}
+}
+
+func TestRecurrence(t *testing.T) {
+	ctx := context.Background()
+	ints := func(vs ...int64) []*big.Int {
+		r := make([]*big.Int, len(vs))
+		for i, v := range vs {
+			r[i] = big.NewInt(v)
+		}
+		return r
+	}
+
+	// Pell numbers, P(n) = 2·P(n−1) + P(n−2) from 0, 1.
+	for n, want := range []int64{0, 1, 2, 5, 12, 29, 70, 169, 408, 985, 2378, 5741, 13860} {
+		got, err := Recurrence(ctx, n, ints(2, 1), ints(0, 1))
+		if err != nil {
+			t.Fatalf("unexpected error for n=%d: %v", n, err)
+		}
+		if got.Int64() != want {
+			t.Errorf("P(%d): expected %d, got %s", n, want, got)
+		}
+	}
+
+	m := big.NewInt(1000000007)
+	for _, c := range []struct {
+		name         string
+		coeffs, init []*big.Int
+		want         func(n int) (*big.Int, error)
+	}{
+		{"Fibonacci", ints(1, 1), ints(0, 1), func(n int) (*big.Int, error) { return FastDoubling(ctx, n) }},
+		{"Lucas", ints(1, 1), ints(2, 1), func(n int) (*big.Int, error) { return Generalized(ctx, n, big.NewInt(2), big.NewInt(1)) }},
+		{"tribonacci", ints(1, 1, 1), ints(0, 0, 1), func(n int) (*big.Int, error) { return Tribonacci(ctx, n) }},
+	} {
+		for _, n := range []int{0, 1, 2, 3, 64, 100, 511, 512, 1000} {
+			want, err := c.want(n)
+			if err != nil {
+				t.Fatalf("%s: unexpected error for n=%d: %v", c.name, n, err)
+			}
+			got, err := Recurrence(ctx, n, c.coeffs, c.init)
+			if err != nil || got.Cmp(want) != 0 {
+				t.Errorf("%s(%d): expected %s, got %v (error %v)", c.name, n, want, got, err)
+			}
+			gotMod, err := RecurrenceMod(ctx, n, c.coeffs, c.init, m)
+			if wantMod := new(big.Int).Mod(want, m); err != nil || gotMod.Cmp(wantMod) != 0 {
+				t.Errorf("%s(%d) mod %s: expected %s, got %v (error %v)", c.name, n, m, wantMod, gotMod, err)
+			}
+		}
+	}
+
+	// Negative coefficients and a first-order recurrence: G(n) = −3·G(n−1)
+	// from 5 is 5·(−3)ⁿ.
+	got, err := Recurrence(ctx, 41, ints(-3), ints(5))
+	want := new(big.Int).Exp(big.NewInt(-3), big.NewInt(41), nil)
+	if want.Mul(want, big.NewInt(5)); err != nil || got.Cmp(want) != 0 {
+		t.Errorf("5·(−3)^41: expected %s, got %v (error %v)", want, got, err)
+	}
+
+	if _, err := Recurrence(ctx, -1, ints(1, 1), ints(0, 1)); !errors.Is(err, ErrIndexOutOfRange) {
+		t.Errorf("n=-1: expected ErrIndexOutOfRange, got %v", err)
+	}
+	if _, err := Recurrence(ctx, 10, ints(1, 1), ints(0)); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("mismatched initial terms: expected ErrInvalidArgument, got %v", err)
+	}
+	if _, err := Recurrence(ctx, 10, nil, nil); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("no coefficients: expected ErrInvalidArgument, got %v", err)
+	}
+}
+
+func TestEstimateRecurrenceBytes(t *testing.T) {
+	ctx := context.Background()
+	for _, c := range []struct {
+		coeffs, init []int64
+	}{
+		{[]int64{1, 1}, []int64{0, 1}},
+		{[]int64{2, 1}, []int64{0, 1}},
+		{[]int64{1, 1, 1}, []int64{0, 0, 1}},
+		{[]int64{0, 1}, []int64{3, 7}}, // Oscillating growth ratio
+		{[]int64{-10, 3}, []int64{-1000, 1}},
+	} {
+		coeffs, init := make([]*big.Int, len(c.coeffs)), make([]*big.Int, len(c.init))
+		for i := range c.coeffs {
+			coeffs[i], init[i] = big.NewInt(c.coeffs[i]), big.NewInt(c.init[i])
+		}
+		for _, n := range []int{0, 1, 10, 1000, 100000} {
+			v, err := Recurrence(ctx, n, coeffs, init)
+			if err != nil {
+				t.Fatalf("%v from %v: unexpected error for n=%d: %v", c.coeffs, c.init, n, err)
+			}
+			size := uint64(len(v.Bytes()))
+			if est := EstimateRecurrenceBytes(n, coeffs, init); est < size || est > size+size/8+16 {
+				t.Errorf("%v from %v, n=%d: estimated %d bytes for %d", c.coeffs, c.init, n, est, size)
+			}
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package fib
+
+import (
+	"context"
+	"math/big"
+)
+
+// Generalized calculates G(n) for the sequence with G(0) = a, G(1) = b and the
+// Fibonacci recurrence G(n) = G(n-1) + G(n-2). Seeds (0, 1) give F(n) and
+// (2, 1) give the Lucas numbers L(n).
+//
+// Concept:
+// Any such sequence is a linear combination of two shifted Fibonacci
+// sequences: G(n) = b·F(n) + a·F(n−1), which holds for every integer n since
+// both sides follow the recurrence and agree at n = 0 and n = 1. With
+// F(n−1) = F(n+1) − F(n), a single Fast Doubling pair is enough.
+func Generalized(ctx context.Context, n int, a, b *big.Int, opts ...Option) (*big.Int, error) {
+	return generalized(ctx, n, a, b, nil, newConfig(opts))
+}
+
+// GeneralizedMod calculates G(n) mod m, reducing every intermediate value modulo m.
+func GeneralizedMod(ctx context.Context, n int, a, b, m *big.Int, opts ...Option) (*big.Int, error) {
+	if err := checkModulus(m); err != nil {
+		return nil, err
+	}
+	return generalized(ctx, reduceByPisano(n, m), a, b, m, newConfig(opts))
+}
+
+// generalized is the shared implementation behind Generalized and GeneralizedMod.
+func generalized(ctx context.Context, n int, a, b, m *big.Int, c *config) (*big.Int, error) {
+	fn := new(big.Int)
+	fn1 := c.pool.Get().(*big.Int)
+	defer putInt(c.pool, fn1)
+
+	if err := fastDoublingInto(ctx, n, m, c, fn, fn1); err != nil {
+		return nil, err
+	}
+
+	// G(n) = b·F(n) + a·(F(n+1) − F(n))
+	fn1.Sub(fn1, fn).Mul(fn1, a)
+	fn.Mul(fn, b).Add(fn, fn1)
+	if m != nil {
+		fn.Mod(fn, m)
+	}
+	return fn, nil
+}
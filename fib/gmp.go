@@ -0,0 +1,63 @@
+//go:build gmp
+
+package fib
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ncw/gmp"
+)
+
+// FastDoublingGMP calculates F(n) by Fast Doubling on GMP integers, through
+// the cgo bindings of github.com/ncw/gmp. It is only available when building
+// with `-tags gmp`, which requires libgmp and `go get github.com/ncw/gmp`.
+//
+// Strengths/Weaknesses:
+// GMP's multiplication outperforms math/big on multi-million-digit operands.
+// The result is converted back to a *big.Int, which costs O(size of F(n)).
+// The pool option does not apply; parallel multiplication does.
+func FastDoublingGMP(ctx context.Context, n int, opts ...Option) (*big.Int, error) {
+	if n < 0 {
+		if -n < 0 { // -math.MinInt overflows
+			return nil, fmt.Errorf("%w: %d", ErrIndexOutOfRange, n)
+		}
+		v, err := FastDoublingGMP(ctx, -n, opts...)
+		if err == nil {
+			negate(v, -n, nil)
+		}
+		return v, err
+	}
+	if n <= 1 {
+		return FastDoubling(ctx, n, opts...)
+	}
+	be := gmpBackend{}
+	v, v1, err := doublingPair[*gmp.Int](ctx, n, be, newConfig(opts), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer be.Put(v)
+	defer be.Put(v1)
+	return new(big.Int).SetBytes(v.Bytes()), nil // F(n) >= 0 here
+}
+
+// gmpBackend is the Backend of FastDoublingGMP. GMP values hold C memory
+// released by their finalizer, so they are simply allocated and dropped.
+type gmpBackend struct{}
+
+func (gmpBackend) Get() *gmp.Int  { return new(gmp.Int) }
+func (gmpBackend) Put(x *gmp.Int) {}
+
+// FastDoublingGMPMod calculates F(n) mod m with FastDoublingGMP. GMP has no
+// pooled modular path here: the full value is computed, then reduced.
+func FastDoublingGMPMod(ctx context.Context, n int, m *big.Int, opts ...Option) (*big.Int, error) {
+	if err := checkModulus(m); err != nil {
+		return nil, err
+	}
+	v, err := FastDoublingGMP(ctx, n, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return v.Mod(v, m), nil
+}
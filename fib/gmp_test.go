@@ -0,0 +1,43 @@
+//go:build gmp
+
+package fib
+
+import (
+	"context"
+	"testing"
+)
+
+// TestFastDoublingGMP verifies the GMP backend against the math/big one.
+func TestFastDoublingGMP(t *testing.T) {
+	ctx := context.Background()
+	for _, n := range []int{0, 1, 2, 10, 93, 1000, 100001, -7} {
+		want, _ := FastDoubling(ctx, n)
+		got, err := FastDoublingGMP(ctx, n)
+		if err != nil {
+			t.Fatalf("unexpected error for F(%d): %v", n, err)
+		}
+		if got.Cmp(want) != 0 {
+			t.Errorf("for F(%d), expected %s, but got %s", n, want, got)
+		}
+	}
+}
+
+// benchmarkGMPN is the index at which GMP's multiplication clearly pulls
+// ahead of math/big: F(10,000,000) has about 2.1 million digits.
+const benchmarkGMPN = 10000000
+
+// BenchmarkFastDoublingBig10M and BenchmarkFastDoublingGMP10M document the
+// speedup of the GMP backend: go test -tags gmp -run '^$' -bench 10M ./fib
+func BenchmarkFastDoublingBig10M(b *testing.B) {
+	ctx := context.Background()
+	for i := 0; i < b.N; i++ {
+		_, _ = FastDoubling(ctx, benchmarkGMPN)
+	}
+}
+
+func BenchmarkFastDoublingGMP10M(b *testing.B) {
+	ctx := context.Background()
+	for i := 0; i < b.N; i++ {
+		_, _ = FastDoublingGMP(ctx, benchmarkGMPN)
+	}
+}
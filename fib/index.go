@@ -0,0 +1,158 @@
+package fib
+
+import (
+	"context"
+	"math"
+	"math/big"
+)
+
+// IsFibonacci reports whether x is a Fibonacci number, negafibonacci terms
+// included.
+//
+// Concept:
+// A non-negative integer x is a Fibonacci number if and only if 5x² + 4 or
+// 5x² − 4 is a perfect square (Gessel's criterion), which follows from the
+// identity L(n)² − 5F(n)² = 4(−1)ⁿ. The test costs a squaring and two integer
+// square roots, with no term of the sequence computed. A negative x can only
+// be F(−n) = −F(n) for an even n, which the criterion cannot tell apart, so
+// Index settles it.
+func IsFibonacci(x *big.Int) bool {
+	if x.Sign() < 0 {
+		_, ok := Index(x)
+		return ok
+	}
+	v := new(big.Int).Mul(x, x)
+	v.Mul(v, big.NewInt(5))
+	return isPerfectSquare(new(big.Int).Add(v, big.NewInt(4))) ||
+		isPerfectSquare(v.Sub(v, big.NewInt(4)))
+}
+
+// Index returns an index n such that F(n) = x, and whether there is one. For
+// x = 1, both F(1) and F(2) qualify and 1 is returned; for a negative x, the
+// index is negative.
+//
+// Concept:
+// Binet's formula inverts to n ≈ log(x·√5)/log(φ), evaluated in float64 from
+// the leading bits of x. The estimate is then confirmed exactly: a Fast
+// Doubling pass yields F(n−1) and F(n), one addition F(n+1), and x must be
+// one of them.
+func Index(x *big.Int) (int, bool) {
+	if x.Sign() < 0 {
+		n, ok := Index(new(big.Int).Neg(x))
+		if !ok {
+			return 0, false
+		}
+		if n == 1 {
+			n = 2 // F(-1) = 1 but F(-2) = -1
+		}
+		if n%2 != 0 { // F(-n) = F(n) for an odd n
+			return 0, false
+		}
+		return -n, true
+	}
+	if x.Cmp(big.NewInt(1)) <= 0 {
+		return int(x.Int64()), true // F(0) = 0, F(1) = 1
+	}
+	if !IsFibonacci(x) {
+		return 0, false
+	}
+
+	est := int(math.Round((bigLog(x) + math.Log(math.Sqrt(5))) / math.Log(math.Phi)))
+	est = max(est, 3) // F(2) = 1 was handled above
+	fn := new(big.Int)
+	fn1 := new(big.Int)
+	c := newConfig(nil)
+	if err := fastDoublingInto(context.Background(), est-1, nil, c, fn, fn1); err != nil {
+		return 0, false
+	}
+	switch {
+	case fn.Cmp(x) == 0:
+		return est - 1, true
+	case fn1.Cmp(x) == 0:
+		return est, true
+	case fn.Add(fn, fn1).Cmp(x) == 0:
+		return est + 1, true
+	}
+	return 0, false
+}
+
+// NearestIndex returns the index n of the Fibonacci number closest to x, and
+// delta = x − F(n), which is zero exactly when x is a Fibonacci number. On a
+// tie, the term closer to zero wins. As with Index, 1 = F(1) = F(2) gives 1,
+// and a negative x is matched against the negative terms F(−m) = −F(m), m
+// even, and 0.
+//
+// Concept:
+// Binet's formula inverts to n ≈ log(|x|·√5)/log(φ), evaluated in float64
+// from the leading bits of x. One Fast Doubling pass yields the pair around
+// the estimate, which is then moved by single additions or subtractions until
+// F(k) <= |x| < F(k+1): the nearest term is one of these two, or for a
+// negative x one of the two even-indexed terms around them.
+func NearestIndex(ctx context.Context, x *big.Int, opts ...Option) (n int, delta *big.Int, err error) {
+	y := new(big.Int).Abs(x)
+	if y.Sign() == 0 {
+		return 0, new(big.Int), nil
+	}
+	c := newConfig(opts)
+
+	k := int((bigLog(y) + math.Log(math.Sqrt(5))) / math.Log(math.Phi))
+	k = max(k, 1)
+	a, b := new(big.Int), new(big.Int) // a = F(k), b = F(k+1)
+	if err := fastDoublingInto(ctx, k, nil, c, a, b); err != nil {
+		return 0, nil, err
+	}
+	// The float64 estimate may be off by one: settle F(k) <= y < F(k+1).
+	for a.Cmp(y) > 0 && k > 1 {
+		a, b = b.Sub(b, a), a
+		k--
+	}
+	for b.Cmp(y) <= 0 {
+		a, b = b, a.Add(a, b)
+		k++
+	}
+
+	// The candidates below and above y: F(k) and F(k+1), or for a negative x
+	// the terms of even index around them.
+	lo, hi := k, k+1
+	if x.Sign() < 0 {
+		if k%2 == 0 {
+			b.Add(a, b) // F(k+2)
+			hi = k + 2
+		} else {
+			a.Sub(b, a) // F(k−1)
+			lo = k - 1
+		}
+	}
+	below := new(big.Int).Sub(y, a)
+	above := new(big.Int).Sub(b, y)
+	m, term := lo, a
+	if above.Cmp(below) < 0 {
+		m, term = hi, b
+	}
+	if m == 2 && x.Sign() > 0 {
+		m = 1 // F(1) = F(2) = 1
+	}
+	if x.Sign() < 0 {
+		m = -m
+		term.Neg(term)
+	}
+	return m, new(big.Int).Sub(x, term), nil
+}
+
+// isPerfectSquare reports whether v is the square of an integer.
+func isPerfectSquare(v *big.Int) bool {
+	if v.Sign() < 0 {
+		return false
+	}
+	r := new(big.Int).Sqrt(v)
+	return r.Mul(r, r).Cmp(v) == 0
+}
+
+// bigLog returns the natural logarithm of x > 0, from its 64 leading bits so
+// that x may exceed the range of float64.
+func bigLog(x *big.Int) float64 {
+	shift := max(x.BitLen()-64, 0)
+	top := new(big.Int).Rsh(x, uint(shift))
+	f, _ := new(big.Float).SetInt(top).Float64()
+	return math.Log(f) + float64(shift)*math.Ln2
+}
@@ -0,0 +1,176 @@
+package fib
+
+import (
+	"context"
+	"math/big"
+	"math/bits"
+	"sync"
+)
+
+// ------------------------------------------------------------
+// Integer Abstraction
+// ------------------------------------------------------------
+//
+// Concept:
+// The doubling loop only needs a handful of operations on its integers.
+// Expressing it against the Integer interface lets the same code run on
+// `*big.Int` (bigIntBackend), on GMP integers (gmpBackend, built with the
+// `gmp` tag), or on any other representation providing these methods, such
+// as a modular integer type reducing after each operation.
+
+// Integer is the set of operations the Fast Doubling loop performs. The
+// methods follow the `*big.Int` conventions: the receiver is set to the
+// result and returned. T is the implementing type itself, e.g. *big.Int.
+type Integer[T any] interface {
+	Set(x T) T
+	SetInt64(x int64) T
+	Add(x, y T) T
+	Sub(x, y T) T
+	Mul(x, y T) T
+	Lsh(x T, n uint) T
+	Cmp(y T) int
+	BitLen() int
+}
+
+// Backend provides the temporary values of an algorithm. Get returns a value
+// of unspecified content; Put hands it back once it is no longer used.
+type Backend[T Integer[T]] interface {
+	Get() T
+	Put(x T)
+}
+
+// bigIntBackend is the default Backend: `*big.Int` values recycled through a
+// sync.Pool as returned by NewIntPool.
+type bigIntBackend struct {
+	pool *sync.Pool
+}
+
+func (b bigIntBackend) Get() *big.Int  { return b.pool.Get().(*big.Int) }
+func (b bigIntBackend) Put(x *big.Int) { putInt(b.pool, x) }
+
+// index is the index of a doubling loop, read bit by bit: an int through
+// smallIndex, or a *big.Int of any size for the modular variants.
+type index interface {
+	BitLen() int
+	Bit(i int) uint
+}
+
+// smallIndex is an int index, n >= 0.
+type smallIndex uint
+
+func (n smallIndex) BitLen() int    { return bits.Len(uint(n)) }
+func (n smallIndex) Bit(i int) uint { return uint(n) >> i & 1 }
+
+// doublingPair computes F(n) and F(n+1), n >= 2, by Fast Doubling on values
+// taken from be. When reduce is not nil, it is applied to both values after
+// each step, e.g. to keep them modulo m. The returned values come from be:
+// the caller copies them out and Puts them back.
+func doublingPair[T Integer[T]](ctx context.Context, n int, be Backend[T], c *config, reduce func(T)) (fn, fn1 T, err error) {
+	return doublingPairIndex(ctx, smallIndex(n), be, c, reduce)
+}
+
+// doublingPairIndex is doublingPair for an index of any size, n >= 2.
+func doublingPairIndex[T Integer[T]](ctx context.Context, n index, be Backend[T], c *config, reduce func(T)) (fn, fn1 T, err error) {
+	// Initialize F(k) and F(k+1)
+	// a = F(k), b = F(k+1)
+	a := be.Get().SetInt64(0)
+	b := be.Get().SetInt64(1)
+	defer func() {
+		if err != nil { // On success, a and b are handed to the caller
+			be.Put(a)
+			be.Put(b)
+		}
+	}()
+
+	// Temporary variables for calculations, taken from the backend.
+	t1 := be.Get()
+	t2 := be.Get()
+	defer be.Put(t1)
+	defer be.Put(t2)
+
+	// Extra temporaries needed only when products run concurrently.
+	var t3, t4 T
+	if c.parallelMul {
+		t3 = be.Get()
+		t4 = be.Get()
+		defer be.Put(t3)
+		defer be.Put(t4)
+	}
+
+	totalBits := n.BitLen() // Number of bits in n
+	// Iterate from the most significant bit of n down to the least significant bit
+	for i := totalBits - 1; i >= 0; i-- {
+		// Cooperative context cancellation check
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+			return
+		default:
+		}
+
+		// Doubling Step, with three squarings and no shift:
+		// F(2k)   = F(k+1)² − F(k−1)²
+		// F(2k+1) = F(k+1)² + F(k)²
+		// where F(k−1) = F(k+1) − F(k). This is the classic
+		// F(2k) = F(k)·[2F(k+1) − F(k)] with the ×2 folded into the
+		// subtraction: squarings are cheaper than general products, and
+		// no operand aliases its destination, so no product allocates.
+		//
+		// Current a = F(k), b = F(k+1)
+		// We calculate F(2k) and F(2k+1) and store them in a and b respectively.
+
+		t1.Sub(b, a) // t1 = F(k−1)
+
+		if c.parallelMul && a.BitLen() >= c.parallelThreshold {
+			// Parallel path: the three squarings read distinct values, so
+			// two of them run in their own goroutines while this one squares
+			// F(k+1) into t4.
+			var wg sync.WaitGroup
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				t2.Mul(t1, t1) // t2 = F(k−1)²
+			}()
+			go func() {
+				defer wg.Done()
+				t3.Mul(a, a) // t3 = F(k)²
+			}()
+			t4.Mul(b, b) // t4 = F(k+1)²
+			wg.Wait()
+
+			a.Sub(t4, t2) // a = F(2k)
+			b.Add(t4, t3) // b = F(2k+1)
+		} else {
+			t2.Mul(t1, t1) // t2 = F(k−1)²
+			t1.Mul(a, a)   // t1 = F(k)²
+			a.Mul(b, b)    // a = F(k+1)²
+			b.Add(a, t1)   // b = F(k+1)² + F(k)² = F(2k+1)
+			a.Sub(a, t2)   // a = F(k+1)² − F(k−1)² = F(2k)
+		}
+
+		c.count(3, 3) // Three squarings; two subtractions and an addition
+
+		// In modular mode, keep both values in [0, m) so they never grow.
+		if reduce != nil {
+			reduce(a)
+			reduce(b)
+		}
+
+		// If the i-th bit of n is 1, step forward by one:
+		// (F(2k), F(2k+1)) → (F(2k+1), F(2k+2)), with F(2k+2) = F(2k) + F(2k+1).
+		// F(2k+2) is built in a, then a and b swap places, so no value is copied.
+		if n.Bit(i) == 1 {
+			a.Add(a, b)
+			a, b = b, a
+			if reduce != nil {
+				reduce(b)
+			}
+			c.count(0, 1)
+		}
+
+		c.report((float64(totalBits-i) / float64(totalBits)) * 100.0)
+	}
+
+	c.report(100.0)
+	return a, b, nil
+}
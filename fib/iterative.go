@@ -0,0 +1,112 @@
+package fib
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+)
+
+const (
+	// checkLatency is the cancellation latency the derived check interval
+	// aims to stay under.
+	checkLatency = 50 * time.Millisecond
+	// wordAddCost is a conservative estimate of the cost of one step per
+	// 64-bit word of the operands: the addition, and the reduction in modular
+	// mode.
+	wordAddCost = 2 * time.Nanosecond
+	// maxCheckInterval bounds the derived interval, so that small operands
+	// still see the cancellation promptly in absolute terms.
+	maxCheckInterval = 1 << 12
+)
+
+// Iterative calculates F(n) by applying the recurrence F(i+2) = F(i+1) + F(i)
+// n times.
+//
+// Concept:
+// The textbook method: keep the last two terms and add them, n times. It is
+// O(n) additions on numbers growing to n·log2(φ) bits, so O(n²) bit
+// operations overall, far slower than Fast Doubling for large n.
+//
+// Strengths/Weaknesses:
+// Its only strength is simplicity: it shares no code or identity with the
+// doubling algorithms, which makes it a trustworthy independent reference to
+// check them against.
+func Iterative(ctx context.Context, n int, opts ...Option) (*big.Int, error) {
+	return iterative(ctx, n, nil, newConfig(opts))
+}
+
+// IterativeMod calculates F(n) mod m with the iterative method, reducing the
+// terms modulo m at each step.
+func IterativeMod(ctx context.Context, n int, m *big.Int, opts ...Option) (*big.Int, error) {
+	if err := checkModulus(m); err != nil {
+		return nil, err
+	}
+	return iterative(ctx, n, m, newConfig(opts))
+}
+
+// iterative is the shared implementation behind Iterative and IterativeMod.
+func iterative(ctx context.Context, n int, m *big.Int, c *config) (*big.Int, error) {
+	if n < 0 {
+		if -n < 0 { // -math.MinInt overflows
+			return nil, fmt.Errorf("%w: %d", ErrIndexOutOfRange, n)
+		}
+		v, err := iterative(ctx, -n, m, c)
+		if err == nil {
+			negate(v, -n, m)
+		}
+		return v, err
+	}
+
+	a, b := big.NewInt(0), big.NewInt(1) // a = F(i), b = F(i+1)
+	if m != nil {
+		b.Mod(b, m)
+	}
+	reportEvery := n/100 + 1 // Report progress about once per percent
+	checkEvery := c.checkInterval
+	if checkEvery <= 0 {
+		checkEvery = checkInterval(n, m)
+	}
+
+	for i := 0; i < n; i++ {
+		// Cooperative context cancellation check, every checkEvery steps only:
+		// on small operands the select costs as much as the addition itself.
+		if i%checkEvery == 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			default:
+			}
+		}
+
+		// (a, b) = (b, a + b), reusing a's storage for the sum.
+		a.Add(a, b)
+		if m != nil {
+			a.Mod(a, m)
+		}
+		a, b = b, a
+
+		if i%reportEvery == 0 {
+			c.report(float64(i+1) / float64(n) * 100.0)
+		}
+	}
+
+	c.count(0, int64(n))
+	c.report(100.0)
+	return a, nil
+}
+
+// checkInterval returns the number of steps between two context checks of
+// the iterative loop for F(n), reduced modulo m when m is not nil. It is
+// sized so that that many steps on the largest operands, F(n) or m, take
+// about checkLatency.
+func checkInterval(n int, m *big.Int) int {
+	bits := float64(n) * math.Log2(math.Phi)
+	if m != nil {
+		bits = min(bits, float64(m.BitLen()))
+	}
+	words := int64(bits)/64 + 1
+	k := int64(checkLatency / (time.Duration(words) * wordAddCost))
+	return int(min(max(k, 1), maxCheckInterval))
+}
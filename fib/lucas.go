@@ -0,0 +1,45 @@
+package fib
+
+import (
+	"context"
+	"math/big"
+)
+
+// Lucas calculates the Lucas number L(n), defined by the same recurrence as
+// F(n) but starting from L(0) = 2 and L(1) = 1.
+//
+// Concept:
+// Fast Doubling already tracks the pair F(n), F(n+1), from which the Lucas
+// number follows directly with the identity L(n) = 2·F(n+1) − F(n).
+// The cost is therefore the same as FastDoubling, plus one shift and one
+// subtraction.
+func Lucas(ctx context.Context, n int, opts ...Option) (*big.Int, error) {
+	return lucas(ctx, n, nil, newConfig(opts))
+}
+
+// LucasMod calculates L(n) mod m, reducing every intermediate value modulo m.
+func LucasMod(ctx context.Context, n int, m *big.Int, opts ...Option) (*big.Int, error) {
+	if err := checkModulus(m); err != nil {
+		return nil, err
+	}
+	return lucas(ctx, reduceByPisano(n, m), m, newConfig(opts))
+}
+
+// lucas is the shared implementation behind Lucas and LucasMod.
+func lucas(ctx context.Context, n int, m *big.Int, c *config) (*big.Int, error) {
+	fn := new(big.Int)
+	fn1 := c.pool.Get().(*big.Int)
+	defer putInt(c.pool, fn1)
+
+	if err := fastDoublingInto(ctx, n, m, c, fn, fn1); err != nil {
+		return nil, err
+	}
+
+	// L(n) = 2·F(n+1) − F(n)
+	fn.Sub(fn1.Lsh(fn1, 1), fn)
+	c.count(0, 2)
+	if m != nil {
+		fn.Mod(fn, m)
+	}
+	return fn, nil
+}
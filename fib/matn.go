@@ -0,0 +1,110 @@
+package fib
+
+import (
+	"context"
+	"math/big"
+	"math/bits"
+	"sync"
+)
+
+// matN is a k×k matrix of big integers, stored as rows. It generalizes mat2
+// to the companion matrices of recurrences of order k > 2.
+type matN [][]*big.Int
+
+// newMatN returns the k×k zero matrix, with entries taken from pool.
+func newMatN(pool *sync.Pool, k int) matN {
+	z := make(matN, k)
+	for i := range z {
+		z[i] = make([]*big.Int, k)
+		for j := range z[i] {
+			z[i][j] = pool.Get().(*big.Int).SetInt64(0)
+		}
+	}
+	return z
+}
+
+// identityMatN returns the k×k identity matrix, with entries taken from pool.
+func identityMatN(pool *sync.Pool, k int) matN {
+	z := newMatN(pool, k)
+	for i := range z {
+		z[i][i].SetInt64(1)
+	}
+	return z
+}
+
+// release returns the entries of z to pool.
+func (z matN) release(pool *sync.Pool) {
+	for _, row := range z {
+		for _, v := range row {
+			putInt(pool, v)
+		}
+	}
+}
+
+// mul sets z = x·y with the schoolbook product: k³ multiplications and
+// k²·(k−1) additions. z may alias x or y. When m is not nil, the entries are
+// reduced modulo m.
+func (z matN) mul(x, y matN, m *big.Int, c *config) {
+	pool := c.pool
+	k := len(z)
+	r := newMatN(pool, k)
+	t := pool.Get().(*big.Int)
+	defer putInt(pool, t)
+
+	for i := 0; i < k; i++ {
+		for j := 0; j < k; j++ {
+			for l := 0; l < k; l++ {
+				r[i][j].Add(r[i][j], t.Mul(x[i][l], y[l][j]))
+			}
+			if m != nil {
+				r[i][j].Mod(r[i][j], m)
+			}
+		}
+	}
+	c.count(int64(k*k*k), int64(k*k*(k-1)))
+
+	// Swap the rows instead of copying them; the old ones go back to the pool.
+	for i := range z {
+		z[i], r[i] = r[i], z[i]
+	}
+	r.release(pool)
+}
+
+// matNPow returns base^exp, computed by binary exponentiation from the most
+// significant bit like matPow, with entries taken from c.pool: the caller
+// releases it. base is left unchanged, and exp = 0 gives the identity. When
+// m is not nil, the entries are reduced modulo m after each product.
+// Progress is the share of the exponent bits consumed.
+func matNPow(ctx context.Context, base matN, exp uint, m *big.Int, c *config) (matN, error) {
+	pool := c.pool
+	k := len(base)
+	if exp == 0 {
+		c.report(100.0)
+		return identityMatN(pool, k), nil
+	}
+	res := newMatN(pool, k)
+	for i := range res {
+		for j := range res[i] {
+			res[i][j].Set(base[i][j])
+		}
+	}
+
+	totalBits := bits.Len(exp)
+	for i := totalBits - 2; i >= 0; i-- {
+		// Cooperative context cancellation check
+		select {
+		case <-ctx.Done():
+			res.release(pool)
+			return nil, ctx.Err()
+		default:
+		}
+
+		res.mul(res, res, m, c)
+		if exp>>i&1 == 1 {
+			res.mul(res, base, m, c)
+		}
+		c.report(float64(totalBits-i) / float64(totalBits) * 100.0)
+	}
+	c.report(100.0)
+	return res, nil
+}
@@ -0,0 +1,272 @@
+package fib
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"math/bits"
+	"sync"
+)
+
+// Matrix calculates F(n) by exponentiation of the Fibonacci Q-matrix.
+//
+// Concept:
+// Q = [[1, 1], [1, 0]] satisfies Qᵏ = [[F(k+1), F(k)], [F(k), F(k-1)]], so
+// F(n) is the top-right entry of Qⁿ. The power is computed by binary
+// exponentiation (see matPow): O(log n) matrix products.
+//
+// Strengths/Weaknesses:
+// O(log n) like Fast Doubling, but each general 2x2 product costs 8 big.Int
+// multiplications, so it is noticeably slower. MatrixFast exploits the
+// symmetry of the powers of Q to need only 3.
+func Matrix(ctx context.Context, n int, opts ...Option) (*big.Int, error) {
+	return matrix(ctx, n, nil, newConfig(opts))
+}
+
+// MatrixMod calculates F(n) mod m by Q-matrix exponentiation, reducing every
+// entry modulo m after each product.
+func MatrixMod(ctx context.Context, n int, m *big.Int, opts ...Option) (*big.Int, error) {
+	if err := checkModulus(m); err != nil {
+		return nil, err
+	}
+	return matrix(ctx, reduceByPisano(n, m), m, newConfig(opts))
+}
+
+// mat2 is a 2x2 matrix [[a, b], [c, d]] of big integers.
+type mat2 struct {
+	a, b, c, d *big.Int
+}
+
+// newMat2 returns the matrix [[a, b], [c, d]] with entries taken from pool.
+func newMat2(pool *sync.Pool, a, b, c, d int64) *mat2 {
+	return &mat2{
+		a: pool.Get().(*big.Int).SetInt64(a),
+		b: pool.Get().(*big.Int).SetInt64(b),
+		c: pool.Get().(*big.Int).SetInt64(c),
+		d: pool.Get().(*big.Int).SetInt64(d),
+	}
+}
+
+// release returns the entries of z to pool.
+func (z *mat2) release(pool *sync.Pool) {
+	putInt(pool, z.a)
+	putInt(pool, z.b)
+	putInt(pool, z.c)
+	putInt(pool, z.d)
+}
+
+// mul sets z = x·y with the schoolbook product: 8 multiplications and 4
+// additions. z may alias x or y. When m is not nil, the entries are reduced
+// modulo m.
+func (z *mat2) mul(x, y *mat2, m *big.Int, pool *sync.Pool) {
+	r := newMat2(pool, 0, 0, 0, 0)
+	t := pool.Get().(*big.Int)
+	defer putInt(pool, t)
+
+	r.a.Mul(x.a, y.a).Add(r.a, t.Mul(x.b, y.c))
+	r.b.Mul(x.a, y.b).Add(r.b, t.Mul(x.b, y.d))
+	r.c.Mul(x.c, y.a).Add(r.c, t.Mul(x.d, y.c))
+	r.d.Mul(x.c, y.b).Add(r.d, t.Mul(x.d, y.d))
+	if m != nil {
+		r.a.Mod(r.a, m)
+		r.b.Mod(r.b, m)
+		r.c.Mod(r.c, m)
+		r.d.Mod(r.d, m)
+	}
+
+	// Swap the entries instead of copying them; the old ones go back to the pool.
+	*z, *r = *r, *z
+	r.release(pool)
+}
+
+// matPow returns base^exp, computed by binary exponentiation, with entries
+// taken from c.pool: the caller releases it. base is left unchanged, and
+// exp = 0 gives the identity. When m is not nil, the entries are reduced
+// modulo m after each product.
+//
+// The bits of exp are read from the most significant one, like Fast
+// Doubling: the result starts as base for the leading 1 bit, and each
+// following bit squares it, then multiplies it by base when the bit is set.
+// No product involves the identity, and the squarings stop with the bits.
+// Progress is the share of the exponent bits consumed.
+func matPow(ctx context.Context, base *mat2, exp uint, m *big.Int, c *config) (*mat2, error) {
+	pool := c.pool
+	if exp == 0 {
+		c.report(100.0)
+		return newMat2(pool, 1, 0, 0, 1), nil // Identity
+	}
+	res := newMat2(pool, 0, 0, 0, 0)
+	res.a.Set(base.a)
+	res.b.Set(base.b)
+	res.c.Set(base.c)
+	res.d.Set(base.d)
+
+	totalBits := bits.Len(exp)
+	for i := totalBits - 2; i >= 0; i-- {
+		// Cooperative context cancellation check
+		select {
+		case <-ctx.Done():
+			res.release(pool)
+			return nil, ctx.Err()
+		default:
+		}
+
+		res.mul(res, res, m, pool)
+		c.count(8, 4)
+		if exp>>i&1 == 1 {
+			res.mul(res, base, m, pool)
+			c.count(8, 4)
+		}
+		c.report(float64(totalBits-i) / float64(totalBits) * 100.0)
+	}
+	c.report(100.0)
+	return res, nil
+}
+
+// matrix is the shared implementation behind Matrix and MatrixMod: F(n) is
+// the top-right entry of Qⁿ, which holds for n = 0 (the identity) and n = 1
+// (Q itself) too.
+func matrix(ctx context.Context, n int, m *big.Int, c *config) (*big.Int, error) {
+	if n < 0 {
+		if -n < 0 { // -math.MinInt overflows
+			return nil, fmt.Errorf("%w: %d", ErrIndexOutOfRange, n)
+		}
+		v, err := matrix(ctx, -n, m, c)
+		if err == nil {
+			negate(v, -n, m)
+		}
+		return v, err
+	}
+
+	q := newMat2(c.pool, 1, 1, 1, 0)
+	defer q.release(c.pool)
+	p, err := matPow(ctx, q, uint(n), m, c)
+	if err != nil {
+		return nil, err
+	}
+	defer p.release(c.pool)
+
+	v := new(big.Int).Set(p.b) // F(n), copied out of the pooled matrix
+	if m != nil {
+		v.Mod(v, m) // Q⁰ and Q¹ are not reduced by any product
+	}
+	return v, nil
+}
+
+// MatrixFast calculates F(n) by Q-matrix exponentiation, using the symmetry
+// of the powers of Q to need 3 multiplications per product instead of 8.
+//
+// Concept:
+// Every power of Q is symmetric, [[x, y], [y, z]], with z = x − y since
+// F(k-1) = F(k+1) − F(k). Such a matrix is fully described by (x, y), and
+// for two of them:
+//
+//	x = x1·x2 + y1·y2
+//	y = x1·y2 + y1·(x2 − y2) = (x1+y1)(x2+y2) − x1·x2 − 2·y1·y2
+//
+// which takes the 3 products x1·x2, y1·y2 and (x1+y1)(x2+y2). A squaring
+// likewise takes x², y² and y·(2x − y).
+func MatrixFast(ctx context.Context, n int, opts ...Option) (*big.Int, error) {
+	return matrixFast(ctx, n, nil, newConfig(opts))
+}
+
+// MatrixFastMod calculates F(n) mod m with MatrixFast, reducing the entries
+// modulo m after each product.
+func MatrixFastMod(ctx context.Context, n int, m *big.Int, opts ...Option) (*big.Int, error) {
+	if err := checkModulus(m); err != nil {
+		return nil, err
+	}
+	return matrixFast(ctx, reduceByPisano(n, m), m, newConfig(opts))
+}
+
+// sym2 is a power of Q, [[x, y], [y, x − y]], stored as (x, y).
+type sym2 struct {
+	x, y *big.Int
+}
+
+// mul sets z = p·q with 3 multiplications and 6 additions or subtractions.
+// z may alias p or q. t1 to t4 are
+// scratch values. When m is not nil, the entries are reduced modulo m.
+func (z *sym2) mul(p, q *sym2, m, t1, t2, t3, t4 *big.Int) {
+	t1.Mul(p.x, q.x)                           // x1·x2
+	t2.Mul(p.y, q.y)                           // y1·y2
+	t3.Mul(t3.Add(p.x, p.y), t4.Add(q.x, q.y)) // (x1+y1)(x2+y2)
+	z.y.Sub(t3, t1).Sub(z.y, t2).Sub(z.y, t2)
+	z.x.Add(t1, t2)
+	if m != nil {
+		z.x.Mod(z.x, m)
+		z.y.Mod(z.y, m)
+	}
+}
+
+// square sets z = z² with 3 multiplications and 3 linear operations:
+// x' = x² + y², y' = y·(2x − y).
+func (z *sym2) square(m, t1, t2 *big.Int) {
+	t1.Lsh(z.x, 1).Sub(t1, z.y) // 2x − y
+	t2.Mul(z.y, z.y)            // y²
+	z.y.Mul(z.y, t1)            // y·(2x − y)
+	z.x.Mul(z.x, z.x).Add(z.x, t2)
+	if m != nil {
+		z.x.Mod(z.x, m)
+		z.y.Mod(z.y, m)
+	}
+}
+
+// matrixFast is the shared implementation behind MatrixFast and MatrixFastMod.
+func matrixFast(ctx context.Context, n int, m *big.Int, c *config) (*big.Int, error) {
+	if n < 0 {
+		if -n < 0 { // -math.MinInt overflows
+			return nil, fmt.Errorf("%w: %d", ErrIndexOutOfRange, n)
+		}
+		v, err := matrixFast(ctx, -n, m, c)
+		if err == nil {
+			negate(v, -n, m)
+		}
+		return v, err
+	}
+	if n <= 1 {
+		c.report(100.0)
+		v := big.NewInt(int64(n))
+		if m != nil {
+			v.Mod(v, m)
+		}
+		return v, nil
+	}
+
+	pool := c.pool
+	get := func(v int64) *big.Int { return pool.Get().(*big.Int).SetInt64(v) }
+	res := &sym2{x: get(1), y: get(0)}  // Identity: [[1, 0], [0, 1]]
+	base := &sym2{x: get(1), y: get(1)} // Q: [[1, 1], [1, 0]]
+	t1, t2, t3, t4 := get(0), get(0), get(0), get(0)
+	for _, v := range []*big.Int{res.x, res.y, base.x, base.y, t1, t2, t3, t4} {
+		defer putInt(pool, v)
+	}
+
+	// Q^(n-1) by binary exponentiation from the least significant bit: base
+	// runs through Q, Q², Q⁴... and is multiplied into res for each set bit.
+	// Unlike matPow, which reads the bits from the most significant one and
+	// multiplies by Q itself, this loop squares base in place.
+	exp := uint(n - 1)
+	totalBits := bits.Len(exp)
+	for exp > 0 {
+		// Cooperative context cancellation check
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+
+		if exp&1 == 1 {
+			res.mul(res, base, m, t1, t2, t3, t4)
+			c.count(3, 6)
+		}
+		exp >>= 1
+		if exp > 0 { // Only square if more steps remain
+			base.square(m, t1, t2)
+			c.count(3, 3)
+		}
+		c.report(float64(totalBits-bits.Len(exp)) / float64(totalBits) * 100.0)
+	}
+
+	return new(big.Int).Set(res.x), nil // F(n), copied out of the pooled values
+}
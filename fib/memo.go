@@ -0,0 +1,105 @@
+package fib
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+)
+
+// Memo calculates F(n) with top-down recursion and memoization.
+//
+// Concept:
+// The classic teaching example: F(n) = F(n-1) + F(n-2), where every F(k)
+// already computed is kept in a cache so that each subproblem is solved only
+// once. This turns the exponential naive recursion into O(n) additions.
+//
+// Implementation:
+// The recursion is unrolled onto an explicit stack of pending indices, so a
+// large n cannot overflow the goroutine stack. Progress counts the distinct
+// subproblems solved so far.
+//
+// Strengths/Weaknesses:
+// Purely educational: the cache keeps every F(k) alive, so memory grows as
+// O(n²) bits (hundreds of megabytes around n = 100 000). The values are
+// retained, so the pool is not used.
+func Memo(ctx context.Context, n int, opts ...Option) (*big.Int, error) {
+	return memo(ctx, n, nil, newConfig(opts))
+}
+
+// MemoMod calculates F(n) mod m with memoized recursion, reducing every
+// cached value modulo m. The cache then stays O(n) words in size.
+func MemoMod(ctx context.Context, n int, m *big.Int, opts ...Option) (*big.Int, error) {
+	if err := checkModulus(m); err != nil {
+		return nil, err
+	}
+	return memo(ctx, reduceByPisano(n, m), m, newConfig(opts))
+}
+
+// memo is the shared implementation behind Memo and MemoMod.
+func memo(ctx context.Context, n int, m *big.Int, c *config) (*big.Int, error) {
+	if n < 0 {
+		if -n < 0 { // -math.MinInt overflows
+			return nil, fmt.Errorf("%w: %d", ErrIndexOutOfRange, n)
+		}
+		v, err := memo(ctx, -n, m, c)
+		if err == nil {
+			negate(v, -n, m)
+		}
+		return v, err
+	}
+
+	cache := map[int]*big.Int{0: big.NewInt(0), 1: big.NewInt(1)}
+	if m != nil {
+		cache[1].Mod(cache[1], m)
+	}
+
+	// Each stack entry is a call to F(k) whose result is not known yet. A
+	// "call" whose operands are both cached completes and is popped; otherwise
+	// the missing operands are pushed, F(k-1) last so that it is evaluated
+	// first, as in the recursive version.
+	stack := []int{n}
+	total := n - 1 // Distinct subproblems to solve: F(2) to F(n)
+	solved := 0
+	reportEvery := n/100 + 1 // Report progress about once per percent
+
+	for len(stack) > 0 {
+		// Cooperative context cancellation check
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+
+		k := stack[len(stack)-1]
+		if _, done := cache[k]; done {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		f1, ok1 := cache[k-1]
+		f2, ok2 := cache[k-2]
+		if !ok1 || !ok2 {
+			if !ok2 {
+				stack = append(stack, k-2)
+			}
+			if !ok1 {
+				stack = append(stack, k-1)
+			}
+			continue
+		}
+
+		v := new(big.Int).Add(f1, f2)
+		if m != nil {
+			v.Mod(v, m)
+		}
+		cache[k] = v
+		stack = stack[:len(stack)-1]
+
+		solved++
+		if solved%reportEvery == 0 {
+			c.report(float64(solved) / float64(total) * 100.0)
+		}
+	}
+
+	c.report(100.0)
+	return cache[n], nil
+}
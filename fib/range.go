@@ -0,0 +1,79 @@
+package fib
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+)
+
+// Range calls yield with F(i) for every i from a to b inclusive, in order.
+//
+// Concept:
+// Computing each term separately would repeat almost all the work. Instead,
+// Fast Doubling gives the starting pair F(a), F(a+1) in O(log a) steps, and
+// the recurrence F(i+2) = F(i+1) + F(i) then produces each following term
+// with a single addition.
+//
+// Only two values are held at any time, so memory stays bounded however long
+// the range is. The value passed to yield is reused by the next step: copy
+// it if it must outlive the call. A non-nil error from yield stops the
+// iteration and is returned as is.
+func Range(ctx context.Context, a, b int, yield func(i int, v *big.Int) error, opts ...Option) error {
+	return fibRange(ctx, a, b, nil, newConfig(opts), yield)
+}
+
+// RangeMod is the modular variant of Range, yielding F(i) mod m.
+func RangeMod(ctx context.Context, a, b int, m *big.Int, yield func(i int, v *big.Int) error, opts ...Option) error {
+	if err := checkModulus(m); err != nil {
+		return err
+	}
+	return fibRange(ctx, a, b, m, newConfig(opts), yield)
+}
+
+// fibRange is the shared implementation behind Range and RangeMod.
+func fibRange(ctx context.Context, a, b int, m *big.Int, c *config, yield func(i int, v *big.Int) error) error {
+	if a < 0 || b < a {
+		return fmt.Errorf("%w: range [%d, %d]: bounds must satisfy 0 <= a <= b", ErrInvalidArgument, a, b)
+	}
+
+	// Seed with F(a) and F(a+1). Progress covers the iteration only, so the
+	// seeding runs without the callback.
+	seed := *c
+	seed.progress = nil
+	fi, fi1 := new(big.Int), new(big.Int)
+	if err := fastDoublingInto(ctx, a, m, &seed, fi, fi1); err != nil {
+		return err
+	}
+
+	total := b - a + 1
+	reportEvery := total/100 + 1 // Report progress about once per percent
+	for i := a; ; i++ {
+		// Cooperative context cancellation check
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		if err := yield(i, fi); err != nil {
+			return err
+		}
+		if i == b {
+			break
+		}
+
+		// (fi, fi1) = (fi1, fi + fi1), reusing fi's storage for the sum.
+		fi.Add(fi, fi1)
+		if m != nil {
+			fi.Mod(fi, m)
+		}
+		fi, fi1 = fi1, fi
+
+		if done := i - a + 1; done%reportEvery == 0 {
+			c.report(float64(done) / float64(total) * 100.0)
+		}
+	}
+
+	c.report(100.0)
+	return nil
+}
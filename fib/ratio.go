@@ -0,0 +1,53 @@
+package fib
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// ratioGuardBits is the precision kept for the difference with φ once the
+// bits it shares with the ratio cancel out.
+const ratioGuardBits = 64
+
+// Ratio returns F(n+1)/F(n), n >= 1, and its difference with the golden ratio
+// φ = (1+√5)/2, F(n+1)/F(n) − φ.
+//
+// Concept:
+// The ratios of consecutive terms are the convergents of the continued
+// fraction [1; 1, 1, 1, ...] of φ, its best rational approximations. Binet's
+// formula gives F(n+1) − φ·F(n) = ψⁿ, so the difference is exactly ψⁿ/F(n),
+// of sign (−1)ⁿ and about √5·φ^(−2n) in absolute value: the ratio approaches
+// φ alternately from above and below, gaining 2·log10(φ) ≈ 0.418 correct
+// decimal digits per step.
+//
+// Implementation:
+// FastDoublingPair yields both terms in a single pass. The quotient and φ are
+// then computed as big.Float values with 2n·log2(φ) + 64 bits of precision:
+// the subtraction cancels the first 2n·log2(φ) bits they share, and leaves
+// the difference with 64 significant bits. The cost of the division and of
+// the square root of φ grows with this precision, much like Binet.
+func Ratio(ctx context.Context, n int, opts ...Option) (ratio, diff *big.Float, err error) {
+	if n < 1 {
+		return nil, nil, fmt.Errorf("%w: the ratio F(n+1)/F(n) requires n >= 1. Received: %d", ErrIndexOutOfRange, n)
+	}
+	fn, fn1, err := FastDoublingPair(ctx, n, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	prec := uint(2*float64(n)*math.Log2(math.Phi)) + ratioGuardBits
+	ratio = new(big.Float).SetPrec(prec).SetInt(fn1)
+	ratio.Quo(ratio, new(big.Float).SetPrec(prec).SetInt(fn))
+	diff = new(big.Float).SetPrec(prec).Sub(ratio, Phi(prec))
+	return ratio, diff, nil
+}
+
+// Phi returns the golden ratio (1+√5)/2 rounded to prec bits.
+func Phi(prec uint) *big.Float {
+	v := new(big.Float).SetPrec(prec).SetInt64(5)
+	v.Sqrt(v)
+	v.Add(v, new(big.Float).SetInt64(1))
+	return v.SetMantExp(v, -1) // Exact halving
+}
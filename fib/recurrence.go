@@ -0,0 +1,145 @@
+package fib
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// Recurrence calculates G(n), n >= 0, for the linear recurrence of order
+// k = len(coeffs)
+//
+//	G(n) = c1·G(n−1) + c2·G(n−2) + ... + ck·G(n−k)
+//
+// with coeffs = c1, ..., ck and the initial terms init = G(0), ..., G(k−1):
+// 1,1 from 0,1 gives the Fibonacci numbers, 2,1 from 0,1 the Pell numbers
+// and 1,1,1 from 0,0,1 the tribonacci numbers. The coefficients and the
+// initial terms are integers of any size and sign.
+//
+// Concept:
+// The companion matrix M holds the coefficients on its first row and ones
+// below its diagonal, so that it moves the state (G(j+k−1), ..., G(j)) one
+// step forward:
+//
+//	M = [[c1, c2, ..., ck],
+//	     [ 1,  0, ...,  0],
+//	     ...
+//	     [ 0, ...,  1,  0]]
+//
+// Mⁿ maps (G(k−1), ..., G(0)) to (G(n+k−1), ..., G(n)): G(n) is the dot
+// product of the last row of Mⁿ with the initial state. The power is computed
+// by binary exponentiation of matN, as Tribonacci does for k = 3: O(log n)
+// products of k³ multiplications each. Recurrence with 1,1 is Matrix with the
+// Q-matrix, without its 2x2 specializations.
+//
+// Unlike the Fibonacci and tribonacci recurrences, a general one cannot be
+// run backwards over the integers (ck may be zero, or not divide), so n must
+// be non-negative.
+func Recurrence(ctx context.Context, n int, coeffs, init []*big.Int, opts ...Option) (*big.Int, error) {
+	return recurrence(ctx, n, coeffs, init, nil, newConfig(opts))
+}
+
+// RecurrenceMod calculates G(n) mod m, reducing every entry modulo m after
+// each matrix product.
+func RecurrenceMod(ctx context.Context, n int, coeffs, init []*big.Int, m *big.Int, opts ...Option) (*big.Int, error) {
+	if err := checkModulus(m); err != nil {
+		return nil, err
+	}
+	return recurrence(ctx, n, coeffs, init, m, newConfig(opts))
+}
+
+// recurrence is the shared implementation behind Recurrence and
+// RecurrenceMod.
+func recurrence(ctx context.Context, n int, coeffs, init []*big.Int, m *big.Int, c *config) (*big.Int, error) {
+	k := len(coeffs)
+	if k == 0 || len(init) != k {
+		return nil, fmt.Errorf("%w: a recurrence of order k needs k >= 1 coefficients and k initial terms. Received: %d and %d", ErrInvalidArgument, k, len(init))
+	}
+	if n < 0 {
+		return nil, fmt.Errorf("%w: the recurrence G(n) requires n >= 0. Received: %d", ErrIndexOutOfRange, n)
+	}
+	base := newMatN(c.pool, k)
+	defer base.release(c.pool)
+	for j, coeff := range coeffs {
+		base[0][j].Set(coeff)
+	}
+	for i := 1; i < k; i++ {
+		base[i][i-1].SetInt64(1)
+	}
+	p, err := matNPow(ctx, base, uint(n), m, c)
+	if err != nil {
+		return nil, err
+	}
+	defer p.release(c.pool)
+
+	// G(n) = Σ Mⁿ[k−1][j]·G(k−1−j), the state listing the latest term first.
+	v := new(big.Int)
+	t := c.pool.Get().(*big.Int)
+	defer putInt(c.pool, t)
+	for j, e := range p[k-1] {
+		v.Add(v, t.Mul(e, init[k-1-j]))
+	}
+	c.count(int64(k), int64(k-1))
+	if m != nil {
+		v.Mod(v, m)
+	}
+	return v, nil
+}
+
+// EstimateRecurrenceBytes returns an estimate of the size of |G(n)| in bytes
+// for the recurrence of Recurrence, without computing G(n).
+//
+// Concept:
+// |G(n)| is at most max|G(i)| times H(n), the recurrence with the absolute
+// values of the coefficients started from ones, which grows as ρⁿ where ρ is
+// the dominant root of its characteristic polynomial. ρ is obtained by running
+// H in float64, renormalized at each step, until the ratio H(j)/H(j−k)
+// settles: measured over k steps, it converges even when the nonzero
+// coefficients make H oscillate. The estimate is n·log2(ρ) bits, plus those
+// of the largest initial term and a margin for the first steps, where H has
+// not settled yet. A negative n, which Recurrence rejects, counts as 0.
+func EstimateRecurrenceBytes(n int, coeffs, init []*big.Int) uint64 {
+	k := len(coeffs)
+	abs := make([]float64, k)
+	sum := 0.0
+	for i, coeff := range coeffs {
+		f, _ := new(big.Float).SetInt(coeff).Float64()
+		abs[i] = math.Abs(f)
+		sum += abs[i]
+	}
+	var initBits int
+	for _, g := range init {
+		initBits = max(initBits, g.BitLen())
+	}
+
+	// h holds H(j−k+1), ..., H(j), scaled so that H(j) = 1; logs[j%k] is the
+	// log2 of the growth H(j)/H(j−1) before rescaling.
+	const steps = 4096
+	rate := 0.0
+	if sum > 0 {
+		h := make([]float64, k)
+		for i := range h {
+			h[i] = 1
+		}
+		logs := make([]float64, k)
+		for j := 0; j < steps; j++ {
+			next := 0.0
+			for i, a := range abs {
+				next += a * h[k-1-i]
+			}
+			copy(h, h[1:])
+			h[k-1] = next
+			for i := range h {
+				h[i] /= next
+			}
+			logs[j%k] = math.Log2(next)
+		}
+		for _, l := range logs {
+			rate += l
+		}
+		rate = max(rate/float64(k), 0)
+	}
+	bits := float64(max(n, 0))*rate + float64(initBits) + math.Log2(float64(k)) + math.Log2(max(sum, 1)) + 1
+	return uint64(bits)/8 + 1
+}
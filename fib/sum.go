@@ -0,0 +1,86 @@
+package fib
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// Sum calculates F(0) + F(1) + ... + F(n) for n >= 0.
+//
+// Concept:
+// The partial sums of the sequence have the closed form
+// F(0) + ... + F(n) = F(n+2) − 1, so the sum costs a single Fast Doubling
+// call instead of n additions.
+func Sum(ctx context.Context, n int, opts ...Option) (*big.Int, error) {
+	return sum(ctx, n, nil, newConfig(opts))
+}
+
+// SumMod calculates (F(0) + ... + F(n)) mod m.
+func SumMod(ctx context.Context, n int, m *big.Int, opts ...Option) (*big.Int, error) {
+	if err := checkModulus(m); err != nil {
+		return nil, err
+	}
+	return sum(ctx, n, m, newConfig(opts))
+}
+
+// sum is the shared implementation behind Sum and SumMod.
+func sum(ctx context.Context, n int, m *big.Int, c *config) (*big.Int, error) {
+	if n < 0 || n > math.MaxInt-2 {
+		return nil, fmt.Errorf("%w for a sum: %d", ErrIndexOutOfRange, n)
+	}
+	k := n + 2
+	if m != nil {
+		k = reduceByPisano(k, m)
+	}
+	s := new(big.Int)
+	if err := fastDoublingInto(ctx, k, m, c, s, nil); err != nil {
+		return nil, err
+	}
+	s.Sub(s, big.NewInt(1))
+	if m != nil {
+		s.Mod(s, m)
+	}
+	return s, nil
+}
+
+// SumSquares calculates F(0)² + F(1)² + ... + F(n)² for n >= 0.
+//
+// Concept:
+// The squares tile a golden rectangle of sides F(n) and F(n+1), hence
+// F(0)² + ... + F(n)² = F(n)·F(n+1). Fast Doubling yields both factors in a
+// single pass, so the sum costs one more multiplication than F(n).
+func SumSquares(ctx context.Context, n int, opts ...Option) (*big.Int, error) {
+	return sumSquares(ctx, n, nil, newConfig(opts))
+}
+
+// SumSquaresMod calculates (F(0)² + ... + F(n)²) mod m.
+func SumSquaresMod(ctx context.Context, n int, m *big.Int, opts ...Option) (*big.Int, error) {
+	if err := checkModulus(m); err != nil {
+		return nil, err
+	}
+	return sumSquares(ctx, n, m, newConfig(opts))
+}
+
+// sumSquares is the shared implementation behind SumSquares and SumSquaresMod.
+func sumSquares(ctx context.Context, n int, m *big.Int, c *config) (*big.Int, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("%w for a sum: %d", ErrIndexOutOfRange, n)
+	}
+	if m != nil {
+		n = reduceByPisano(n, m)
+	}
+	fn := new(big.Int)
+	fn1 := c.pool.Get().(*big.Int)
+	defer putInt(c.pool, fn1)
+
+	if err := fastDoublingInto(ctx, n, m, c, fn, fn1); err != nil {
+		return nil, err
+	}
+	fn.Mul(fn, fn1)
+	if m != nil {
+		fn.Mod(fn, m)
+	}
+	return fn, nil
+}
@@ -0,0 +1,73 @@
+package fib
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+)
+
+// Tribonacci calculates the tribonacci number T(n), defined by
+// T(0) = T(1) = 0, T(2) = 1 and T(n) = T(n−1) + T(n−2) + T(n−3):
+// 0, 0, 1, 1, 2, 4, 7, 13, 24, 44...
+//
+// Concept:
+// The recurrence of order 3 moves the state (T(k+2), T(k+1), T(k)) one step
+// forward through the companion matrix
+//
+//	M = [[1, 1, 1],
+//	     [1, 0, 0],
+//	     [0, 1, 0]]
+//
+// so Mⁿ maps (T(2), T(1), T(0)) = (1, 0, 0) to (T(n+2), T(n+1), T(n)):
+// T(n) is the bottom-left entry of Mⁿ. The power is computed by binary
+// exponentiation of the generalized matrix type matN, as Matrix does for the
+// 2x2 Q-matrix: O(log n) products of 27 multiplications each. T(n) grows as
+// ψⁿ with ψ ≈ 1.839, the tribonacci constant, about 0.879·n bits.
+//
+// Run backwards, T(n) = T(n+3) − T(n+2) − T(n+1) extends the sequence to
+// negative indices: 1, −1, 0, 2, −3... for n = −1, −2, −3... Since
+// det M = 1, M⁻¹ = [[0, 1, 0], [0, 0, 1], [1, −1, −1]] has integer entries,
+// and T(n) is the bottom-left entry of M⁻¹ raised to −n.
+func Tribonacci(ctx context.Context, n int, opts ...Option) (*big.Int, error) {
+	return tribonacci(ctx, n, nil, newConfig(opts))
+}
+
+// TribonacciMod calculates T(n) mod m, reducing every entry modulo m after
+// each matrix product.
+func TribonacciMod(ctx context.Context, n int, m *big.Int, opts ...Option) (*big.Int, error) {
+	if err := checkModulus(m); err != nil {
+		return nil, err
+	}
+	return tribonacci(ctx, n, m, newConfig(opts))
+}
+
+// tribonacci is the shared implementation behind Tribonacci and TribonacciMod.
+func tribonacci(ctx context.Context, n int, m *big.Int, c *config) (*big.Int, error) {
+	entries := [3][3]int64{{1, 1, 1}, {1, 0, 0}, {0, 1, 0}} // M
+	exp := n
+	if n < 0 {
+		if -n < 0 { // -math.MinInt overflows
+			return nil, fmt.Errorf("%w: %d", ErrIndexOutOfRange, n)
+		}
+		entries = [3][3]int64{{0, 1, 0}, {0, 0, 1}, {1, -1, -1}} // M⁻¹
+		exp = -n
+	}
+	base := newMatN(c.pool, 3)
+	defer base.release(c.pool)
+	for i, row := range entries {
+		for j, e := range row {
+			base[i][j].SetInt64(e)
+		}
+	}
+	p, err := matNPow(ctx, base, uint(exp), m, c)
+	if err != nil {
+		return nil, err
+	}
+	defer p.release(c.pool)
+
+	v := new(big.Int).Set(p[2][0]) // T(n), copied out of the pooled matrix
+	if m != nil {
+		v.Mod(v, m) // The powers 0 and 1 are not reduced by any product
+	}
+	return v, nil
+}
@@ -0,0 +1,75 @@
+package fib
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// wordChunkSize bounds the length of the words WriteWord builds in memory.
+const wordChunkSize = 1 << 16
+
+// WriteWord writes the n-th Fibonacci word, n >= 0, to w.
+//
+// Concept:
+// The Fibonacci words are S(0) = "0", S(1) = "01" and S(n) = S(n−1)·S(n−2),
+// the concatenation of the two previous ones: "0", "01", "010", "01001",
+// "01001010"... They are also the iterates of the substitution 0 → 01,
+// 1 → 0 from "0". Their lengths add up like the Fibonacci numbers, from
+// F(2) = 1 and F(3) = 2: S(n) has F(n+2) symbols, F(n+1) zeros and F(n)
+// ones. Each word is a prefix of the next.
+//
+// Implementation:
+// The words of at most wordChunkSize symbols are built by concatenation. A
+// longer word is never materialized: it is written as the leaves of its
+// concatenation tree, walked depth first with an explicit stack of indices,
+// where S(k) stands for S(k−1) followed by S(k−2). The memory stays within
+// the built words, about φ²·wordChunkSize bytes, and a stack of at most n
+// indices, however long the output.
+func WriteWord(ctx context.Context, w io.Writer, n int, opts ...Option) error {
+	if n < 0 {
+		return fmt.Errorf("%w: the Fibonacci word S(n) requires n >= 0. Received: %d", ErrIndexOutOfRange, n)
+	}
+	c := newConfig(opts)
+
+	words := [][]byte{[]byte("0"), []byte("01")}
+	for k := 2; k <= n; k++ {
+		prev := words[k-1]
+		if len(prev)+len(words[k-2]) > wordChunkSize {
+			break
+		}
+		words = append(words, append(append(make([]byte, 0, len(prev)+len(words[k-2])), prev...), words[k-2]...))
+	}
+
+	// The length F(n+2), as a float64, only serves the progress percentage.
+	var total float64
+	if c.progress != nil {
+		length, err := FastDoubling(ctx, n+2)
+		if err != nil {
+			return err
+		}
+		total, _ = length.Float64()
+	}
+
+	var written float64
+	stack := []int{n}
+	for len(stack) > 0 {
+		k := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if k >= len(words) {
+			stack = append(stack, k-2, k-1) // S(k−1) is popped first
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if _, err := w.Write(words[k]); err != nil {
+			return err
+		}
+		if c.progress != nil {
+			written += float64(len(words[k]))
+			c.report(100 * written / total)
+		}
+	}
+	return nil
+}
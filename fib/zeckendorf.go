@@ -0,0 +1,66 @@
+package fib
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// Zeckendorf returns the Zeckendorf representation of x >= 0: the indices,
+// in decreasing order, of the non-consecutive Fibonacci numbers F(k), k >= 2,
+// summing to x. Zeckendorf's theorem makes it unique. It is empty for x = 0.
+//
+// Concept:
+// The greedy algorithm takes the largest F(k) <= x, then repeats on the
+// remainder, which is below F(k+1) − F(k) = F(k−1): the next term is at most
+// F(k−2), hence the gaps. The first F(k) is located by inverting Binet's
+// formula and confirmed by Fast Doubling, which yields F(k) and F(k+1) at
+// once; the following candidates are then reached by walking the sequence
+// down with one subtraction per index, F(k−1) = F(k+1) − F(k).
+func Zeckendorf(ctx context.Context, x *big.Int, opts ...Option) ([]int, error) {
+	if x.Sign() < 0 {
+		return nil, fmt.Errorf("%w: zeckendorf representation of a negative number: %s", ErrInvalidArgument, x)
+	}
+	if x.Sign() == 0 {
+		return nil, nil
+	}
+	c := newConfig(opts)
+
+	k := int((bigLog(x) + math.Log(math.Sqrt(5))) / math.Log(math.Phi))
+	k = max(k, 2)
+	a, b := new(big.Int), new(big.Int) // a = F(k), b = F(k+1)
+	if err := fastDoublingInto(ctx, k, nil, c, a, b); err != nil {
+		return nil, err
+	}
+	// The float64 estimate may be off by one: settle F(k) <= x < F(k+1).
+	for a.Cmp(x) > 0 && k > 2 {
+		a, b = b.Sub(b, a), a
+		k--
+	}
+	for b.Cmp(x) <= 0 {
+		a, b = b, a.Add(a, b)
+		k++
+	}
+
+	var indices []int
+	r := new(big.Int).Set(x)
+	for ; r.Sign() > 0; k-- {
+		// Cooperative context cancellation check
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+
+		if a.Cmp(r) <= 0 {
+			r.Sub(r, a)
+			indices = append(indices, k)
+		}
+		if k == 2 { // F(2) = 1 always fits: r is 0 here
+			break
+		}
+		a, b = b.Sub(b, a), a // (F(k), F(k+1)) → (F(k−1), F(k))
+	}
+	return indices, nil
+}
@@ -1,3 +1,3 @@
-module fibapp
+module github.com/agbruneau/FibJule
 
 go 1.22.2
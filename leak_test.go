@@ -0,0 +1,102 @@
+// leak_test.go
+
+package main
+
+import (
+	"context"
+	"io"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+// ------------------------------------------------------------
+// Goroutine Leak Detection
+// ------------------------------------------------------------
+//
+// Tasks, their relays, the race of -only-fastest and the progress display all
+// run in goroutines that must exit once a run completes or is cancelled. The
+// tests below compare the number of goroutines before and after a run; the
+// count may take a moment to settle, as exiting goroutines are still being
+// torn down when the run returns.
+
+// leakSettleTimeout bounds the wait for the goroutines of a run to exit.
+const leakSettleTimeout = 2 * time.Second
+
+// checkNoGoroutineLeak runs fn and fails t if more goroutines are running
+// after it than before, once leakSettleTimeout has passed. The stacks of all
+// goroutines are dumped to point at the leaking one.
+func checkNoGoroutineLeak(t *testing.T, name string, fn func()) {
+	t.Helper()
+	before := runtime.NumGoroutine()
+	fn()
+
+	deadline := time.Now().Add(leakSettleTimeout)
+	after := runtime.NumGoroutine()
+	for after > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		after = runtime.NumGoroutine()
+	}
+	if after > before {
+		buf := make([]byte, 1<<20)
+		buf = buf[:runtime.Stack(buf, true)]
+		t.Errorf("%s: %d goroutines before, %d after; stacks:\n%s", name, before, after, buf)
+	}
+}
+
+// TestNoGoroutineLeak checks complete, raced and cancelled runs of Compute,
+// and a run driving the progress display through a ProgressReporter and
+// cancelled midway, as in the CLI.
+func TestNoGoroutineLeak(t *testing.T) {
+	checkNoGoroutineLeak(t, "complete run", func() {
+		if _, err := Compute(context.Background(), 10000, WithAlgorithms("all")); err != nil {
+			t.Fatal(err)
+		}
+	})
+	checkNoGoroutineLeak(t, "-only-fastest", func() {
+		if _, err := Compute(context.Background(), 100000, WithAlgorithms("all"), WithOnlyFastest()); err != nil {
+			t.Fatal(err)
+		}
+	})
+	checkNoGoroutineLeak(t, "cancelled run", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		if _, err := Compute(ctx, 5000000, WithAlgorithms("fast,matrix,binet"), WithConcurrency(1)); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	// The display goroutines of run(): each must exit on cancellation even
+	// though the channel is only closed afterwards.
+	displays := map[string]func(context.Context, <-chan progressData, []string){
+		"progressPrinter": func(ctx context.Context, ch <-chan progressData, names []string) {
+			progressPrinter(ctx, ch, names, 0)
+		},
+		"progressLogger": func(ctx context.Context, ch <-chan progressData, names []string) {
+			progressLogger(ctx, ch, names, io.Discard, time.Millisecond)
+		},
+	}
+	for name, display := range displays {
+		checkNoGoroutineLeak(t, name, func() {
+			tasks, err := resolveTasks("fast,matrix", 0, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			names := []string{tasks[0].name, tasks[1].name}
+			ch := make(chan progressData, 2*len(tasks))
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				display(ctx, ch, names)
+			}()
+			computeTasks(WithProgressReporter(ctx, channelReporter(ch)), tasks, 5000000, runOptions{})
+			close(ch)
+			wg.Wait()
+		})
+	}
+}
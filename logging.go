@@ -0,0 +1,65 @@
+// logging.go
+
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// ------------------------------------------------------------
+// Structured Logging
+// ------------------------------------------------------------
+
+// Log formats accepted by the `-log-format` flag.
+const (
+	logFormatText = "text" // key=value pairs, one record per line (default)
+	logFormatJSON = "json" // One JSON object per line, for log pipelines
+)
+
+// newLogger returns a logger writing records in the given format to w,
+// dropping those below level. Logs always go to stderr in the program, so
+// that they never mix with the results table or the JSON report written to
+// stdout.
+func newLogger(w io.Writer, format string, level slog.Level) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level}
+	if format == logFormatJSON {
+		return slog.New(slog.NewJSONHandler(w, opts))
+	}
+	return slog.New(slog.NewTextHandler(w, opts))
+}
+
+// logLevel returns the lowest level logged: with `-quiet` or `-format
+// value`, only warnings and errors remain.
+func logLevel(cfg config) slog.Level {
+	if cfg.quiet || cfg.format == formatValue {
+		return slog.LevelWarn
+	}
+	return slog.LevelInfo
+}
+
+// fatal logs msg at the error level with the given attributes, then exits
+// with status 1, like log.Fatal.
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	flushProfiles()
+	os.Exit(1)
+}
+
+// logCompletion records the end of a calculation with its structured fields.
+// error is null (JSON) or <nil> (text) on success.
+func logCompletion(r Result, n int) {
+	level := slog.LevelInfo
+	if r.Err != nil {
+		level = slog.LevelWarn
+	}
+	slog.Log(context.Background(), level, "calculation finished",
+		slog.String("algorithm", r.Name),
+		slog.Int("n", n),
+		slog.Float64("duration_ms", float64(r.Duration)/float64(time.Millisecond)),
+		slog.Any("error", r.Err),
+	)
+}
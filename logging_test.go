@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestNewLogger checks that each log format produces the expected encoding.
+func TestNewLogger(t *testing.T) {
+	var buf bytes.Buffer
+	newLogger(&buf, logFormatText, slog.LevelInfo).Info("hello", "n", 42)
+	if out := buf.String(); !strings.Contains(out, "msg=hello") || !strings.Contains(out, "n=42") {
+		t.Errorf("unexpected text record: %q", out)
+	}
+
+	buf.Reset()
+	newLogger(&buf, logFormatJSON, slog.LevelInfo).Info("hello", "n", 42)
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("JSON record does not parse: %v (%q)", err, buf.String())
+	}
+	if record["msg"] != "hello" || record["n"] != 42.0 {
+		t.Errorf("unexpected JSON record: %v", record)
+	}
+}
+
+// TestLogCompletion checks the structured fields logged when a calculation
+// finishes, with and without an error.
+func TestLogCompletion(t *testing.T) {
+	var buf bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(newLogger(&buf, logFormatJSON, slog.LevelInfo))
+
+	logCompletion(Result{Name: "Fast Doubling", Duration: 1500 * time.Microsecond}, 100)
+	logCompletion(Result{Name: "Memoized", Err: errors.New("boom")}, 100)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 records, got %d: %q", len(lines), buf.String())
+	}
+	var ok, failed map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &ok); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &failed); err != nil {
+		t.Fatal(err)
+	}
+	if ok["algorithm"] != "Fast Doubling" || ok["n"] != 100.0 || ok["duration_ms"] != 1.5 || ok["error"] != nil || ok["level"] != "INFO" {
+		t.Errorf("unexpected success record: %v", ok)
+	}
+	if failed["algorithm"] != "Memoized" || failed["error"] != "boom" || failed["level"] != "WARN" {
+		t.Errorf("unexpected failure record: %v", failed)
+	}
+}
@@ -2,26 +2,88 @@
 //
 // This program calculates the n-th Fibonacci number using distinct algorithms:
 // 1. Fast Doubling algorithm.
+// 2. Lucas numbers L(n), derived from the Fast Doubling pair.
+// 3. Q-matrix exponentiation, with general (8 multiplications) or symmetric
+//    (3 multiplications) 2x2 products.
+// 4. Memoized recursion, for educational comparison.
+// 5. Binet's closed-form formula, with a self-checked floating-point precision.
+// 6. Tribonacci numbers T(n), an order-3 recurrence, by 3x3 matrix exponentiation.
 //
-// It executes this algorithm, displays its real-time progress,
-// and its execution time and result.
-// A sync.Pool is used to reduce memory allocations for big.Int objects.
+// It executes the selected algorithms concurrently, displays their real-time
+// progress, their execution time and result, and cross-validates results
+// computing the same sequence. The details of a result time the conversion
+// of its value to text apart from the calculation.
+// Each algorithm uses its own sync.Pool to reduce memory allocations for big.Int objects.
 //
 // Usage:
-//   go run . -n <index> -timeout <duration>
+//   go run . -n <index> -timeout <duration> [-timeout-per-digit <duration>] [-per-algo-timeout <duration>] [-retries <k> [-retry-factor <x>]] [-algorithms <list>] [-mod <m>] [-format table|json|csv|protobuf|value|raw] [-csv-values] [-log-format text|json] [-quiet] [-output <path>] [-base <2..36>] [-sci-digits <k>] [-sum | -sum-squares | -seed <a,b> | -recurrence <c1,...,ck[:g0,...]>] [-digits-only] [-estimate-digits] [-head <k>] [-tail <k>] [-is-fib <x>] [-zeckendorf <x>] [-index-of <x>] [-ratio] [-word <n>] [-crt <p1,p2,...> [-crt-reconstruct]] [-parallel-mul] [-precision-bits <bits>] [-cache <dir>] [-verify] [-check-gcd <m>] [-save <path>] [-compare-with <path>] [-no-validate] [-continue-on-discrepancy] [-sequential [-gc-between]] [-concurrency <k>] [-gomaxprocs <k>] [-only-fastest] [-repeat <k>] [-warmup <n>] [-count-ops] [-bar-width <cells>] [-max-memory <size>] [-max-n <n>] [-progress auto|always|log|never] [-progress-fd <n>] [-range <a:b>] [-stdin] [-batch <n1,n2,...>] [-n-list <n1,n2,...>] [-selftest] [-compare-algorithms] [-serve <addr> [-cache-size <k>]] [-connect <addr>] [-benchmark <start:end:multiplier>] [-benchmark-runs <k>] [-cpuprofile <path>] [-memprofile <path>] [-trace <path>]
 // Example:
 //   go run . -n 100000 -timeout 1m
+//   go run . -n 100000000 -timeout 10s -retries 3 -retry-factor 4
+//   go run . -n 100000 -algorithms fast,lucas
+//   go run . -n 20000 -algorithms fast,memo
+//   go run . -n 30 -algorithms auto
+//   go run . -n 1000 -algorithms tribonacci
+//   go run . -n 10000000 -timeout 10m -timeout-per-digit 10us
+//   go run . -n 1000000000 -mod 1000000007
+//   go run . -n 100000000000000000000 -mod 1000000007
+//   go run . -n 1000 -sum
+//   go run . -n 1000 -recurrence 2,1
+//   go run . -n 1000 -format json
+//   go run . -n 1000 -quiet
+//   go run . -n 100 -format value
+//   go run . -n 10000000 -algorithms fast,lucas -cpuprofile cpu.out -trace trace.out
+//   go run . -n -100
+//   go run . -n 10000000 -output fib.txt
+//   go run . -n 10000000 -base 16 -output fib.hex
+//   go run . -n 10000000 -format raw -output fib.bin
+//   go run . -n 1000000 -save f1m.gob
+//   go run . -n 1000000 -compare-with f1m.gob
+//   go run . -n 100000000 -digits-only
+//   go run . -n 10000000 -sci-digits 0
+//   go run . -n 1000000000000 -estimate-digits
+//   go run . -n 2000000000 -max-memory 32GiB
+//   go run . -n 1000000000000 -head 50 -tail 50
+//   go run . -is-fib 354224848179261915075
+//   go run . -zeckendorf 100
+//   go run . -index-of 1000000
+//   go run . -n 100 -ratio
+//   go run . -word 40 -output word.txt
+//   go run . -n 100 -crt 1000000007,998244353,1000000009 -crt-reconstruct
+//   go run . -range 0:1000 -output table.txt
+//   go run . -stdin -format csv < indices.txt
+//   go run . -batch 1000000,2000000,4000000 -output terms.txt
+//   go run . -n-list 100,1000,50000 -concurrency 2
+//   go run . -n 10000000 -algorithms fast,matrix -sequential -repeat 5 -gomaxprocs 1
+//   go run . -n 10000000 -format json -progress-fd 3 3>progress.jsonl
+//   go run . -selftest
+//   go run . -compare-algorithms
+//   go run . -benchmark 1000:1000000:10x -algorithms all -output bench.csv
 
 package main
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"flag"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
+	"math"
 	"math/big"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"slices"
+	"sort"
+	"strings"
 	"sync"
+	"text/tabwriter"
 	"time"
+
+	"github.com/agbruneau/FibJule/fib"
 )
 
 // ------------------------------------------------------------
@@ -30,168 +92,1215 @@ import (
 
 // task represents a Fibonacci calculation task to be executed.
 type task struct {
-	name string  // Name of the algorithm
-	fn   fibFunc // Algorithm function
+	name   string  // Name of the algorithm
+	symbol string  // Notation of the computed sequence, e.g. "F" or "L"
+	fn     fibFunc // Algorithm function
 }
 
-// result stores the outcome of a calculation task.
-type result struct {
-	name     string        // Name of the algorithm
-	value    *big.Int      // Calculated Fibonacci value
-	duration time.Duration // Duration of the calculation
-	err      error         // Potential error
+// Result stores the outcome of a calculation task, as returned by Compute.
+// The unexported fields only serve the CLI's presentation.
+type Result struct {
+	Name     string        // Name of the algorithm
+	Value    *big.Int      // Calculated value
+	Duration time.Duration // Duration of the calculation
+	Err      error         // Potential error
+
+	symbol      string // Notation of the computed sequence, e.g. "F" or "L"
+	peakMem     uint64 // Peak heap growth during the calculation, in bytes
+	cached      bool   // Value loaded from the on-disk cache instead of computed
+	taskTimeout bool   // Exceeded its own `-per-algo-timeout` rather than the global timeout
+	outrun      bool   // Cancelled by `-only-fastest` once another task succeeded
+
+	repeats     int           // Number of executions, more than 1 with `-repeat`
+	minDuration time.Duration // Fastest execution; duration then holds the median
+	maxDuration time.Duration // Slowest execution
+	deadline    time.Duration // Time the last execution had before its deadline, negative if it started past it, 0 without one
+
+	ops *fib.OpCounts // Operations of the last execution with `-count-ops`, nil otherwise
+
+	text      string        // Value in the display base, converted once for the table and the details, "" otherwise
+	stringify time.Duration // Time the conversion to text took, 0 if the value was not converted
 }
 
 // ------------------------------------------------------------
 // Main Function: The Orchestrator
 // ------------------------------------------------------------
 //
-// The `main` function orchestrates the entire process:
-// 1. It reads command-line parameters (`-n`, `-timeout`).
-// 2. It defines the task to execute (Fast Doubling).
+// The `main` function, through `run`, orchestrates the entire process:
+//  1. It reads command-line parameters (`-n`, `-timeout`, `-timeout-per-digit`, `-per-algo-timeout`, `-retries`, `-retry-factor`, `-algorithms`, `-mod`,
+//     `-format`, `-quiet`, `-output`, `-base`, `-sci-digits`, `-digits-only`, `-estimate-digits`, `-ratio`, `-word`, `-recurrence`,
+//     `-parallel-mul`, `-precision-bits`, `-cache`, `-cache-size`, `-verify`, `-check-gcd`, `-save`, `-compare-with`, `-no-validate`, `-continue-on-discrepancy`, `-sequential`, `-gc-between`, `-concurrency`, `-gomaxprocs`, `-only-fastest`, `-repeat`, `-count-ops`, `-bar-width`,
+//     `-max-memory`, `-max-n`, `-progress`, `-progress-fd`, `-range`, `-stdin`, `-batch`, `-n-list`, `-selftest`, `-compare-algorithms`, `-benchmark`, `-benchmark-runs`, `-cpuprofile`,
+//     `-memprofile`, `-trace`), and starts the requested profiles (see `startProfiling`).
+//     It refuses a calculation whose estimated memory exceeds `-max-memory`
+//     (see `checkMemoryBudget`), or whose index exceeds `-max-n`, which
+//     defaults to the FIBJULE_MAX_N environment variable (see `checkMaxN`).
+//     An index beyond the range of int, accepted with `-mod` only, is
+//     computed on its own by `runBigIndexMode`.
+//     With `-estimate-digits`, `-range`, `-stdin`, `-batch`, `-n-list` or
+//     `-benchmark`, it prints the estimate, runs `writeRange`, the batch of
+//     `runBatch`, that of `runBatchListMode`, the separate indices of
+//     `runNListMode` or the sweep of `runBenchmark` instead and stops.
+//  2. It selects the tasks to execute from the algorithm registry (see
+//     `Register`), or lets `-algorithms auto` pick one for n (see `autoAlgorithm`).
 //  3. It creates a `context` with a global timeout to ensure the program
-//     doesn't run indefinitely. This context is passed to the calculation goroutine
-//     to allow for cooperative cancellation.
-//  4. It launches the `progressPrinter` goroutine for real-time display.
+//     doesn't run indefinitely. This context is passed to the calculation goroutines
+//     to allow for cooperative cancellation. SIGINT and SIGTERM cancel it too.
+//  4. It launches the `progressPrinter` goroutine for real-time display
+//     (table format only), or `progressLogger` with `-progress log` or when stdout is not a terminal,
+//     or `progressEvents` with `-progress-fd`.
 //  5. It launches a goroutine for each calculation task. Using goroutines
-//     allows all selected algorithms to run concurrently.
+//     allows all selected algorithms to run concurrently. With `-sequential`,
+//     a single goroutine runs them one after the other instead.
 //  6. It waits for all tasks to complete using a `sync.WaitGroup`.
 //  7. It closes communication channels to signal recipient goroutines
 //     (like `progressPrinter`) that there will be no more data.
-//  8. Finally, it calls `collectAndDisplayResults` to analyze and present the results.
+//  8. Finally, it calls `collectAndDisplayResults` to analyze and present the results,
+//     or `writeJSONReport` in JSON format, verifies them against an independent
+//     reference with `-verify` or the identity gcd(F(m), F(n)) = F(gcd(m, n))
+//     with `-check-gcd`, saves the winning one with `-save` or compares it
+//     with `-compare-with`, and stores them in the cache if enabled.
+//  9. It exits with a status reflecting the outcome (see exitStatus).
 func main() {
+	os.Exit(run())
+}
+
+// run is the body of main. It returns the process exit code instead of
+// exiting, so that its deferred calls run before main exits.
+func run() int {
 	// 1. Read command-line parameters
-	nFlag := flag.Int("n", 100000, "Index n of the Fibonacci term (non-negative integer)")
-	timeoutFlag := flag.Duration("timeout", 1*time.Minute, "Global maximum execution time")
-	flag.Parse()
+	cfg, err := parseConfig(os.Args[1:], os.Stderr)
+	if err == flag.ErrHelp {
+		return exitOK
+	}
+	if err != nil {
+		fatal("invalid arguments", "error", err)
+	}
+	slog.SetDefault(newLogger(os.Stderr, cfg.logFormat, logLevel(cfg)))
+
+	profiler, err := startProfiling(cfg)
+	if err != nil {
+		fatal("failed to start profiling", "error", err)
+	}
+	defer profiler.Stop()
+
+	defer setGOMAXPROCS(cfg.gomaxprocs)()
+	slog.Info("parallelism", "gomaxprocs", runtime.GOMAXPROCS(0), "cpus", runtime.NumCPU())
+
+	if cfg.perDigit > 0 {
+		slog.Info("timeout derived from the estimated digits", "n", cfg.n, "digits", fib.EstimateDigits(cfg.n), "per_digit", cfg.perDigit, "timeout", cfg.timeout)
+	}
+
+	n := cfg.n
+	timeout := cfg.timeout
+	// The self-test ignores the other options but the timeout.
+	if cfg.selftest {
+		return runSelfTestMode(cfg)
+	}
+	// So does the comparison of the algorithms over a sweep of indices.
+	if cfg.compareAlgorithms {
+		return runCompareMode(cfg)
+	}
 
-	n := *nFlag
-	timeout := *timeoutFlag
+	// An index beyond the range of int is only computed modulo m.
+	if cfg.bigN != nil {
+		runBigIndexMode(cfg)
+		return exitOK
+	}
 
-	if n < 0 {
-		log.Fatalf("Index n must be greater than or equal to 0. Received: %d", n)
+	// The analytic estimate needs no calculation at all.
+	if cfg.estimateDigits {
+		fmt.Printf("Number of digits in F(%d) (estimated): %d\n", cfg.n, fib.EstimateDigits(cfg.n))
+		return exitOK
 	}
 
-	// 2. Define the task to run
-	taskToRun := task{
-		name: "Fast Doubling",
-		fn:   fibFastDoubling,
+	// The first and last digits are derived without computing F(n) in full.
+	if cfg.head > 0 || cfg.tail > 0 {
+		runDigitsMode(cfg)
+		return exitOK
 	}
-	selectedTaskNames := []string{taskToRun.name} // For progress printer
 
-	log.Printf("Calculating F(%d) using %s with a timeout of %v...", n, taskToRun.name, timeout)
+	// The membership test inspects the given integer instead of computing F(n).
+	if cfg.isFib != "" {
+		runIsFibMode(cfg)
+		return exitOK
+	}
 
-	// 3. Create context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	// The Zeckendorf representation decomposes the given integer.
+	if cfg.zeckendorf != "" {
+		runZeckendorfMode(cfg)
+		return exitOK
+	}
+
+	// The nearest term of the given integer is found by inverting Binet.
+	if cfg.indexOf != "" {
+		runIndexOfMode(cfg)
+		return exitOK
+	}
+
+	// The listed indices are computed together, sharing their doubling steps.
+	if cfg.batch != "" {
+		runBatchListMode(cfg)
+		return exitOK
+	}
+
+	// The listed indices are computed as separate tasks, concurrently.
+	if cfg.nList != "" {
+		return runNListMode(cfg)
+	}
+
+	// The Fibonacci word is streamed rather than computed as a number.
+	if cfg.word >= 0 {
+		runWordMode(cfg)
+		return exitOK
+	}
+
+	// The ratio of consecutive terms is compared with the golden ratio.
+	if cfg.ratio {
+		runRatioMode(cfg)
+		return exitOK
+	}
+
+	// CRT mode computes residues of F(n) modulo small primes.
+	if cfg.crt != "" {
+		runCRTMode(cfg)
+		return exitOK
+	}
+
+	// Range mode writes a sequence of terms instead of comparing algorithms.
+	if cfg.rangeSpec != "" {
+		runRangeMode(cfg)
+		return exitOK
+	}
+
+	// Server mode answers remote requests; client mode delegates the
+	// calculation to a server and only displays its results.
+	if cfg.serve != "" {
+		runServeMode(cfg)
+		return exitOK
+	}
+	if cfg.connect != "" {
+		return runConnectMode(cfg)
+	}
+
+	// Batch mode computes the indices read from stdin instead of -n.
+	if cfg.stdin {
+		return runStdinMode(cfg)
+	}
+
+	// 2. Define the available tasks and select the ones to run
+	m, opts := algorithmOptions(cfg)
+	tasksToRun, err := resolveTasks(cfg.algorithms, n, m, opts...)
+	if err != nil {
+		fatal("invalid arguments", "error", err)
+	}
+	if t, ok := quantityTask(cfg, m, opts...); ok {
+		tasksToRun = []task{t}
+	}
+	if binetOutOfRange(n, tasksToRun) {
+		slog.Warn("⚠️ Binet is impractical for this n: prefer 'fast' or 'matrix-fast'", "n", n, "threshold", fib.BinetThreshold(),
+			"reason", "past 2^20 bits of big.Float precision, its division and square root make it 30 to 60 times slower than an integer algorithm")
+	}
+	logBinetPrecision(cfg, n, tasksToRun)
+	selectedTaskNames := make([]string, len(tasksToRun)) // For progress printer
+	for i, t := range tasksToRun {
+		selectedTaskNames[i] = t.name
+	}
+
+	// Benchmark mode replaces the single calculation with a sweep over n.
+	if cfg.benchmark != "" {
+		runBenchmarkMode(cfg, tasksToRun)
+		return exitOK
+	}
+
+	slog.Info("calculating", "n", n, "mod", cfg.mod, "algorithms", strings.Join(selectedTaskNames, ","), "timeout", timeout)
+
+	// A cache hit for every selected sequence skips the calculations entirely.
+	if cfg.cacheDir != "" {
+		if cached, ok := loadCachedResults(cfg, tasksToRun); ok {
+			slog.Info("results found in cache, skipping calculations", "cache", cfg.cacheDir)
+			results := reportResults(context.Background(), cached, cfg)
+			if cfg.verify {
+				if err := verifyResults(cfg, results); err != nil {
+					fatal("verification failed", "error", err)
+				}
+			}
+			if cfg.checkGCD != 0 {
+				if err := checkGCDResults(gcdReportWriter(cfg), cfg, results); err != nil {
+					fatal("GCD check failed", "error", err)
+				}
+			}
+			if err := saveAndCompare(cfg, results); err != nil {
+				slog.Error("comparison with the saved result failed", "path", cfg.compareWith, "error", err)
+				return exitMismatch
+			}
+			slog.Info("program finished")
+			return exitStatus(results, failOnDiscrepancy(cfg))
+		}
+	}
+
+	// 3. Create the context of the run. Each attempt derives its own from it,
+	// with the global timeout (see computeWithRetries).
+	runCtx, cancel := context.WithCancel(context.Background())
 	defer cancel() // Important to release resources associated with the context
 
-	intPool := newIntPool()
+	// Ctrl-C (or SIGTERM) cancels the context instead of killing the process,
+	// so a summary of whatever completed is still displayed.
+	stopSignals := handleSignals(cancel)
+	defer stopSignals()
 
-	// Channels for communication between goroutines
-	progressAggregatorCh := make(chan progressData, 2) // Buffer for progress data
-	resultsCh := make(chan result, 1)                  // Buffer for the single result
+	// 4. Launch progress display. In JSON mode or with `-progress never`, no
+	// progress is reported at all: the context of the tasks carries no
+	// ProgressReporter and the printer is not started.
+	calcCtx, stopProgress := startProgressDisplay(runCtx, cfg, selectedTaskNames)
+
+	// 5. Launch calculations, concurrently or one after the other
+	if cfg.warmup != 0 {
+		slog.Info("warming up", "n", cfg.warmup)
+	}
+	slog.Info("launching calculations", "count", len(tasksToRun), "sequential", cfg.sequential)
+
+	// 6. Wait for the calculations to finish, retrying with a larger timeout
+	// if it expires first and `-retries` allows it
+	results, ctx := computeWithRetries(calcCtx, tasksToRun, n, runOptions{
+		sequential:  cfg.sequential,
+		taskTimeout: cfg.perAlgoTimeout,
+		repeat:      cfg.repeat,
+		warmup:      cfg.warmup,
+		gcBetween:   cfg.gcBetween,
+		countOps:    cfg.countOps,
+		concurrency: concurrencyLimit(cfg.concurrency),
+		onlyFastest: cfg.onlyFastest,
+		sizedPools:  cfg.mod == 0,
+	}, timeout, cfg.retries, cfg.retryFactor)
+	slog.Info("calculations finished")
+
+	// 7. Signal the end of transmissions and wait for the display to finish
+	stopProgress()
+
+	// 8. Collect and display results, verify them if requested, then fill the cache on a miss
+	results = reportResults(ctx, results, cfg)
+	if cfg.verify {
+		if err := verifyResults(cfg, results); err != nil {
+			fatal("verification failed", "error", err)
+		}
+	}
+	if cfg.checkGCD != 0 {
+		if err := checkGCDResults(gcdReportWriter(cfg), cfg, results); err != nil {
+			fatal("GCD check failed", "error", err)
+		}
+	}
+	if err := saveAndCompare(cfg, results); err != nil {
+		slog.Error("comparison with the saved result failed", "path", cfg.compareWith, "error", err)
+		return exitMismatch
+	}
+	if cfg.cacheDir != "" {
+		storeResultsInCache(cfg, results)
+	}
+
+	slog.Info("program finished")
+	return exitStatus(results, failOnDiscrepancy(cfg))
+}
+
+// startProgressDisplay launches the progress display of the named tasks, if
+// cfg shows one, and returns ctx carrying a ProgressReporter that feeds it,
+// with a function that stops the display and waits for it once the tasks
+// have finished. Without a display, ctx is returned unchanged.
+//
+// Progress is shown in table format unless disabled, which `-quiet` also
+// does. It is animated on a terminal, or when forced with `-progress always`;
+// otherwise it is logged as periodic lines so that redirected output stays
+// clean. With `-progress-fd`, the progress goes to that file descriptor as
+// JSON events instead, in every format.
+func startProgressDisplay(ctx context.Context, cfg config, names []string) (context.Context, func()) {
+	showProgress := (cfg.format == formatTable && cfg.progress != progressNever && !cfg.quiet) || cfg.progressFD > 0
+	if !showProgress {
+		return ctx, func() {}
+	}
+	animateProgress := cfg.progress == progressAlways || (cfg.progress == progressAuto && isTerminal(os.Stdout))
 
-	// 4. Launch progress display
-	var wgDisplay sync.WaitGroup
-	wgDisplay.Add(1)
+	// Channel for progress data between goroutines
+	progressCh := make(chan progressData, 2*len(names))
+	done := make(chan struct{})
 	go func() {
-		defer wgDisplay.Done()
-		progressPrinter(ctx, progressAggregatorCh, selectedTaskNames)
+		defer close(done)
+		if cfg.progressFD > 0 {
+			progressEvents(ctx, progressCh, os.NewFile(uintptr(cfg.progressFD), "progress-fd"))
+		} else if animateProgress {
+			progressPrinter(ctx, progressCh, names, cfg.barWidth)
+		} else {
+			progressLogger(ctx, progressCh, names, os.Stderr, progressLogInterval)
+		}
 	}()
+	return WithProgressReporter(ctx, channelReporter(progressCh)), func() {
+		close(progressCh)
+		<-done
+	}
+}
 
-	// 5. Launch calculation
-	var wg sync.WaitGroup
-	wg.Add(1)
-	log.Println("Launching calculation...")
-	go func(currentTask task) {
-		defer wg.Done()
+// runBenchmarkMode runs the `-benchmark` sweep over the selected tasks and
+// writes the CSV to stdout, or to the `-output` file when set. The global
+// timeout applies to each data point rather than to the whole sweep.
+func runBenchmarkMode(cfg config, tasks []task) {
+	ns, err := parseSweep(cfg.benchmark) // Already validated by parseConfig
+	if err != nil {
+		fatal("invalid arguments", "error", err)
+	}
+
+	w := io.Writer(os.Stdout)
+	if cfg.output != "" {
+		f, err := os.Create(cfg.output)
+		if err != nil {
+			fatal("failed to create output file", "path", cfg.output, "error", err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stopSignals := handleSignals(cancel)
+	defer stopSignals()
+
+	slog.Info("benchmarking", "points", len(ns), "from", ns[0], "to", ns[len(ns)-1], "runs", cfg.benchmarkRuns, "timeout", cfg.timeout)
+	if err := runBenchmark(ctx, w, tasks, ns, cfg.benchmarkRuns, cfg.timeout); err != nil {
+		slog.Error("benchmark interrupted", "error", err)
+		return
+	}
+	slog.Info("benchmark finished")
+}
+
+// runDigitsMode runs the `-head` and `-tail` modes: it prints the first and
+// last digits of |F(n)| requested, within the global timeout.
+func runDigitsMode(cfg config) {
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.timeout)
+	defer cancel()
+	stopSignals := handleSignals(cancel)
+	defer stopSignals()
+
+	if cfg.head > 0 {
+		head, err := fib.LeadingDigits(ctx, cfg.n, cfg.head)
+		if err != nil {
+			fatal("failed to compute the leading digits", "n", cfg.n, "error", err)
+		}
+		fmt.Printf("First %d digits of F(%d): %s\n", len(head), cfg.n, head)
+	}
+	if cfg.tail > 0 {
+		tail, err := fib.TrailingDigits(ctx, cfg.n, cfg.tail)
+		if err != nil {
+			fatal("failed to compute the trailing digits", "n", cfg.n, "error", err)
+		}
+		fmt.Printf("Last %d digits of F(%d): %s\n", len(tail), cfg.n, tail)
+	}
+}
+
+// runIsFibMode runs the `-is-fib` mode: it prints whether the integer is a
+// Fibonacci number and, if so, its index.
+func runIsFibMode(cfg config) {
+	x, _ := new(big.Int).SetString(cfg.isFib, 10) // Validated by parseConfig
+	if !fib.IsFibonacci(x) {
+		fmt.Printf("IsFibonacci(%s): false\n", x)
+		return
+	}
+	n, _ := fib.Index(x)
+	fmt.Printf("IsFibonacci(%s): true, F(%d) = %s\n", x, n, x)
+}
+
+// runOptions gathers the flags controlling how runTasks executes the tasks.
+type runOptions struct {
+	sequential  bool          // Run the tasks one after the other instead of concurrently
+	taskTimeout time.Duration // Deadline of each task taken separately, 0 when disabled
+	repeat      int           // Number of executions of each task, at least 1
+	warmup      int           // Index computed once per task before any timing, 0 when disabled
+	gcBetween   bool          // Collect garbage before each task of a sequential run
+	countOps    bool          // Count the big-integer operations of each task
+	concurrency int           // Maximum number of tasks running at once, 0 for no limit
+	onlyFastest bool          // Cancel the other tasks once one succeeds (see computeTasks)
+	pools       []*sync.Pool  // Pool of each task, kept warm across calls; nil for fresh pools
+	sizedPools  bool          // Size the fresh pools for F(n) (see newSizedIntPool)
+	indices     []int         // Index of each task, replacing n, with `-n-list`; nil otherwise
+	silent      bool          // Skip the completion log of each task, for sweeps over many indices
+}
+
+// runZeckendorfMode runs the `-zeckendorf` mode: it prints the integer as a
+// sum of non-consecutive Fibonacci numbers, given by their indices.
+func runZeckendorfMode(cfg config) {
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.timeout)
+	defer cancel()
+	stopSignals := handleSignals(cancel)
+	defer stopSignals()
+
+	x, _ := new(big.Int).SetString(cfg.zeckendorf, 10) // Validated by parseConfig
+	indices, err := fib.Zeckendorf(ctx, x)
+	if err != nil {
+		fatal("failed to compute the Zeckendorf representation", "x", x, "error", err)
+	}
+	fmt.Printf("Zeckendorf(%s) = %s\n", x, formatZeckendorf(indices))
+}
+
+// runIndexOfMode runs the `-index-of` mode: it prints the index of the
+// Fibonacci number closest to the integer, and their difference.
+func runIndexOfMode(cfg config) {
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.timeout)
+	defer cancel()
+	stopSignals := handleSignals(cancel)
+	defer stopSignals()
+
+	x, _ := new(big.Int).SetString(cfg.indexOf, 10) // Validated by parseConfig
+	n, delta, err := fib.NearestIndex(ctx, x)
+	if err != nil {
+		fatal("failed to find the nearest Fibonacci number", "x", x, "error", err)
+	}
+	if delta.Sign() == 0 {
+		fmt.Printf("IndexOf(%s): F(%d) = %s (exact)\n", x, n, x)
+		return
+	}
+	term := new(big.Int).Sub(x, delta)
+	fmt.Printf("IndexOf(%s): nearest F(%d) = %s, X - F(%d) = %s\n", x, n, term, n, delta)
+}
+
+// formatZeckendorf renders a Zeckendorf representation as "F(11) + F(6) + F(4)",
+// or "0" for the empty sum.
+func formatZeckendorf(indices []int) string {
+	if len(indices) == 0 {
+		return "0"
+	}
+	terms := make([]string, len(indices))
+	for i, k := range indices {
+		terms[i] = fmt.Sprintf("F(%d)", k)
+	}
+	return strings.Join(terms, " + ")
+}
+
+// concurrencyLimit resolves the `-concurrency` value: 0 stands for GOMAXPROCS
+// of the machine running the tasks, which is the server's in client mode.
+func concurrencyLimit(k int) int {
+	if k == 0 {
+		return runtime.GOMAXPROCS(0)
+	}
+	return k
+}
+
+// setGOMAXPROCS applies `-gomaxprocs`: k > 0 sets GOMAXPROCS to k, 0 keeps
+// it. The returned function restores the previous value.
+func setGOMAXPROCS(k int) (restore func()) {
+	prev := runtime.GOMAXPROCS(0)
+	if k > 0 {
+		runtime.GOMAXPROCS(k)
+	}
+	return func() { runtime.GOMAXPROCS(prev) }
+}
+
+// runTask executes a single task with the given pool and measures its
+// duration and peak memory. A nil pool is replaced by a fresh one.
+//
+// Each task gets its own `sync.Pool`: with a shared pool, an algorithm
+// churning through many objects could take the ones another algorithm just
+// returned, skewing its timings and defeating the locality benefits of the
+// pool.
+//
+// A positive timeout gives the task its own deadline, derived from ctx, so it
+// can time out without affecting the others. With opts.repeat > 1, the task
+// runs that many times: the result reports the median, minimum and maximum
+// durations, and the value of the last run. With opts.countOps, the
+// algorithm counts its operations (see withOpCounts), for the last run too.
+func runTask(ctx context.Context, t task, n int, pool *sync.Pool, opts runOptions) Result {
+	taskCtx := ctx
+	if opts.taskTimeout > 0 {
+		var cancel context.CancelFunc
+		taskCtx, cancel = context.WithTimeout(ctx, opts.taskTimeout)
+		defer cancel()
+	}
+	repeat := max(opts.repeat, 1)
+	var ops *fib.OpCounts
+	if opts.countOps {
+		ops = new(fib.OpCounts)
+		taskCtx = withOpCounts(taskCtx, ops)
+	}
+
+	if pool == nil {
+		pool = newIntPool()
+	}
+	tracker := startMemoryTracker()
+	var v *big.Int
+	var err error
+	var deadline time.Duration
+	durations := make([]time.Duration, 0, repeat)
+	reporter, reporting := progressReporter(taskCtx)
+	for i := 0; i < repeat && err == nil; i++ {
+		repCtx := taskCtx
+		if reporting && repeat > 1 {
+			repCtx = WithProgressReporter(taskCtx, repetitionReporter{next: reporter, rep: i, reps: repeat})
+		}
+		if ops != nil {
+			*ops = fib.OpCounts{}
+		}
 		start := time.Now()
-		v, err := currentTask.fn(ctx, progressAggregatorCh, n, intPool)
-		duration := time.Since(start)
-		resultsCh <- result{currentTask.name, v, duration, err}
-	}(taskToRun)
+		if d, ok := repCtx.Deadline(); ok {
+			deadline = d.Sub(start)
+		}
+		v, err = t.fn(repCtx, n, pool)
+		durations = append(durations, time.Since(start))
+	}
+	peakMem := tracker.Stop()
 
-	// 6. Wait for the calculation to finish
+	r := Result{
+		Name: t.name, symbol: t.symbol, Value: v, Err: err, peakMem: peakMem,
+		// Only the task's own deadline expired if the parent is still alive.
+		taskTimeout: errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil,
+		repeats:     len(durations),
+		deadline:    deadline,
+		ops:         ops,
+	}
+	if err != nil {
+		r.Duration = durations[len(durations)-1] // Time spent in the failed run
+		return r
+	}
+	slices.Sort(durations)
+	r.Duration = median(durations)
+	r.minDuration, r.maxDuration = durations[0], durations[len(durations)-1]
+	return r
+}
+
+// median returns the median of sorted, a non-empty sorted slice.
+func median(sorted []time.Duration) time.Duration {
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// runTasks executes the tasks and sends their results to resultsCh, which
+// must have room for one result per task. By default each task runs in its
+// own goroutine; with opts.sequential, each one runs to completion before the
+// next starts, so they do not compete for CPU and memory. A positive
+// opts.concurrency bounds how many tasks run at once: the goroutines of the
+// others wait for a free slot of a semaphore channel before starting, and
+// their progress stays at 0% meanwhile. A positive opts.taskTimeout bounds each task separately (see runTask). With a non-zero
+// opts.warmup, every task first computes that index once (see warmUp) before
+// any of them is timed. With opts.gcBetween, a sequential run collects
+// garbage and returns freed memory to the OS before each task, so that none
+// starts with the leftover heap of the previous one. The tasks use
+// opts.pools when set, one per task, so that a caller running them for many
+// indices keeps the pools warm; otherwise each gets a fresh pool, sized for
+// F(n) with opts.sizedPools. With opts.indices, each task computes its own
+// index instead of n. runTasks returns once every task has finished.
+func runTasks(ctx context.Context, tasks []task, n int, resultsCh chan<- Result, opts runOptions) {
+	indices := opts.indices
+	if indices == nil {
+		indices = make([]int, len(tasks))
+		for i := range indices {
+			indices[i] = n
+		}
+	}
+	pools := opts.pools
+	if pools == nil {
+		pools = make([]*sync.Pool, len(tasks))
+		for i := range pools {
+			if opts.sizedPools {
+				pools[i] = newSizedIntPool(indices[i])
+			} else {
+				pools[i] = newIntPool()
+			}
+		}
+	}
+	if opts.warmup != 0 {
+		warmUp(ctx, tasks, pools, opts.warmup)
+	}
+
+	var wg sync.WaitGroup
+	if opts.sequential {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i, t := range tasks {
+				if opts.gcBetween {
+					runtime.GC()
+					debug.FreeOSMemory()
+				}
+				r := runTask(ctx, t, indices[i], pools[i], opts)
+				if !opts.silent {
+					logCompletion(r, indices[i])
+				}
+				resultsCh <- r
+			}
+		}()
+	} else {
+		var slots chan struct{}
+		if opts.concurrency > 0 {
+			slots = make(chan struct{}, opts.concurrency)
+		}
+		for i, t := range tasks {
+			wg.Add(1)
+			go func(currentTask task, index int, pool *sync.Pool) {
+				defer wg.Done()
+				if slots != nil {
+					slots <- struct{}{}
+					defer func() { <-slots }()
+				}
+				r := runTask(ctx, currentTask, index, pool, opts)
+				if !opts.silent {
+					logCompletion(r, index)
+				}
+				resultsCh <- r
+			}(t, indices[i], pools[i])
+		}
+	}
 	wg.Wait()
-	log.Println("Calculation finished.")
+}
+
+// warmUp computes F(warmup) once per task, one task after the other, with
+// the pool its timed run will use. The pools then already hold objects and
+// the heap has grown, so the first task timed no longer pays for it. The
+// values, durations and errors of this phase are discarded: a warmup failure
+// never affects the results or their cross-validation, nor the progress
+// display, whose reporter is removed from the context.
+func warmUp(ctx context.Context, tasks []task, pools []*sync.Pool, warmup int) {
+	quiet := WithProgressReporter(ctx, nil)
+	for i, t := range tasks {
+		if ctx.Err() != nil {
+			return
+		}
+		_, _ = t.fn(quiet, warmup, pools[i])
+	}
+}
+
+// selectTasks resolves the comma-separated `-algorithms` value into tasks.
+// The special name "all" expands to every task in defaultOrder. Duplicates are
+// ignored and unknown names are reported as an error, as is "auto" within a
+// list: on its own, it is resolved by resolveTasks.
+func selectTasks(spec string, available map[string]task, defaultOrder []string) ([]task, error) {
+	var names []string
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "all" {
+			names = append(names, defaultOrder...)
+		} else if name == "auto" {
+			return nil, fmt.Errorf("\"auto\" selects a single algorithm and cannot be combined with others")
+		} else if name != "" {
+			names = append(names, name)
+		}
+	}
+
+	var selected []task
+	seen := make(map[string]bool)
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+		t, ok := available[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown algorithm %q (available: %s, all, auto)", name, strings.Join(defaultOrder, ", "))
+		}
+		seen[name] = true
+		selected = append(selected, t)
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no algorithm selected")
+	}
+	return selected, nil
+}
+
+// reportResults presents the results in the configured format and returns
+// them, sorted as by sortResults.
+func reportResults(ctx context.Context, results []Result, cfg config) []Result {
+	if cfg.format == formatTable {
+		return collectAndDisplayResults(ctx, results, cfg)
+	}
+	results = sortResults(results)
+	if cfg.format == formatProtobuf && cfg.output != "" {
+		// The message already holds the values: -output receives it instead.
+		if err := writeProtobufFile(cfg.output, cfg, results); err != nil {
+			fatal("failed to write report", "format", cfg.format, "path", cfg.output, "error", err)
+		}
+		return results
+	}
+	write := writeJSONReport
+	switch cfg.format {
+	case formatCSV:
+		write = writeCSVReport
+	case formatProtobuf:
+		write = writeProtobufReport
+	case formatValue:
+		write = writeValueReport
+	}
+	// The raw format only goes to -output, below.
+	if cfg.format != formatRaw {
+		if err := write(os.Stdout, cfg, results); err != nil {
+			fatal("failed to write report", "format", cfg.format, "error", err)
+		}
+	}
+	// stdout holds the report: the discrepancy goes to stderr, with the logs.
+	if compared, ok := crossValidate(results); !cfg.noValidate && compared && !ok {
+		slog.Error("discrepancy: algorithms computing the same sequence produced different results")
+		writeDiscrepancies(os.Stderr, results)
+	}
+	if cfg.output != "" && len(results) > 0 && results[0].Err == nil {
+		if cfg.format == formatRaw {
+			saveRawToFile(cfg.output, results[0].Value)
+		} else {
+			saveResultToFile(cfg.output, results[0].Value, cfg.base)
+		}
+	}
+	return results
+}
 
-	// 7. Close channels to signal end of transmissions
-	close(progressAggregatorCh)
-	close(resultsCh)
+// sortResults sorts results in place and returns them: successful ones
+// first, ordered by duration, then failed ones in the same order.
+func sortResults(results []Result) []Result {
+	sort.SliceStable(results, func(i, j int) bool {
+		if (results[i].Err == nil) != (results[j].Err == nil) {
+			return results[i].Err == nil
+		}
+		return results[i].Duration < results[j].Duration
+	})
+	return results
+}
 
-	// Wait for the display goroutine to finish
-	wgDisplay.Wait()
+// crossValidate checks that all successful results computing the same
+// sequence hold the same value. compared reports whether at least two results
+// could be compared at all; ok is false on any discrepancy.
+func crossValidate(results []Result) (compared, ok bool) {
+	ok = true
+	reference := make(map[string]*big.Int)
+	for _, r := range results {
+		if r.Err != nil || r.Value == nil {
+			continue
+		}
+		ref, seen := reference[r.symbol]
+		if !seen {
+			reference[r.symbol] = r.Value
+			continue
+		}
+		compared = true
+		if ref.Cmp(r.Value) != 0 {
+			ok = false
+		}
+	}
+	return compared, ok
+}
 
-	// 8. Collect and display results
-	collectAndDisplayResults(ctx, resultsCh, n)
+// writeDiscrepancies writes, for each sequence whose successful results
+// differ, the algorithms that computed it and the fingerprint of each value,
+// to tell the faulty one apart: the algorithms sharing a fingerprint agree.
+func writeDiscrepancies(w io.Writer, results []Result) error {
+	var symbols []string
+	bySymbol := make(map[string][]Result)
+	for _, r := range results {
+		if r.Err != nil || r.Value == nil {
+			continue
+		}
+		if _, seen := bySymbol[r.symbol]; !seen {
+			symbols = append(symbols, r.symbol)
+		}
+		bySymbol[r.symbol] = append(bySymbol[r.symbol], r)
+	}
 
-	log.Println("Program finished.")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, symbol := range symbols {
+		group := bySymbol[symbol]
+		if !slices.ContainsFunc(group, func(r Result) bool { return r.Value.Cmp(group[0].Value) != 0 }) {
+			continue
+		}
+		fmt.Fprintf(tw, "Results of %s(n) by algorithm:\n", symbol)
+		for _, r := range group {
+			residue, hash := fingerprint(r.Value)
+			fmt.Fprintf(tw, "  %s\tmod 1e9+7 = %d\tsha256 = %s\n", r.Name, residue, hash)
+		}
+	}
+	return tw.Flush()
+}
+
+// Exit codes of the program, for use in scripts. Invalid arguments and
+// verification failures exit with 1 through fatal.
+const (
+	exitOK          = 0 // At least one success, and all successful results agree
+	exitDiscrepancy = 2 // Results of the same sequence differ, or `-selftest` or `-compare-algorithms` failed
+	exitAllFailed   = 3 // Every algorithm failed, timed out or was cancelled
+	exitMismatch    = 4 // The result differs from the `-compare-with` file
+)
+
+// failOnDiscrepancy reports whether a discrepancy changes the exit status:
+// not with `-no-validate`, where none is looked for, nor with
+// `-continue-on-discrepancy`, where it is only reported.
+func failOnDiscrepancy(cfg config) bool {
+	return !cfg.noValidate && !cfg.continueOnDiscrepancy
+}
+
+// exitStatus derives the exit code from the results. Without validate
+// (see failOnDiscrepancy), a discrepancy is not looked for.
+func exitStatus(results []Result, validate bool) int {
+	succeeded := false
+	for _, r := range results {
+		if r.Err == nil && r.Value != nil {
+			succeeded = true
+			break
+		}
+	}
+	if !succeeded {
+		return exitAllFailed
+	}
+	if !validate {
+		return exitOK
+	}
+	if compared, ok := crossValidate(results); compared && !ok {
+		return exitDiscrepancy
+	}
+	return exitOK
 }
 
 // collectAndDisplayResults retrieves, sorts, and displays calculation results.
 //
 // This function is responsible for the final presentation:
-//  1. It collects all results from the `resultsCh` channel until it's closed.
-//  2. It displays a clear summary.
-//  3. It displays details about the calculated number.
-func collectAndDisplayResults(ctx context.Context, resultsCh <-chan result, n int) {
-	// Since there's only one result, we read it directly.
-	r := <-resultsCh // This will block until the result is sent.
-
-	fmt.Println("\n--------------------------- RESULT ---------------------------")
-
-	if r.err != nil {
-		// Distinguish a timeout from other errors for a clearer message.
-		if err := ctx.Err(); err == context.DeadlineExceeded && r.err == context.DeadlineExceeded {
-			log.Printf("⚠️ Task '%s' was interrupted by the global timeout after %v", r.name, r.duration.Round(time.Microsecond))
-		} else if r.err == context.DeadlineExceeded {
-			log.Printf("⚠️ Task '%s' self-terminated due to context cancellation (possibly timeout) after %v", r.name, r.duration.Round(time.Microsecond))
-		} else {
-			log.Printf("❌ Error for task '%s': %v (duration: %v)", r.name, r.err, r.duration.Round(time.Microsecond))
+//  1. It sorts the results with `sortResults`.
+//  2. It displays a clear summary, one line per algorithm, as a table
+//     aligned by `writeResultsTable`.
+//  3. It cross-validates successful results computing the same sequence,
+//     unless `-no-validate` is set.
+//  4. It displays details about the fastest result of each sequence.
+//
+// When `-output` is set, the full value of the fastest result is also written to that file.
+// The sorted results are returned to the caller.
+func collectAndDisplayResults(ctx context.Context, results []Result, cfg config) []Result {
+	results = sortResults(results)
+
+	// With -quiet, only the details of the results reach stdout.
+	if !cfg.quiet {
+		if !cfg.digitsOnly {
+			for i := range results {
+				convertValue(&results[i], cfg.base)
+			}
+			// The texts are only needed for the display.
+			defer func() {
+				for i := range results {
+					results[i].text = ""
+				}
+			}()
+		}
+		fmt.Println("\n--------------------------- RESULTS ---------------------------")
+		writeResultsTable(os.Stdout, results, cfg)
+	}
+
+	successCount := 0
+	for _, r := range results {
+		if r.Err != nil {
+			logTaskFailure(ctx, r)
+		} else if r.Value != nil {
+			successCount++
 		}
+	}
+	if !cfg.quiet {
 		fmt.Println("------------------------------------------------------------------------")
-		fmt.Println("\nThe calculation could not complete successfully.")
-		return
 	}
 
-	// Display the result
-	status := "OK"
-	valStr := "N/A"
-	if r.value != nil {
-		if len(r.value.String()) > 15 {
-			valStr = r.value.String()[:5] + "..." + r.value.String()[len(r.value.String())-5:]
+	if successCount == 0 {
+		if !cfg.quiet {
+			fmt.Println("\nThe calculation could not complete successfully.")
+		}
+		return results
+	}
+
+	// Comparing multi-million-digit values is pointless when only timing.
+	if !cfg.noValidate {
+		if compared, ok := crossValidate(results); compared && ok && !cfg.quiet {
+			fmt.Println("✅ All valid results of the same sequence are identical.")
+		} else if compared && !ok {
+			// Never silenced, but kept off stdout with -quiet.
+			w := io.Writer(os.Stdout)
+			if cfg.quiet {
+				w = os.Stderr
+			}
+			fmt.Fprintln(w, "❌ DISCREPANCY! Algorithms computing the same sequence produced different results.")
+			writeDiscrepancies(w, results)
+		}
+	}
+
+	// Details of the fastest successful result of each sequence.
+	shown := make(map[string]bool)
+	for i, r := range results {
+		if r.Err != nil || r.Value == nil || shown[r.symbol] {
+			continue
+		}
+		shown[r.symbol] = true
+		switch {
+		case cfg.quiet: // No header: the details are the whole output
+		case r.cached:
+			fmt.Printf("\n📊 %s(%d) (from cache)\n", r.symbol, cfg.n)
+		default:
+			fmt.Printf("\n📊 Algorithm: %s (%v)\n", r.Name, r.Duration.Round(time.Microsecond))
+		}
+		if cfg.digitsOnly {
+			fmt.Printf("Number of digits in %s(%d): %d\n", r.symbol, cfg.n, decimalDigits(r.Value))
+			if cfg.mod == 0 {
+				printBitLength(r.Value, r.symbol, cfg.n)
+				printFingerprint(r.Value)
+			}
 		} else {
-			valStr = r.value.String()
+			convertValue(&results[i], cfg.base) // Already done for the table, unless -quiet
+			printFibResultDetails(r.Value, results[i].text, r.symbol, cfg.n, cfg.mod, cfg.base, cfg.sciDigits)
+			if !cfg.quiet {
+				printTiming(results[i])
+			}
 		}
 	}
-	fmt.Printf("%-16s : %-12v [%-14s] Result: %s\n", r.name, r.duration.Round(time.Microsecond), status, valStr)
-	fmt.Println("------------------------------------------------------------------------")
 
-	if r.value != nil {
-		fmt.Printf("\n📊 Algorithm: %s (%v)\n", r.name, r.duration.Round(time.Microsecond))
-		printFibResultDetails(r.value, n)
+	if cfg.output != "" {
+		saveResultToFile(cfg.output, results[0].Value, cfg.base)
+	}
+	return results
+}
+
+// writeResultsTable writes the results table to w: a header row, then one
+// row per result. text/tabwriter aligns the columns whatever the width of the
+// names, durations (`min / median / max` with -repeat) and values.
+func writeResultsTable(w io.Writer, results []Result, cfg config) error {
+	header := []string{"Algorithm", "Duration", "Status", "Peak Mem"}
+	if cfg.countOps {
+		header = append(header, "Ops")
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(append(header, "Result"), "\t"))
+	for _, r := range results {
+		valStr := "N/A"
+		if r.Err == nil && r.Value != nil {
+			if cfg.digitsOnly {
+				valStr = fmt.Sprintf("%d digits", decimalDigits(r.Value))
+			} else if r.text != "" {
+				valStr = abbreviate(r.text)
+			} else {
+				valStr = abbreviate(r.Value.Text(cfg.base))
+			}
+		}
+		row := []string{r.Name, formatDuration(r), resultStatus(r), formatBytes(r.peakMem)}
+		if cfg.countOps {
+			row = append(row, formatOps(r))
+		}
+		fmt.Fprintln(tw, strings.Join(append(row, valStr), "\t"))
+	}
+	return tw.Flush()
+}
+
+// resultStatus returns the status shown for r in the results table and the
+// CSV report.
+func resultStatus(r Result) string {
+	switch {
+	case r.Err == nil && r.cached:
+		return "Cached"
+	case r.Err == nil:
+		return "OK"
+	case r.taskTimeout:
+		return "Task Timeout"
+	case errors.Is(r.Err, context.DeadlineExceeded):
+		return "Timeout"
+	case errors.Is(r.Err, context.Canceled):
+		return "Cancelled"
+	default:
+		return "Error"
+	}
+}
+
+// formatDuration renders the duration column of the results table: the
+// duration, `min / median / max` for a successful repeated task, or both the
+// duration and the deadline for a timed-out one (see formatTimeout).
+func formatDuration(r Result) string {
+	if r.repeats > 1 && r.Err == nil {
+		return fmt.Sprintf("%v / %v / %v", r.minDuration.Round(time.Microsecond), r.Duration.Round(time.Microsecond), r.maxDuration.Round(time.Microsecond))
+	}
+	if errors.Is(r.Err, context.DeadlineExceeded) && r.deadline != 0 {
+		return formatTimeout(r)
+	}
+	return r.Duration.Round(time.Microsecond).String()
+}
+
+// formatTimeout describes when a timed-out task stopped: an algorithm only
+// notices the deadline at its next cancellation check, so its duration may
+// fall short of the deadline (a check skipped at the end of a step) or
+// exceed it (a long multiplication in progress). Both are shown, e.g.
+// "noticed at 3ms, deadline was 5ms". A task may also start once the
+// deadline has passed, when it waited for a -concurrency slot or behind a
+// warmup.
+func formatTimeout(r Result) string {
+	if r.deadline < 0 {
+		return fmt.Sprintf("noticed at %v, deadline passed %v before the start", r.Duration.Round(time.Microsecond), (-r.deadline).Round(time.Microsecond))
+	}
+	return fmt.Sprintf("noticed at %v, deadline was %v", r.Duration.Round(time.Microsecond), r.deadline.Round(time.Microsecond))
+}
+
+// formatOps renders the operations column of the results table, shown with
+// `-count-ops`: "-" when the algorithm counted nothing, either because it is
+// not instrumented or because the result did not come from a calculation.
+func formatOps(r Result) string {
+	if r.ops == nil || *r.ops == (fib.OpCounts{}) {
+		return "-"
+	}
+	return fmt.Sprintf("%d mul, %d add", r.ops.Mul, r.ops.Add)
+}
+
+// logTaskFailure logs why a task failed, distinguishing a timeout from other
+// errors. A timeout also logs the deadline the task had, next to the time at
+// which it noticed it (see formatTimeout).
+func logTaskFailure(ctx context.Context, r Result) {
+	duration := r.Duration.Round(time.Microsecond)
+	deadline := r.deadline.Round(time.Microsecond)
+	if r.taskTimeout {
+		slog.Warn("task exceeded its own timeout (-per-algo-timeout)", "algorithm", r.Name, "duration", duration, "deadline", deadline)
+	} else if r.outrun {
+		slog.Info("task cancelled after a faster algorithm succeeded (-only-fastest)", "algorithm", r.Name, "duration", duration)
+	} else if err := ctx.Err(); err == context.DeadlineExceeded && errors.Is(r.Err, context.DeadlineExceeded) {
+		slog.Warn("task interrupted by the global timeout", "algorithm", r.Name, "duration", duration, "deadline", deadline)
+	} else if errors.Is(r.Err, context.DeadlineExceeded) {
+		slog.Warn("task self-terminated due to context cancellation (possibly timeout)", "algorithm", r.Name, "duration", duration, "deadline", deadline)
+	} else if errors.Is(r.Err, context.Canceled) {
+		slog.Warn("task cancelled by an interrupt", "algorithm", r.Name, "duration", duration)
 	} else {
-		// This case should ideally be covered by r.err != nil
-		fmt.Println("\nNo result value was produced, despite no explicit error.")
+		slog.Error("task failed", "algorithm", r.Name, "duration", duration, "error", r.Err)
+	}
+}
+
+// abbreviate shortens long numeric strings to their first and last 5 digits.
+func abbreviate(text string) string {
+	if len(text) > 15 {
+		return text[:5] + "..." + text[len(text)-5:]
 	}
+	return text
 }
 
-// printFibResultDetails displays detailed information about the calculated Fibonacci number.
-// This function remains unchanged as its logic is independent of the number of algorithms.
-func printFibResultDetails(value *big.Int, n int) {
+// convertValue sets r.text to the value of a successful result in base and
+// r.stringify to the time the conversion took, unless it is already done.
+// The conversion is not part of the calculation: for large n, it can take
+// longer than the algorithm itself, and is done once for the table and the
+// details to share.
+func convertValue(r *Result, base int) {
+	if r.text != "" || r.Err != nil || r.Value == nil {
+		return
+	}
+	start := time.Now()
+	r.text = r.Value.Text(base)
+	r.stringify = time.Since(start)
+}
+
+// printFibResultDetails prints the details of the term symbol(n) of value,
+// whose text in base is text: its digit count, bit length and fingerprint,
+// then the value itself, or its scientific notation when it has more than
+// 20 digits. The symbol names the computed sequence ("F" for Fibonacci, "L"
+// for Lucas). In modular mode (mod > 0), the residue is always small and
+// printed in full. Digits are counted and printed in the given base (2 to
+// 36), and the scientific notation keeps sciDigits digits after the point
+// (see scientificNotation).
+func printFibResultDetails(value *big.Int, text, symbol string, n int, mod uint64, base, sciDigits int) {
 	if value == nil {
 		return
 	}
+	if mod > 0 {
+		fmt.Printf("%s(%d) mod %d = %s%s\n", symbol, n, mod, text, baseSuffix(base))
+		return
+	}
 
-	digits := len(value.Text(10))
-	fmt.Printf("Number of digits in F(%d): %d\n", n, digits)
+	digits := len(text)
+	if value.Sign() < 0 {
+		digits-- // Do not count the minus sign
+	}
+	fmt.Printf("Number of digits in %s(%d)%s: %d\n", symbol, n, baseSuffix(base), digits)
+	printBitLength(value, symbol, n)
+	printFingerprint(value)
 
 	// Use scientific notation for numbers too large to display.
-	if digits > 20 {
-		floatVal := new(big.Float).SetPrec(uint(digits + 10)).SetInt(value)
-		sci := floatVal.Text('e', 8) // 8 digits of precision for scientific notation
-		fmt.Printf("Value (scientific notation) ≈ %s\n", sci)
+	if digits > 20 && sciDigits == 0 {
+		fmt.Printf("Value (order of magnitude) ≈ %s\n", scientificNotation(value, 0))
+	} else if digits > 20 {
+		fmt.Printf("Value (scientific notation) ≈ %s\n", scientificNotation(value, sciDigits))
 	} else {
-		fmt.Printf("Value = %s\n", value.Text(10))
+		fmt.Printf("Value = %s%s\n", text, baseSuffix(base))
+	}
+}
+
+// printTiming prints the phases of a displayed result: the calculation by
+// the algorithm, the duration reported everywhere else, then the conversion
+// of its value to text by convertValue. A cached value has no
+// calculation.
+func printTiming(r Result) {
+	if r.cached {
+		fmt.Printf("Timing: stringify %v\n", r.stringify.Round(time.Microsecond))
+		return
+	}
+	fmt.Printf("Timing: compute %v, stringify %v\n", r.Duration.Round(time.Microsecond), r.stringify.Round(time.Microsecond))
+}
+
+const (
+	// defaultSciDigits is the default of `-sci-digits`.
+	defaultSciDigits = 8
+	// sciGuardBits is the number of bits of precision kept beyond the k
+	// digits requested from scientificNotation. The k-th digit can only be
+	// misrounded when the digits following it are a run of about 19 nines or
+	// zeros.
+	sciGuardBits = 64
+)
+
+// scientificNotation renders value in decimal scientific notation with k
+// digits after the point, e.g. "2.59740692e+41797" for k = 8. With k = 0, it
+// only gives the order of magnitude, "10^41797", the power of ten of the
+// leading digit. The big.Float holds about k·log2(10) bits plus
+// sciGuardBits, not the whole value, which keeps the conversion cheap for
+// numbers of millions of digits.
+func scientificNotation(value *big.Int, k int) string {
+	if k == 0 {
+		sign := ""
+		if value.Sign() < 0 {
+			sign = "-"
+		}
+		return fmt.Sprintf("%s10^%d", sign, decimalDigits(value)-1)
+	}
+	prec := uint(math.Ceil(float64(k+1)*math.Log2(10))) + sciGuardBits
+	prec = min(prec, uint(value.BitLen()+10)) // Exact beyond that
+	return new(big.Float).SetPrec(prec).SetInt(value).Text('e', k)
+}
+
+// printBitLength displays the size of value in bits and bytes, which is what
+// matters for memory and serialization, and compares the bit length of F(n)
+// or L(n) to the theoretical |n|·log2(φ).
+func printBitLength(value *big.Int, symbol string, n int) {
+	fmt.Printf("Bit length: %d (%d bytes)", value.BitLen(), len(value.Bytes()))
+	if ratio, ok := bitLengthRatio(value.BitLen(), symbol, n); ok {
+		fmt.Printf(", %.6f × n·log2(φ)", ratio)
+	}
+	fmt.Println()
+}
+
+// fingerprintModulus is the prime 10^9+7 reduced by fingerprint.
+const fingerprintModulus = 1_000_000_007
+
+// fingerprint returns a compact digest of value, so that two runs can be
+// compared without the full number: value mod 10^9+7, which is cheap and
+// keeps the sign, and the first 16 hex digits of the SHA-256 of the
+// big-endian bytes of |value|.
+func fingerprint(value *big.Int) (residue uint64, hash string) {
+	residue = new(big.Int).Mod(value, big.NewInt(fingerprintModulus)).Uint64()
+	sum := sha256.Sum256(value.Bytes())
+	return residue, hex.EncodeToString(sum[:8])
+}
+
+// printFingerprint displays the fingerprint of value.
+func printFingerprint(value *big.Int) {
+	residue, hash := fingerprint(value)
+	fmt.Printf("Fingerprint: mod 1e9+7 = %d, sha256 = %s\n", residue, hash)
+}
+
+// bitLengthRatio returns bits divided by |n|·log2(φ). The boolean is false
+// for n = 0, where the theoretical size is 0, and for the sequences other
+// than F and L, which do not grow as φⁿ: T(n), or the G(n) of `-seed` and
+// `-recurrence`, whose size depends on their parameters.
+func bitLengthRatio(bits int, symbol string, n int) (float64, bool) {
+	if n == 0 || symbol != "F" && symbol != "L" {
+		return 0, false
+	}
+	return float64(bits) / (math.Abs(float64(n)) * math.Log2(math.Phi)), true
+}
+
+// baseSuffix returns a " (base b)" annotation for non-decimal output.
+func baseSuffix(base int) string {
+	if base == 10 {
+		return ""
 	}
+	return fmt.Sprintf(" (base %d)", base)
 }
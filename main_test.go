@@ -3,9 +3,24 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
 	"math/big"
+	"os"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/agbruneau/FibJule/fib"
 )
 
 // TestFibFastDoublingAlgorithm verifies the correctness of the Fast Doubling algorithm
@@ -24,7 +39,10 @@ func TestFibFastDoublingAlgorithm(t *testing.T) {
 		{"n=7", 7, big.NewInt(13), false},
 		{"n=10", 10, big.NewInt(55), false},
 		{"n=20", 20, big.NewInt(6765), false},
-		{"negative n", -1, nil, true}, // Test case for negative input
+		{"n=-1", -1, big.NewInt(1), false}, // Negafibonacci: F(-n) = (-1)^(n+1)·F(n)
+		{"n=-2", -2, big.NewInt(-1), false},
+		{"n=-6", -6, big.NewInt(-8), false},
+		{"n out of range", math.MinInt, nil, true}, // -n overflows
 	}
 
 	pool := newIntPool()
@@ -37,8 +55,8 @@ func TestFibFastDoublingAlgorithm(t *testing.T) {
 		// t.Run creates sub-tests, making debugging easier.
 		t.Run(algoName+"/"+tc.name, func(t *testing.T) {
 			// Execute the algorithm function.
-			// The progress channel is not needed for correctness testing.
-			got, err := algoFunc(ctx, nil, tc.n, pool)
+			// No progress reporter is needed for correctness testing.
+			got, err := algoFunc(ctx, tc.n, pool)
 
 			// Check if an error was expected.
 			if tc.wantErr {
@@ -67,6 +85,1169 @@ func TestFibFastDoublingAlgorithm(t *testing.T) {
 	}
 }
 
+// TestFibFastDoublingModAlgorithm verifies that the modular Fast Doubling
+// variant agrees with the full computation reduced modulo m.
+func TestFibFastDoublingModAlgorithm(t *testing.T) {
+	pool := newIntPool()
+	ctx := context.Background()
+	moduli := []int64{1, 2, 10, 97, 1000000007}
+
+	for _, m := range moduli {
+		mod := big.NewInt(m)
+		// Include indices beyond 6m so the Pisano reduction is exercised.
+		for _, n := range []int{0, 1, 2, 7, 59, 60, 61, 1000, 4321} {
+			full, err := fibFastDoubling(ctx, n, pool)
+			if err != nil {
+				t.Fatalf("unexpected error for F(%d): %v", n, err)
+			}
+			want := new(big.Int).Mod(full, mod)
+
+			got, err := fibFastDoublingMod(ctx, n, mod, pool)
+			if err != nil {
+				t.Fatalf("unexpected error for F(%d) mod %d: %v", n, m, err)
+			}
+			if got.Cmp(want) != 0 {
+				t.Errorf("for F(%d) mod %d, expected %s, but got %s", n, m, want, got)
+			}
+		}
+	}
+
+	if _, err := fibFastDoublingMod(ctx, 10, big.NewInt(0), pool); err == nil {
+		t.Error("expected an error for a zero modulus, but got none")
+	}
+}
+
+// TestFibFastDoublingPair verifies that the pair version returns F(n) and
+// F(n+1), and that it agrees with the single-value function.
+func TestFibFastDoublingPair(t *testing.T) {
+	pool := newIntPool()
+	ctx := context.Background()
+	known := []int64{0, 1, 1, 2, 3, 5, 8, 13, 21, 34, 55, 89}
+
+	for n := 0; n+1 < len(known); n++ {
+		fn, fn1, err := fibFastDoublingPair(ctx, n, pool)
+		if err != nil {
+			t.Fatalf("unexpected error for n=%d: %v", n, err)
+		}
+		if fn.Int64() != known[n] || fn1.Int64() != known[n+1] {
+			t.Errorf("for n=%d, expected (%d, %d), but got (%s, %s)", n, known[n], known[n+1], fn, fn1)
+		}
+	}
+
+	for _, n := range []int{100, 1000, 4097, -1, -2, -1000} {
+		fn, fn1, err := fibFastDoublingPair(ctx, n, pool)
+		if err != nil {
+			t.Fatalf("unexpected error for n=%d: %v", n, err)
+		}
+		want, _ := fibFastDoubling(ctx, n, pool)
+		wantNext, _ := fibFastDoubling(ctx, n+1, pool)
+		if fn.Cmp(want) != 0 || fn1.Cmp(wantNext) != 0 {
+			t.Errorf("for n=%d, the pair differs from two single-value calls", n)
+		}
+	}
+
+	if _, _, err := fibFastDoublingPair(ctx, math.MinInt, pool); err == nil {
+		t.Error("expected an error for n=math.MinInt, but got none")
+	}
+}
+
+// TestFibFastDoublingInto verifies that the in-place version writes the same
+// values as fibFastDoubling, including when dst is reused.
+func TestFibFastDoublingInto(t *testing.T) {
+	pool := newIntPool()
+	ctx := context.Background()
+	dst := new(big.Int)
+
+	for _, n := range []int{1000, 0, 20, 1, 5000, -6} {
+		if err := fibFastDoublingInto(ctx, dst, n, pool); err != nil {
+			t.Fatalf("unexpected error for n=%d: %v", n, err)
+		}
+		want, _ := fibFastDoubling(ctx, n, pool)
+		if dst.Cmp(want) != 0 {
+			t.Errorf("for F(%d), expected %s, but got %s", n, want, dst)
+		}
+	}
+
+	if err := fibFastDoublingInto(ctx, dst, math.MinInt, pool); err == nil {
+		t.Error("expected an error for n=math.MinInt, but got none")
+	}
+}
+
+// TestFibLucasAlgorithm verifies the correctness of the Lucas numbers algorithm
+// using a table-driven approach, mirroring TestFibFastDoublingAlgorithm.
+func TestFibLucasAlgorithm(t *testing.T) {
+	testCases := []struct {
+		name    string
+		n       int
+		want    *big.Int
+		wantErr bool
+	}{
+		{"n=0", 0, big.NewInt(2), false},
+		{"n=1", 1, big.NewInt(1), false},
+		{"n=5", 5, big.NewInt(11), false},
+		{"n=10", 10, big.NewInt(123), false},
+		{"n=-1", -1, big.NewInt(-1), false}, // L(-n) = (-1)^n·L(n)
+		{"n=-5", -5, big.NewInt(-11), false},
+		{"n=-10", -10, big.NewInt(123), false},
+		{"n out of range", math.MinInt, nil, true},
+	}
+
+	pool := newIntPool()
+	ctx := context.Background()
+
+	for _, tc := range testCases {
+		t.Run("Lucas/"+tc.name, func(t *testing.T) {
+			got, err := fibLucas(ctx, tc.n, pool)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("expected an error for n=%d, but got none", tc.n)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.Cmp(tc.want) != 0 {
+				t.Errorf("for L(%d), expected %s, but got %s", tc.n, tc.want, got)
+			}
+		})
+	}
+}
+
+// TestFibMemoAlgorithm verifies the memoized recursion against Fast Doubling,
+// including an index deep enough to overflow a naive recursive version.
+func TestFibMemoAlgorithm(t *testing.T) {
+	pool := newIntPool()
+	ctx := context.Background()
+
+	for _, n := range []int{0, 1, 2, 10, 93, 10000, -1, -2, -93} {
+		want, _ := fibFastDoubling(ctx, n, pool)
+		got, err := fibMemo(ctx, n, pool)
+		if err != nil {
+			t.Fatalf("unexpected error for n=%d: %v", n, err)
+		}
+		if got.Cmp(want) != 0 {
+			t.Errorf("for F(%d), expected %s, but got %s", n, want, got)
+		}
+	}
+
+	if _, err := fibMemo(ctx, math.MinInt, pool); err == nil {
+		t.Error("expected an error for n=math.MinInt, but got none")
+	}
+}
+
+// TestFibBinetAlgorithm verifies Binet's formula against Fast Doubling.
+func TestFibBinetAlgorithm(t *testing.T) {
+	pool := newIntPool()
+	ctx := context.Background()
+
+	for _, n := range []int{0, 1, 2, 10, 93, 10000, -6} {
+		want, _ := fibFastDoubling(ctx, n, pool)
+		got, err := fibBinet(ctx, n, pool)
+		if err != nil {
+			t.Fatalf("unexpected error for n=%d: %v", n, err)
+		}
+		if got.Cmp(want) != 0 {
+			t.Errorf("for F(%d), expected %s, but got %s", n, want, got)
+		}
+	}
+}
+
+// TestFibMatrixAlgorithms verifies both matrix algorithms against Fast Doubling.
+func TestFibMatrixAlgorithms(t *testing.T) {
+	pool := newIntPool()
+	ctx := context.Background()
+
+	for _, n := range []int{0, 1, 2, 10, 93, 10000, -6} {
+		want, _ := fibFastDoubling(ctx, n, pool)
+		for name, fn := range map[string]fibFunc{"Matrix": fibMatrix, "Matrix Fast": fibMatrixFast} {
+			got, err := fn(ctx, n, pool)
+			if err != nil {
+				t.Fatalf("%s: unexpected error for n=%d: %v", name, n, err)
+			}
+			if got.Cmp(want) != 0 {
+				t.Errorf("%s: for F(%d), expected %s, but got %s", name, n, want, got)
+			}
+		}
+	}
+}
+
+// TestSelectTasks checks the resolution of the `-algorithms` flag.
+func TestSelectTasks(t *testing.T) {
+	available := map[string]task{
+		"fast":  {name: "Fast Doubling", symbol: "F", fn: fibFastDoubling},
+		"lucas": {name: "Lucas", symbol: "L", fn: fibLucas},
+	}
+	order := []string{"fast", "lucas"}
+
+	testCases := []struct {
+		spec    string
+		want    []string
+		wantErr bool
+	}{
+		{"fast", []string{"Fast Doubling"}, false},
+		{"Lucas", []string{"Lucas"}, false},
+		{"lucas, fast", []string{"Lucas", "Fast Doubling"}, false},
+		{"all", []string{"Fast Doubling", "Lucas"}, false},
+		{"fast,all,fast", []string{"Fast Doubling", "Lucas"}, false},
+		{"matrix", nil, true},
+		{"auto,fast", nil, true},
+		{"", nil, true},
+	}
+	for _, tc := range testCases {
+		got, err := selectTasks(tc.spec, available, order)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("selectTasks(%q): expected an error, but got none", tc.spec)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("selectTasks(%q): unexpected error: %v", tc.spec, err)
+		}
+		var names []string
+		for _, task := range got {
+			names = append(names, task.name)
+		}
+		if strings.Join(names, ",") != strings.Join(tc.want, ",") {
+			t.Errorf("selectTasks(%q) = %v, expected %v", tc.spec, names, tc.want)
+		}
+	}
+}
+
+// TestAutoAlgorithm verifies the choice of `-algorithms auto` for
+// representative n, and that resolveTasks runs the chosen algorithm.
+func TestAutoAlgorithm(t *testing.T) {
+	testCases := []struct {
+		n    int
+		want string
+	}{
+		{0, "iterative"},
+		{1, "iterative"},
+		{10, "iterative"},
+		{39, "iterative"},
+		{-39, "iterative"},
+		{40, "fast"},
+		{-40, "fast"},
+		{1000, "fast"},
+		{1_000_000, "fast"},
+		{fib.BinetThreshold() + 1, "fast"},
+		{math.MaxInt, "fast"},
+		{math.MinInt, "fast"},
+	}
+	for _, tc := range testCases {
+		if got, reason := autoAlgorithm(tc.n); got != tc.want || reason == "" {
+			t.Errorf("autoAlgorithm(%d) = %q (reason %q), expected %q", tc.n, got, reason, tc.want)
+		}
+	}
+
+	for _, tc := range []struct {
+		n    int
+		name string
+	}{{-30, "Iterative"}, {30, "Iterative"}, {300, "Fast Doubling"}} {
+		tasks, err := resolveTasks(" Auto ", tc.n, nil)
+		if err != nil || len(tasks) != 1 || tasks[0].name != tc.name {
+			t.Fatalf("resolveTasks(auto, %d) = %v, %v, expected the single task %q", tc.n, tasks, err, tc.name)
+		}
+		got, err := tasks[0].fn(context.Background(), tc.n, newIntPool())
+		want, _ := fib.FastDoubling(context.Background(), tc.n)
+		if err != nil || got.Cmp(want) != 0 {
+			t.Errorf("%s(%d) = %v, %v, expected %v", tc.name, tc.n, got, err, want)
+		}
+	}
+}
+
+// TestBinetPrecisionOverride checks that a -precision-bits override too low
+// for F(1000) makes Binet wrong, which cross-validation against Fast Doubling
+// reports, while the derived precision agrees.
+func TestBinetPrecisionOverride(t *testing.T) {
+	for _, tc := range []struct {
+		args []string
+		ok   bool
+	}{
+		{[]string{"-n", "1000", "-algorithms", "fast,binet", "-precision-bits", "100"}, false},
+		{[]string{"-n", "1000", "-algorithms", "fast,binet"}, true},
+	} {
+		cfg, err := parseConfig(tc.args, io.Discard)
+		if err != nil {
+			t.Fatalf("parseConfig(%v): %v", tc.args, err)
+		}
+		m, opts := algorithmOptions(cfg)
+		tasks, err := resolveTasks(cfg.algorithms, cfg.n, m, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		results := computeTasks(context.Background(), tasks, cfg.n, runOptions{})
+		for _, r := range results {
+			if r.Err != nil {
+				t.Fatalf("%v: %s failed: %v", tc.args, r.Name, r.Err)
+			}
+		}
+		if compared, ok := crossValidate(results); !compared || ok != tc.ok {
+			t.Errorf("%v: expected the results to agree: %v, got %v", tc.args, tc.ok, ok)
+		}
+	}
+}
+
+// TestSortResultsAndCrossValidate checks result ordering and that
+// cross-validation only compares results of the same sequence.
+func TestSortResultsAndCrossValidate(t *testing.T) {
+	results := sortResults([]Result{
+		{Name: "slow", symbol: "F", Value: big.NewInt(55), Duration: 3 * time.Millisecond},
+		{Name: "failed", symbol: "F", Err: context.DeadlineExceeded, Duration: time.Millisecond},
+		{Name: "fast", symbol: "F", Value: big.NewInt(55), Duration: 2 * time.Millisecond},
+		{Name: "lucas", symbol: "L", Value: big.NewInt(123), Duration: 5 * time.Millisecond},
+	})
+	var order []string
+	for _, r := range results {
+		order = append(order, r.Name)
+	}
+	if got := strings.Join(order, ","); got != "fast,slow,lucas,failed" {
+		t.Errorf("unexpected result order: %s", got)
+	}
+
+	if compared, ok := crossValidate(results); !compared || !ok {
+		t.Errorf("expected consistent results, got compared=%v ok=%v", compared, ok)
+	}
+	if compared, _ := crossValidate(results[2:]); compared {
+		t.Error("expected no comparison between different sequences")
+	}
+
+	results = append(results, Result{Name: "buggy", symbol: "F", Value: big.NewInt(56)})
+	if _, ok := crossValidate(results); ok {
+		t.Error("expected a discrepancy to be detected")
+	}
+}
+
+// TestExitStatus checks the exit code derived from the results.
+func TestExitStatus(t *testing.T) {
+	ok := func(name, symbol string, v int64) Result {
+		return Result{Name: name, symbol: symbol, Value: big.NewInt(v)}
+	}
+	failed := Result{Name: "failed", symbol: "F", Err: context.DeadlineExceeded}
+
+	testCases := []struct {
+		name    string
+		results []Result
+		want    int
+	}{
+		{"single success", []Result{ok("a", "F", 5)}, exitOK},
+		{"agreement", []Result{ok("a", "F", 5), ok("b", "F", 5), ok("l", "L", 11)}, exitOK},
+		{"partial failure", []Result{ok("a", "F", 5), failed}, exitOK},
+		{"discrepancy", []Result{ok("a", "F", 5), ok("b", "F", 6)}, exitDiscrepancy},
+		{"all failed", []Result{failed, failed}, exitAllFailed},
+		{"no results", nil, exitAllFailed},
+	}
+	for _, tc := range testCases {
+		if got := exitStatus(tc.results, true); got != tc.want {
+			t.Errorf("%s: expected exit code %d, got %d", tc.name, tc.want, got)
+		}
+	}
+	discrepancy := []Result{ok("a", "F", 5), ok("b", "F", 6)}
+	if got := exitStatus(discrepancy, false); got != exitOK {
+		t.Errorf("without validation: expected exit code %d, got %d", exitOK, got)
+	}
+}
+
+// TestDiscrepancyReport injects a result disagreeing with another one of the
+// same sequence, and checks the report naming the algorithms with their
+// fingerprints, and the exit status with and without -continue-on-discrepancy.
+func TestDiscrepancyReport(t *testing.T) {
+	results := []Result{
+		{Name: "Fast Doubling", symbol: "F", Value: big.NewInt(55), Duration: time.Millisecond},
+		{Name: "Buggy", symbol: "F", Value: big.NewInt(1_000_000_007 + 56), Duration: 2 * time.Millisecond},
+		{Name: "Lucas", symbol: "L", Value: big.NewInt(123), Duration: 3 * time.Millisecond},
+	}
+	for _, tc := range []struct {
+		args []string
+		want int
+	}{
+		{[]string{"-n", "10"}, exitDiscrepancy},
+		{[]string{"-n", "10", "-continue-on-discrepancy"}, exitOK},
+	} {
+		cfg, err := parseConfig(tc.args, io.Discard)
+		if err != nil {
+			t.Fatalf("parseConfig(%v): %v", tc.args, err)
+		}
+		out := captureStdout(t, func() { collectAndDisplayResults(context.Background(), slices.Clone(results), cfg) })
+		for _, want := range []string{"DISCREPANCY", "Results of F(n) by algorithm:", "Fast Doubling  mod 1e9+7 = 55", "Buggy          mod 1e9+7 = 56"} {
+			if !strings.Contains(out, want) {
+				t.Errorf("%v: expected %q in the report, got:\n%s", tc.args, want, out)
+			}
+		}
+		if strings.Contains(out, "L(n) by algorithm") {
+			t.Errorf("%v: the agreeing Lucas sequence should not be listed, got:\n%s", tc.args, out)
+		}
+		if got := exitStatus(results, failOnDiscrepancy(cfg)); got != tc.want {
+			t.Errorf("%v: expected exit code %d, got %d", tc.args, tc.want, got)
+		}
+	}
+}
+
+// TestRunTasksSequential checks that -sequential never runs two tasks at
+// once, while the default mode runs them concurrently.
+func TestRunTasksSequential(t *testing.T) {
+	for _, sequential := range []bool{true, false} {
+		var running, maxRunning atomic.Int32
+		slow := func(ctx context.Context, n int, pool *sync.Pool) (*big.Int, error) {
+			current := running.Add(1)
+			defer running.Add(-1)
+			for {
+				if m := maxRunning.Load(); current <= m || maxRunning.CompareAndSwap(m, current) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+			return big.NewInt(int64(n)), nil
+		}
+		tasks := []task{
+			{name: "A", symbol: "F", fn: slow},
+			{name: "B", symbol: "F", fn: slow},
+			{name: "C", symbol: "F", fn: slow},
+		}
+
+		resultsCh := make(chan Result, len(tasks))
+		runTasks(context.Background(), tasks, 7, resultsCh, runOptions{sequential: sequential})
+		close(resultsCh)
+
+		count := 0
+		for r := range resultsCh {
+			count++
+			if r.Err != nil || r.Value.Int64() != 7 {
+				t.Errorf("unexpected result for task %s: %+v", r.Name, r)
+			}
+		}
+		if count != len(tasks) {
+			t.Errorf("expected %d results, but got %d", len(tasks), count)
+		}
+		if sequential && maxRunning.Load() != 1 {
+			t.Errorf("sequential mode ran %d tasks at once", maxRunning.Load())
+		}
+		if !sequential && maxRunning.Load() < 2 {
+			t.Errorf("concurrent mode never ran tasks at the same time")
+		}
+	}
+}
+
+// TestRunTasksConcurrency checks that -concurrency k never runs more than k
+// tasks at once, and that the queued tasks all run once slots free up.
+func TestRunTasksConcurrency(t *testing.T) {
+	for _, k := range []int{1, 2, 3} {
+		var running, maxRunning atomic.Int32
+		slow := func(ctx context.Context, n int, pool *sync.Pool) (*big.Int, error) {
+			current := running.Add(1)
+			defer running.Add(-1)
+			for {
+				if m := maxRunning.Load(); current <= m || maxRunning.CompareAndSwap(m, current) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			return big.NewInt(int64(n)), nil
+		}
+		tasks := make([]task, 7)
+		for i := range tasks {
+			tasks[i] = task{name: fmt.Sprint(i), symbol: "F", fn: slow}
+		}
+
+		resultsCh := make(chan Result, len(tasks))
+		runTasks(context.Background(), tasks, 7, resultsCh, runOptions{concurrency: k})
+		close(resultsCh)
+
+		if got := len(resultsCh); got != len(tasks) {
+			t.Errorf("k=%d: expected %d results, but got %d", k, len(tasks), got)
+		}
+		if m := maxRunning.Load(); m > int32(k) {
+			t.Errorf("k=%d: %d tasks ran at once", k, m)
+		}
+	}
+	if concurrencyLimit(0) != runtime.GOMAXPROCS(0) || concurrencyLimit(3) != 3 {
+		t.Error("expected 0 to stand for GOMAXPROCS")
+	}
+}
+
+// TestSetGOMAXPROCS checks that `-gomaxprocs` sets GOMAXPROCS to the
+// requested value, that 0 keeps it, and that both restore it afterwards.
+func TestSetGOMAXPROCS(t *testing.T) {
+	prev := runtime.GOMAXPROCS(0)
+	want := prev + 1
+
+	restore := setGOMAXPROCS(want)
+	if got := runtime.GOMAXPROCS(0); got != want {
+		t.Errorf("expected GOMAXPROCS %d, but got %d", want, got)
+	}
+	restore()
+	if got := runtime.GOMAXPROCS(0); got != prev {
+		t.Errorf("expected GOMAXPROCS to be restored to %d, but got %d", prev, got)
+	}
+
+	restore = setGOMAXPROCS(0)
+	if got := runtime.GOMAXPROCS(0); got != prev {
+		t.Errorf("expected 0 to keep GOMAXPROCS %d, but got %d", prev, got)
+	}
+	restore()
+}
+
+// TestRunTasksPerAlgoTimeout verifies that a task exceeding its own timeout
+// is flagged as such without preventing the others from completing.
+func TestRunTasksPerAlgoTimeout(t *testing.T) {
+	sleepy := func(d time.Duration) fibFunc {
+		return func(ctx context.Context, n int, pool *sync.Pool) (*big.Int, error) {
+			select {
+			case <-time.After(d):
+				return big.NewInt(int64(n)), nil
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+	}
+	tasks := []task{
+		{name: "quick", symbol: "F", fn: sleepy(time.Millisecond)},
+		{name: "slow", symbol: "F", fn: sleepy(time.Minute)},
+	}
+
+	resultsCh := make(chan Result, len(tasks))
+	runTasks(context.Background(), tasks, 7, resultsCh, runOptions{taskTimeout: 50 * time.Millisecond})
+	close(resultsCh)
+
+	for r := range resultsCh {
+		switch r.Name {
+		case "quick":
+			if r.Err != nil || r.taskTimeout {
+				t.Errorf("expected the quick task to succeed, got %+v", r)
+			}
+		case "slow":
+			if r.Err != context.DeadlineExceeded || !r.taskTimeout {
+				t.Errorf("expected the slow task to hit its own timeout, got %+v", r)
+			}
+		}
+	}
+
+	// An expired parent context is not reported as a per-task timeout.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if r := runTask(ctx, tasks[1], 7, nil, runOptions{taskTimeout: time.Minute}); r.Err != context.DeadlineExceeded || r.taskTimeout {
+		t.Errorf("expected a global timeout, got %+v", r)
+	}
+}
+
+// TestRunTaskDeadline runs a large n under a short timeout and checks that
+// the result reports both the time at which the algorithm noticed the
+// deadline and the deadline itself.
+func TestRunTaskDeadline(t *testing.T) {
+	const timeout = 5 * time.Millisecond
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	r := runTask(ctx, task{name: "Fast Doubling", symbol: "F", fn: fibFastDoubling}, 100_000_000, nil, runOptions{})
+	if r.Err != context.DeadlineExceeded {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", r.Err)
+	}
+	if r.deadline <= 0 || r.deadline > timeout {
+		t.Errorf("expected a deadline within (0, %v], got %v", timeout, r.deadline)
+	}
+	if got := formatDuration(r); !strings.HasPrefix(got, "noticed at ") || !strings.Contains(got, ", deadline was ") {
+		t.Errorf("expected the duration column to show both times, got %q", got)
+	}
+
+	r = Result{Err: context.DeadlineExceeded, Duration: 3 * time.Millisecond, deadline: 5 * time.Millisecond}
+	if got, want := formatDuration(r), "noticed at 3ms, deadline was 5ms"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+	r.deadline = -2 * time.Millisecond
+	if got, want := formatDuration(r), "noticed at 3ms, deadline passed 2ms before the start"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+	// Without a known deadline, the duration is shown alone.
+	r.deadline = 0
+	if got := formatDuration(r); got != "3ms" {
+		t.Errorf("expected %q, got %q", "3ms", got)
+	}
+}
+
+// TestRunTaskRepeat checks that -repeat runs the task k times, reports the
+// duration statistics, and relays the repetition in flight with the overall
+// progress.
+func TestRunTaskRepeat(t *testing.T) {
+	var calls atomic.Int32
+	fn := func(ctx context.Context, n int, pool *sync.Pool) (*big.Int, error) {
+		time.Sleep(time.Duration(calls.Add(1)) * time.Millisecond)
+		reporter, _ := progressReporter(ctx)
+		reporter.Report("A", 50)
+		return big.NewInt(int64(n)), nil
+	}
+
+	progressCh := make(chan progressData, 10)
+	ctx := WithProgressReporter(context.Background(), channelReporter(progressCh))
+	r := runTask(ctx, task{name: "A", symbol: "F", fn: fn}, 7, nil, runOptions{repeat: 3})
+	close(progressCh)
+
+	if calls.Load() != 3 || r.repeats != 3 || r.Err != nil || r.Value.Int64() != 7 {
+		t.Fatalf("expected 3 successful runs, got %d calls and %+v", calls.Load(), r)
+	}
+	if !(r.minDuration <= r.Duration && r.Duration <= r.maxDuration) || r.minDuration == r.maxDuration {
+		t.Errorf("unexpected durations: min %v, median %v, max %v", r.minDuration, r.Duration, r.maxDuration)
+	}
+
+	var got []progressData
+	for p := range progressCh {
+		got = append(got, p)
+	}
+	want := []progressData{
+		{name: "A", pct: 50.0 / 3, rep: 1, reps: 3},
+		{name: "A", pct: 150.0 / 3, rep: 2, reps: 3},
+		{name: "A", pct: 250.0 / 3, rep: 3, reps: 3},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("progress %d: expected %+v, got %+v", i, want[i], got[i])
+		}
+	}
+
+	if d := median([]time.Duration{1, 2, 3, 10}); d != 2 {
+		t.Errorf("expected the median of an even count to average the middle values, got %v", d)
+	}
+}
+
+// TestRunTaskCountOps checks that -count-ops reaches the fib algorithms
+// through the task context, reports the counts of the last run only, and
+// renders "-" for an algorithm counting nothing.
+func TestRunTaskCountOps(t *testing.T) {
+	fast := task{name: "Fast Doubling", symbol: "F", fn: fastDoublingTask(nil)}
+	r := runTask(context.Background(), fast, 1000, nil, runOptions{repeat: 2, countOps: true})
+	if r.Err != nil || r.ops == nil || *r.ops != (fib.OpCounts{Mul: 30, Add: 36}) {
+		t.Fatalf("expected the counts of one run of F(1000), got %+v (err=%v)", r.ops, r.Err)
+	}
+	if got := formatOps(r); got != "30 mul, 36 add" {
+		t.Errorf("unexpected operations column: %q", got)
+	}
+
+	if r := runTask(context.Background(), fast, 1000, nil, runOptions{}); r.ops != nil {
+		t.Errorf("expected no counts without -count-ops, got %+v", r.ops)
+	}
+	memo := task{name: "Memoized", symbol: "F", fn: memoTask(nil)}
+	if r := runTask(context.Background(), memo, 100, nil, runOptions{countOps: true}); formatOps(r) != "-" {
+		t.Errorf("expected no counts for an uninstrumented algorithm, got %q", formatOps(r))
+	}
+}
+
+// TestRunTasksWarmup checks that every task computes the warmup index with
+// the pool of its timed run, before any task is timed, and that only the
+// timed run is reported.
+func TestRunTasksWarmup(t *testing.T) {
+	var mu sync.Mutex
+	var calls []int
+	pools := make(map[int]*sync.Pool)
+	fn := func(ctx context.Context, n int, pool *sync.Pool) (*big.Int, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, n)
+		if n == 3 {
+			pools[len(calls)] = pool
+		}
+		return big.NewInt(int64(n)), nil
+	}
+	tasks := []task{{name: "A", symbol: "F", fn: fn}, {name: "B", symbol: "F", fn: fn}}
+
+	resultsCh := make(chan Result, len(tasks))
+	runTasks(context.Background(), tasks, 7, resultsCh, runOptions{sequential: true, warmup: 3})
+	close(resultsCh)
+
+	if want := []int{3, 3, 7, 7}; !slices.Equal(calls, want) {
+		t.Fatalf("expected calls %v, got %v", want, calls)
+	}
+	if pools[1] == pools[2] {
+		t.Error("expected each task to warm up its own pool")
+	}
+	for r := range resultsCh {
+		if r.Value.Int64() != 7 || r.repeats != 1 {
+			t.Errorf("expected only the timed F(7) run to be reported, got %+v", r)
+		}
+	}
+}
+
+// BenchmarkFirstTaskCold and BenchmarkFirstTaskWarm report the measured
+// duration of the first task run, without and with -warmup. The warm one
+// varies less from run to run: go test -run '^$' -bench FirstTask -count 10
+func BenchmarkFirstTaskCold(b *testing.B) {
+	benchmarkFirstTask(b, 0)
+}
+
+func BenchmarkFirstTaskWarm(b *testing.B) {
+	benchmarkFirstTask(b, 100000)
+}
+
+func benchmarkFirstTask(b *testing.B, warmup int) {
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(newLogger(io.Discard, logFormatText, slog.LevelInfo)) // Keep the completion logs out of the report
+
+	tasks := []task{{name: "Fast Doubling", symbol: "F", fn: fibFastDoubling}}
+	var measured time.Duration
+	for i := 0; i < b.N; i++ {
+		resultsCh := make(chan Result, 1)
+		runTasks(context.Background(), tasks, 100000, resultsCh, runOptions{warmup: warmup})
+		measured += (<-resultsCh).Duration
+	}
+	b.ReportMetric(float64(measured.Nanoseconds())/float64(b.N), "measured-ns/op")
+}
+
+// BenchmarkSequentialNoGC and BenchmarkSequentialGC run Matrix then Fast
+// Doubling one after the other, without and with -gc-between, and report the
+// mean and standard deviation of the duration measured for Fast Doubling,
+// which starts on the heap left by Matrix:
+// go test -run '^$' -bench Sequential -benchtime 30x -count 5
+func BenchmarkSequentialNoGC(b *testing.B) {
+	benchmarkSequential(b, false)
+}
+
+func BenchmarkSequentialGC(b *testing.B) {
+	benchmarkSequential(b, true)
+}
+
+func benchmarkSequential(b *testing.B, gcBetween bool) {
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(newLogger(io.Discard, logFormatText, slog.LevelInfo))
+
+	tasks := []task{
+		{name: "Matrix", symbol: "F", fn: fibMatrix},
+		{name: "Fast Doubling", symbol: "F", fn: fibFastDoubling},
+	}
+	measured := make([]float64, 0, b.N)
+	for i := 0; i < b.N; i++ {
+		resultsCh := make(chan Result, len(tasks))
+		runTasks(context.Background(), tasks, 1000000, resultsCh, runOptions{sequential: true, gcBetween: gcBetween})
+		close(resultsCh)
+		for r := range resultsCh {
+			if r.Name == "Fast Doubling" {
+				measured = append(measured, float64(r.Duration.Nanoseconds()))
+			}
+		}
+	}
+	mean, stddev := meanStddev(measured)
+	b.ReportMetric(mean, "measured-ns/op")
+	b.ReportMetric(stddev, "stddev-ns")
+}
+
+// BenchmarkValidation measures the validation of results of F(10^7) done by
+// exitStatus, with and without -no-validate, for one algorithm and for two
+// agreeing ones: go test -run '^$' -bench Validation
+func BenchmarkValidation(b *testing.B) {
+	v, err := fibFastDoubling(context.Background(), 10000000, newIntPool())
+	if err != nil {
+		b.Fatal(err)
+	}
+	for _, count := range []int{1, 2} {
+		results := make([]Result, count)
+		for i := range results {
+			// Distinct copies, as computed by distinct algorithms
+			results[i] = Result{Name: fmt.Sprint(i), symbol: "F", Value: new(big.Int).Set(v)}
+		}
+		for _, validate := range []bool{true, false} {
+			b.Run(fmt.Sprintf("algorithms=%d/validate=%v", count, validate), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					exitStatus(results, validate)
+				}
+			})
+		}
+	}
+}
+
+// TestFormatZeckendorf checks the rendering of a representation and of the
+// empty sum.
+func TestFormatZeckendorf(t *testing.T) {
+	if got := formatZeckendorf([]int{11, 6, 4}); got != "F(11) + F(6) + F(4)" {
+		t.Errorf("unexpected rendering: %q", got)
+	}
+	if got := formatZeckendorf(nil); got != "0" {
+		t.Errorf("expected 0 for the empty sum, got %q", got)
+	}
+}
+
+// TestFingerprint pins the fingerprint of F(100), and checks that the
+// residue tells F(-100) = -F(100) apart.
+func TestFingerprint(t *testing.T) {
+	f, _ := new(big.Int).SetString("354224848179261915075", 10)
+	if residue, hash := fingerprint(f); residue != 687995182 || hash != "bfbe9185a2c991f4" {
+		t.Errorf("unexpected fingerprint of F(100): %d, %s", residue, hash)
+	}
+	if residue, _ := fingerprint(new(big.Int).Neg(f)); residue != fingerprintModulus-687995182 {
+		t.Errorf("unexpected residue of -F(100): %d", residue)
+	}
+}
+
+// TestBitLengthRatio checks the ratio to n·log2(φ), including the F(0) and
+// F(1) edge cases, and its absence for the sequences not growing as φⁿ.
+func TestBitLengthRatio(t *testing.T) {
+	if _, ok := bitLengthRatio(0, "F", 0); ok {
+		t.Error("expected no ratio for n=0")
+	}
+	if r, ok := bitLengthRatio(1, "F", 1); !ok || math.Abs(r-1/math.Log2(math.Phi)) > 1e-12 {
+		t.Errorf("unexpected ratio for F(1): %v (ok=%v)", r, ok)
+	}
+	f, _ := fibFastDoubling(context.Background(), 100000, newIntPool())
+	if r, ok := bitLengthRatio(f.BitLen(), "F", -100000); !ok || math.Abs(r-1) > 1e-3 {
+		t.Errorf("expected a ratio close to 1 for |n|=100000, got %v (ok=%v)", r, ok)
+	}
+	for _, symbol := range []string{"T", "G", "ΣF²"} {
+		if r, ok := bitLengthRatio(877, symbol, 1000); ok {
+			t.Errorf("expected no ratio for %s(1000), got %v", symbol, r)
+		}
+	}
+}
+
+// fuzzMaxN bounds the fuzzed indices so that each input runs in milliseconds.
+const fuzzMaxN = 20000
+
+// FuzzFib checks that Fast Doubling and Matrix agree for any index in
+// [-fuzzMaxN, fuzzMaxN], without panicking or hanging: each input gets a
+// short deadline. Run it with: go test -run '^$' -fuzz FuzzFib
+func FuzzFib(f *testing.F) {
+	for _, n := range []int{0, 1, 2, 3, -1, -2, 92, 93, 94, 4095, 4096, 4097, fuzzMaxN, -fuzzMaxN} {
+		f.Add(n)
+	}
+	f.Fuzz(func(t *testing.T, n int) {
+		n %= fuzzMaxN + 1 // Clamp to the safe range, keeping the sign
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		pool := newIntPool() // Shared, to surface any aliasing between the two
+		want, err := fibFastDoubling(ctx, n, pool)
+		if err != nil {
+			t.Fatalf("Fast Doubling failed for n=%d: %v", n, err)
+		}
+		got, err := fibMatrix(ctx, n, pool)
+		if err != nil {
+			t.Fatalf("Matrix failed for n=%d: %v", n, err)
+		}
+		if got.Cmp(want) != 0 {
+			t.Errorf("for n=%d, Matrix gives %s but Fast Doubling gives %s", n, got, want)
+		}
+	})
+}
+
+// TestBinetOutOfRange checks the Binet range warning on both sides of the
+// threshold, for negative indices, and without Binet.
+func TestBinetOutOfRange(t *testing.T) {
+	binet := []task{{name: "Fast Doubling"}, {name: "Binet"}}
+	limit := fib.BinetThreshold()
+	if binetOutOfRange(limit, binet) || binetOutOfRange(-limit, binet) {
+		t.Error("expected no warning at the threshold")
+	}
+	if !binetOutOfRange(limit+1, binet) || !binetOutOfRange(-limit-1, binet) {
+		t.Error("expected a warning past the threshold")
+	}
+	if binetOutOfRange(limit+1, binet[:1]) {
+		t.Error("expected no warning without Binet")
+	}
+}
+
+// TestAbbreviate checks the shortening of long results in any base.
+func TestAbbreviate(t *testing.T) {
+	testCases := []struct {
+		in, want string
+	}{
+		{"6765", "6765"},
+		{"123456789012345", "123456789012345"},
+		{"1234567890123456", "12345...23456"},
+		{big.NewInt(1 << 62).Text(2), "10000...00000"},
+	}
+	for _, tc := range testCases {
+		if got := abbreviate(tc.in); got != tc.want {
+			t.Errorf("abbreviate(%q) = %q, expected %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+// TestFormatStatus feeds progress updates of several tasks and checks that
+// the consolidated line holds the latest percentage of each, in order, and
+// TestWriteResultsTable renders a mix of long and short names, repeated
+// durations and failures, and checks that every cell of a column starts at
+// the same position as its header.
+func TestWriteResultsTable(t *testing.T) {
+	results := []Result{
+		{Name: "A", symbol: "F", Value: big.NewInt(55), Duration: time.Millisecond},
+		{Name: "A Much Longer Algorithm Name", symbol: "F", Value: big.NewInt(55), Duration: 1500 * time.Millisecond,
+			repeats: 3, minDuration: time.Second, maxDuration: 2 * time.Second, peakMem: 3 << 20},
+		{Name: "Failed", symbol: "F", Err: context.DeadlineExceeded, Duration: 42 * time.Microsecond},
+	}
+	cfg := config{base: 10, countOps: true}
+	var buf bytes.Buffer
+	if err := writeResultsTable(&buf, results, cfg); err != nil {
+		t.Fatalf("writeResultsTable failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != len(results)+1 {
+		t.Fatalf("expected a header and %d rows, got:\n%s", len(results), buf.String())
+	}
+
+	rows := [][]string{{"Algorithm", "Duration", "Status", "Peak Mem", "Ops", "Result"}}
+	for _, r := range results {
+		value := "N/A"
+		if r.Err == nil {
+			value = "55"
+		}
+		rows = append(rows, []string{r.Name, formatDuration(r), resultStatus(r), formatBytes(r.peakMem), formatOps(r), value})
+	}
+	var want []int
+	for i, cells := range rows {
+		// Column positions in runes, since "µs" takes one column but two bytes.
+		line := []rune(lines[i])
+		var starts []int
+		pos := 0
+		for _, cell := range cells {
+			idx := strings.Index(string(line[pos:]), cell)
+			if idx < 0 {
+				t.Fatalf("cell %q not found in line %q", cell, lines[i])
+			}
+			pos += len([]rune(string(line[pos:])[:idx]))
+			starts = append(starts, pos)
+			pos += len([]rune(cell))
+		}
+		if i == 0 {
+			want = starts
+		} else if !slices.Equal(starts, want) {
+			t.Errorf("row %d is misaligned: columns start at %v, header at %v\n%s", i, starts, want, buf.String())
+		}
+	}
+}
+
+// TestResultStatusWrapped checks that failures are classified by errors.Is,
+// so that a wrapped context error keeps its status and a library error
+// stays a plain failure.
+func TestResultStatusWrapped(t *testing.T) {
+	_, outOfRange := fib.Sum(context.Background(), -1)
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{fmt.Errorf("attempt 2: %w", context.DeadlineExceeded), "Timeout"},
+		{fmt.Errorf("attempt 2: %w", context.Canceled), "Cancelled"},
+		{outOfRange, "Error"},
+	}
+	for _, tt := range tests {
+		if got := resultStatus(Result{Err: tt.err}); got != tt.want {
+			t.Errorf("for %v, expected %q, got %q", tt.err, tt.want, got)
+		}
+	}
+}
+
+// ignores unknown tasks.
+func TestFormatStatus(t *testing.T) {
+	names := []string{"Fast Doubling", "Matrix", "Binet"}
+	status := newProgressStatus(names)
+	start := time.Now()
+	for i, p := range []progressData{
+		{name: "Fast Doubling", pct: 40}, {name: "Matrix", pct: 55}, {name: "Binet", pct: 10},
+		{name: "Fast Doubling", pct: 80}, {name: "Unknown", pct: 99}, {name: "Binet", pct: 90},
+	} {
+		applyProgress(status, p, start.Add(time.Duration(i)*time.Second))
+	}
+
+	got := formatStatus(status, names, 0)
+	want := []string{"Fast Doubling  80.0%", "Matrix  55.0%", "Binet  90.0%"}
+	parts := strings.Split(got, " | ")
+	if len(parts) != len(want) {
+		t.Fatalf("expected %d fields, got %q", len(want), got)
+	}
+	for i, w := range want {
+		if !strings.HasPrefix(parts[i], w) {
+			t.Errorf("field %d: expected prefix %q, got %q", i, w, parts[i])
+		}
+	}
+}
+
+// captureStdout runs fn with os.Stdout redirected to a pipe and returns
+// what it wrote.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	out := make(chan string)
+	go func() {
+		b, _ := io.ReadAll(r)
+		out <- string(b)
+	}()
+	fn()
+	w.Close()
+	return <-out
+}
+
+// TestProgressPrinter exercises the three branches of progressPrinter: new
+// data, ticker refreshes and context cancellation, as well as the final
+// newline when the channel is closed.
+func TestProgressPrinter(t *testing.T) {
+	names := []string{"A", "B"}
+
+	// New data, then a closed channel: one line per update, one last
+	// complete line, then the newline.
+	out := captureStdout(t, func() {
+		progress := make(chan progressData, 2)
+		progress <- progressData{name: "A", pct: 50}
+		progress <- progressData{name: "B", pct: 75}
+		close(progress)
+		progressPrinter(context.Background(), progress, names, 0)
+	})
+	if !strings.HasSuffix(out, "\n") {
+		t.Errorf("expected a final newline after the channel is closed, got %q", out)
+	}
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\r")
+	if last := lines[len(lines)-1]; !strings.Contains(last, "A  50.0%") || !strings.Contains(last, "B  75.0%") {
+		t.Errorf("expected the last line to hold both percentages, got %q", last)
+	}
+	if updates := strings.Count(out, "\r"); updates < 3 {
+		t.Errorf("expected 2 updates and the final line, got %d lines in %q", updates, out)
+	}
+
+	// No data: the ticker keeps refreshing until the context is cancelled,
+	// which ends the display with a newline.
+	out = captureStdout(t, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*progressRefreshInterval+progressRefreshInterval/2)
+		defer cancel()
+		progressPrinter(ctx, make(chan progressData), names, 0)
+	})
+	if refreshes := strings.Count(out, "\r"); refreshes < 3 {
+		t.Errorf("expected ticker refreshes before the cancellation, got %d lines in %q", refreshes, out)
+	}
+	if !strings.HasSuffix(out, "\n") || !strings.Contains(out, "A   0.0%") {
+		t.Errorf("expected 0%% lines ending with a newline, got %q", out)
+	}
+
+	// An empty task list must not panic.
+	out = captureStdout(t, func() {
+		progress := make(chan progressData, 1)
+		progress <- progressData{name: "A", pct: 10}
+		close(progress)
+		progressPrinter(context.Background(), progress, nil, 10)
+	})
+	if !strings.HasSuffix(out, "\n") {
+		t.Errorf("expected a final newline with no tasks, got %q", out)
+	}
+}
+
+// TestProgressLogger feeds a simulated sequence of updates, 1s apart, to the
+// plain-text renderer with a 5s interval: each task logs its first update at
+// once, then at most one line per interval, the flush writing an update held
+// back for lack of a newer one.
+func TestProgressLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := newProgressLines(&buf, []string{"Fast Doubling", "Matrix"}, 5*time.Second)
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(s int) time.Time { return start.Add(time.Duration(s) * time.Second) }
+
+	for s := 0; s <= 7; s++ {
+		l.update(progressData{name: "Fast Doubling", pct: float64(10 * s)}, at(s))
+		switch s {
+		case 1:
+			l.update(progressData{name: "Matrix", pct: 2.5}, at(s))
+		case 3:
+			l.update(progressData{name: "Matrix", pct: 4, rep: 2, reps: 3}, at(s))
+			l.update(progressData{name: "Unknown", pct: 50}, at(s))
+		case 4:
+			l.flush(at(s)) // Too early for Matrix, logged at 1s
+		case 6:
+			l.flush(at(s)) // Matrix is due; Fast Doubling, logged at 5s, is not
+		}
+	}
+	l.flush(at(12)) // Fast Doubling's 70% is due; Matrix has nothing new
+
+	want := "[12:00:00] Fast Doubling 0.0%\n" +
+		"[12:00:01] Matrix 2.5%\n" +
+		"[12:00:05] Fast Doubling 50.0%\n" +
+		"[12:00:06] Matrix (2/3) 4.0%\n" +
+		"[12:00:12] Fast Doubling 70.0%\n"
+	if got := buf.String(); got != want {
+		t.Errorf("expected the lines\n%s\ngot\n%s", want, got)
+	}
+
+	// progressLogger writes through the same renderer and returns once the
+	// channel is closed.
+	buf.Reset()
+	progress := make(chan progressData, 2)
+	progress <- progressData{name: "A", pct: 50}
+	progress <- progressData{name: "A", pct: 75} // Coalesced with the first
+	close(progress)
+	progressLogger(context.Background(), progress, []string{"A"}, &buf, time.Hour)
+	if got := buf.String(); !strings.HasSuffix(got, "] A 50.0%\n") || strings.Count(got, "\n") != 1 {
+		t.Errorf("expected a single timestamped line for A, got %q", got)
+	}
+}
+
+// TestProgressEvents runs progressEvents on the write end of a pipe, as a
+// parent process would pass it with -progress-fd, and parses the JSON events
+// read from the other end.
+func TestProgressEvents(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	progress := make(chan progressData, 3)
+	progress <- progressData{name: "Fast Doubling", pct: 42.54}
+	progress <- progressData{name: "Matrix", pct: 10, rep: 2, reps: 3}
+	progress <- progressData{name: "Fast Doubling", pct: 100}
+	close(progress)
+	progressEvents(context.Background(), progress, w)
+	w.Close()
+
+	want := []progressEvent{
+		{Task: "Fast Doubling", Pct: 42.5},
+		{Task: "Matrix", Pct: 10, Rep: 2, Reps: 3},
+		{Task: "Fast Doubling", Pct: 100},
+	}
+	dec := json.NewDecoder(r)
+	for i, event := range want {
+		var got progressEvent
+		if err := dec.Decode(&got); err != nil {
+			t.Fatalf("event %d: %v", i+1, err)
+		}
+		if got != event {
+			t.Errorf("event %d: expected %+v, got %+v", i+1, event, got)
+		}
+	}
+	if dec.More() {
+		t.Error("unexpected events after the last update")
+	}
+
+	// A write failure drains the remaining updates without blocking.
+	progress = make(chan progressData, 2)
+	progress <- progressData{name: "A", pct: 1}
+	progress <- progressData{name: "A", pct: 2}
+	close(progress)
+	progressEvents(context.Background(), progress, w) // Closed: writes fail
+	if len(progress) != 0 {
+		t.Error("expected the updates to be drained after a write failure")
+	}
+}
+
+// TestFormatBytes checks the human-readable rendering of byte counts.
+func TestFormatBytes(t *testing.T) {
+	testCases := []struct {
+		in   uint64
+		want string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KiB"},
+		{1536, "1.5 KiB"},
+		{5 << 20, "5.0 MiB"},
+		{3 << 30, "3.0 GiB"},
+		{2048 << 30, "2048.0 GiB"},
+	}
+	for _, tc := range testCases {
+		if got := formatBytes(tc.in); got != tc.want {
+			t.Errorf("formatBytes(%d) = %q, expected %q", tc.in, got, tc.want)
+		}
+	}
+}
+
 // TestFibonacciConsistencyForLargeN is removed as there are no other algorithms to compare against.
 // If needed, specific large value tests for Fast Doubling can be added to TestFibFastDoublingAlgorithm.
 // The helper function min(a,b) was part of TestFibonacciConsistencyForLargeN and is now removed.
@@ -87,8 +1268,155 @@ func BenchmarkFibFastDoubling(b *testing.B) {
 
 	for i := 0; i < b.N; i++ {
 		// The result is not verified here; focus is on performance.
-		_, _ = fibFastDoubling(ctx, nil, benchmarkN, pool)
+		_, _ = fibFastDoubling(ctx, benchmarkN, pool)
+	}
+}
+
+// BenchmarkFibFastDoublingInto measures the in-place version, reusing the
+// same destination across iterations. Compare its allocations with
+// BenchmarkFibFastDoubling, which allocates a new result on every call.
+func BenchmarkFibFastDoublingInto(b *testing.B) {
+	pool := newIntPool()
+	ctx := context.Background()
+	dst := new(big.Int)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_ = fibFastDoublingInto(ctx, dst, benchmarkN, pool)
 	}
 }
 
+// countingPool returns a *big.Int pool counting its allocations in news.
+// A pool miss (an object not found in the pool) shows up as an allocation.
+func countingPool(news *atomic.Int64) *sync.Pool {
+	return &sync.Pool{
+		New: func() interface{} {
+			news.Add(1)
+			return new(big.Int)
+		},
+	}
+}
+
+// benchmarkPoolMisses runs Fast Doubling with its own pool and reports the
+// number of pool misses per operation. When alongside is set, Lucas runs
+// continuously in the background with another pool, as with `-algorithms all`.
+func benchmarkPoolMisses(b *testing.B, alongside bool) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	if alongside {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pool := newIntPool()
+			for ctx.Err() == nil {
+				_, _ = fibLucas(ctx, benchmarkN, pool)
+			}
+		}()
+	}
+
+	var news atomic.Int64
+	pool := countingPool(&news)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = fibFastDoubling(context.Background(), benchmarkN, pool)
+	}
+	b.StopTimer()
+	b.ReportMetric(float64(news.Load())/float64(b.N), "pool-misses/op")
+
+	cancel()
+	wg.Wait()
+}
+
+// BenchmarkFibFastDoublingOwnPoolAlone and BenchmarkFibFastDoublingOwnPoolAlongside
+// show that, with one pool per task, the pool misses of Fast Doubling do not
+// depend on the algorithms running alongside it.
+func BenchmarkFibFastDoublingOwnPoolAlone(b *testing.B) {
+	benchmarkPoolMisses(b, false)
+}
+
+func BenchmarkFibFastDoublingOwnPoolAlongside(b *testing.B) {
+	benchmarkPoolMisses(b, true)
+}
+
 // Other benchmarks (BenchmarkFibMatrix, BenchmarkFibBinet, BenchmarkFibIterative) are removed.
+
+// TestScientificNotation checks the mantissa length for several -sci-digits
+// values against the notation of the exact value, and the order of magnitude
+// printed for k = 0.
+func TestScientificNotation(t *testing.T) {
+	v, err := fib.FastDoubling(context.Background(), 1000) // 209 digits
+	if err != nil {
+		t.Fatal(err)
+	}
+	exact := new(big.Float).SetPrec(uint(v.BitLen() + 10)).SetInt(v)
+	for _, k := range []int{1, 3, 8, 40} {
+		got := scientificNotation(v, k)
+		mantissa, exponent, ok := strings.Cut(got, "e")
+		if !ok || len(mantissa) != k+2 || exponent != "+208" {
+			t.Errorf("k=%d: expected a mantissa of %d characters and exponent +208, got %s", k, k+2, got)
+		}
+		if want := exact.Text('e', k); got != want {
+			t.Errorf("k=%d: expected %s, got %s", k, want, got)
+		}
+	}
+	if got := scientificNotation(v, 0); got != "10^208" {
+		t.Errorf("expected the order of magnitude 10^208, got %s", got)
+	}
+	if got := scientificNotation(new(big.Int).Neg(v), 0); got != "-10^208" {
+		t.Errorf("expected -10^208, got %s", got)
+	}
+}
+
+// TestQuiet checks that with -quiet stdout only holds the details of the
+// result, with neither the table nor the validation message, and that the
+// informational logs are dropped but not the warnings.
+func TestQuiet(t *testing.T) {
+	cfg, err := parseConfig([]string{"-n", "100", "-quiet"}, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f100, _ := new(big.Int).SetString("354224848179261915075", 10)
+	results := []Result{
+		{Name: "Fast Doubling", symbol: "F", Value: f100, Duration: time.Millisecond},
+		{Name: "Matrix", symbol: "F", Value: f100, Duration: 2 * time.Millisecond},
+	}
+	out := captureStdout(t, func() { collectAndDisplayResults(context.Background(), results, cfg) })
+	want := "Number of digits in F(100): 21\n" +
+		"Bit length: 69 (9 bytes), 0.993890 × n·log2(φ)\n" +
+		"Fingerprint: mod 1e9+7 = 687995182, sha256 = bfbe9185a2c991f4\n" +
+		"Value (scientific notation) ≈ 3.54224848e+20\n"
+	if out != want {
+		t.Errorf("expected only the details of F(100), got:\n%s", out)
+	}
+
+	var buf bytes.Buffer
+	logger := newLogger(&buf, cfg.logFormat, logLevel(cfg))
+	logger.Info("launching calculations")
+	logger.Warn("task interrupted by the global timeout")
+	if logs := buf.String(); strings.Contains(logs, "launching") || !strings.Contains(logs, "interrupted") {
+		t.Errorf("expected only the warning in the logs, got:\n%s", logs)
+	}
+}
+
+// TestTiming checks that the details of a result record both phases, the
+// calculation and the conversion of the value to text, and print them.
+func TestTiming(t *testing.T) {
+	cfg, err := parseConfig([]string{"-n", "100000"}, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	v, err := fib.FastDoubling(context.Background(), cfg.n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := []Result{{Name: "Fast Doubling", symbol: "F", Value: v, Duration: time.Since(start)}}
+	out := captureStdout(t, func() { results = collectAndDisplayResults(context.Background(), results, cfg) })
+	if results[0].Duration <= 0 || results[0].stringify <= 0 {
+		t.Errorf("expected both phases to be recorded, got compute %v and stringify %v", results[0].Duration, results[0].stringify)
+	}
+	if !strings.Contains(out, "Timing: compute ") || !strings.Contains(out, ", stringify ") {
+		t.Errorf("expected the timing breakdown, got:\n%s", out)
+	}
+}
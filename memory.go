@@ -0,0 +1,333 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"fmt"
+	"math"
+	"math/bits"
+	"os"
+	"runtime/metrics"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/agbruneau/FibJule/fib"
+)
+
+// ------------------------------------------------------------
+// Peak Memory Tracking
+// ------------------------------------------------------------
+//
+// Concept:
+// The memory cost of an algorithm is its peak heap usage, which a single
+// before/after measurement misses: the temporaries are gone by the time the
+// algorithm returns. A sampler goroutine therefore polls the heap size during
+// the task and keeps the maximum. It reads `runtime/metrics`, which, unlike
+// `runtime.ReadMemStats`, does not stop the world on each poll.
+//
+// The heap is shared by the whole process: when algorithms run concurrently,
+// each measurement also includes the memory of the others. Run them with
+// `-sequential` for an accurate attribution.
+
+// memorySampleInterval is the delay between two heap samples.
+const memorySampleInterval = 2 * time.Millisecond
+
+// heapMetric is the runtime/metrics name of the bytes occupied by heap objects.
+const heapMetric = "/memory/classes/heap/objects:bytes"
+
+// heapObjectsBytes returns the current size of the heap objects, live or not
+// yet swept.
+func heapObjectsBytes() uint64 {
+	sample := []metrics.Sample{{Name: heapMetric}}
+	metrics.Read(sample)
+	if sample[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return sample[0].Value.Uint64()
+}
+
+// memoryTracker records the peak heap size while a task runs.
+type memoryTracker struct {
+	baseline uint64
+	peak     atomic.Uint64
+	stop     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// startMemoryTracker takes a baseline and starts sampling the heap.
+func startMemoryTracker() *memoryTracker {
+	t := &memoryTracker{baseline: heapObjectsBytes(), stop: make(chan struct{})}
+	t.peak.Store(t.baseline)
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		ticker := time.NewTicker(memorySampleInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-t.stop:
+				return
+			case <-ticker.C:
+				t.sample()
+			}
+		}
+	}()
+	return t
+}
+
+// sample updates the peak with the current heap size.
+func (t *memoryTracker) sample() {
+	current := heapObjectsBytes()
+	for {
+		peak := t.peak.Load()
+		if current <= peak || t.peak.CompareAndSwap(peak, current) {
+			return
+		}
+	}
+}
+
+// Stop ends the sampling and returns the peak heap growth over the baseline.
+func (t *memoryTracker) Stop() uint64 {
+	t.sample() // Catch tasks shorter than one sampling interval
+	close(t.stop)
+	t.wg.Wait()
+	return t.peak.Load() - t.baseline
+}
+
+// ------------------------------------------------------------
+// Memory Budget (-max-memory)
+// ------------------------------------------------------------
+//
+// Concept:
+// F(n) takes about n·log2(φ)/8 bytes, 87 GB for a mistyped
+// `-n 1000000000000`: the run would swap, then be killed by the OS long after
+// the typo. The size of the result is known in advance, and so is the peak
+// memory of each algorithm computing it: a calculation whose tasks, summed
+// over those running at once, would exceed the budget is refused before it
+// starts, with the estimate in the error. The budget defaults to half of the
+// physical memory.
+
+// workingSetFactor is the ratio of the peak heap of a calculation to the size
+// of its result. Fast Doubling and Lucas peak at 7 to 8 times the size of
+// F(n), with their temporaries and the buffers of the multiplications (see
+// the Peak Mem column).
+const workingSetFactor = 8
+
+// tribonacciBitsPerIndex bounds the growth of T(n) in bits per index:
+// log2(ψ), ψ ≈ 1.839 being the tribonacci constant.
+const tribonacciBitsPerIndex = 0.8792
+
+// systemMemory returns the physical memory of the machine in bytes, read from
+// /proc/meminfo, or 0 when it is unknown, e.g. on other systems than Linux.
+func systemMemory() uint64 {
+	f, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// The line reads "MemTotal:       16318712 kB".
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 3 && fields[0] == "MemTotal:" && fields[2] == "kB" {
+			kb, err := strconv.ParseUint(fields[1], 10, 64)
+			if err != nil {
+				return 0
+			}
+			return kb << 10
+		}
+	}
+	return 0
+}
+
+// memoryBudget returns the budget in bytes set by the `-max-memory` value
+// spec: half of the physical memory when spec is empty, and 0, which disables
+// the check, when it is "0" or the physical memory is unknown.
+func memoryBudget(spec string) (uint64, error) {
+	if spec == "" {
+		return systemMemory() / 2, nil
+	}
+	return parseByteSize(spec)
+}
+
+// termBytes estimates the size in bytes of the value computed for the index
+// n with the options of cfg, or returns 0 in modular mode, whose values stay
+// below the modulus. The sum F(n+2)−1 is at most one byte larger than F(n),
+// the sum of squares F(n)·F(n+1) twice its size, a term of the sequence
+// seeded by a,b larger by the size of a or b, and a term of `-recurrence`
+// has its own growth rate (see fib.EstimateRecurrenceBytes).
+func termBytes(cfg config, n int) uint64 {
+	if cfg.mod > 0 {
+		return 0
+	}
+	size := fib.EstimateBytes(n)
+	switch {
+	case cfg.sum:
+		size++
+	case cfg.sumSquares:
+		size *= 2
+	case cfg.seed != "":
+		a, b, _ := parseSeed(cfg.seed) // Validated by parseConfig
+		size += uint64(max(a.BitLen(), b.BitLen())+7)/8 + 1
+	case cfg.recurrence != "":
+		coeffs, init, _ := parseRecurrence(cfg.recurrence) // Validated by parseConfig
+		size = fib.EstimateRecurrenceBytes(n, coeffs, init)
+	}
+	return size
+}
+
+// taskBytes estimates the peak memory in bytes of the task t computing its
+// value for the index n with the options of cfg: workingSetFactor times the
+// size of the value, except for memoization, which keeps every F(k) up to
+// |n|, about |n|/2 times the size of F(n) (0.347·n² bits), and for T(n),
+// which takes 0.879·n bits. It returns 0 in modular mode.
+func taskBytes(cfg config, t task, n int) uint64 {
+	if cfg.mod > 0 {
+		return 0
+	}
+	size := termBytes(cfg, n)
+	switch {
+	case t.name == "Memoized":
+		return addBytes(mulBytes(size, uint64(absIndex(n))/2+1), mulBytes(workingSetFactor, size))
+	case t.symbol == "T":
+		size = uint64(float64(absIndex(n))*tribonacciBitsPerIndex/8) + 1
+	}
+	return mulBytes(workingSetFactor, size)
+}
+
+// plannedTasks returns the tasks a run of cfg computes for the index n,
+// resolved as by resolveTasks but without logging the choice of "auto". It
+// returns nil for an invalid `-algorithms` value, which the run reports.
+func plannedTasks(cfg config, n int) []task {
+	if t, ok := quantityTask(cfg, nil); ok {
+		return []task{t}
+	}
+	if strings.EqualFold(strings.TrimSpace(cfg.algorithms), "auto") {
+		t, _ := autoTask(n, nil)
+		return []task{t}
+	}
+	available, defaultOrder := registeredTasks(nil)
+	tasks, _ := selectTasks(cfg.algorithms, available, defaultOrder)
+	return tasks
+}
+
+// indexBytes estimates the peak memory in bytes of a run of cfg computing
+// the index n with every selected algorithm.
+func indexBytes(cfg config, n int) uint64 {
+	return tasksBytes(cfg, plannedTasks(cfg, n), n)
+}
+
+// tasksBytes estimates the peak memory in bytes of tasks computing the index
+// n, scheduled as by the `-sequential` and `-concurrency` of cfg.
+func tasksBytes(cfg config, tasks []task, n int) uint64 {
+	var sizes []uint64
+	for _, t := range tasks {
+		sizes = append(sizes, taskBytes(cfg, t, n))
+	}
+	return concurrentBytes(cfg, sizes)
+}
+
+// concurrentBytes returns the peak memory of tasks peaking at sizes bytes
+// each: the largest size with `-sequential`, or else the sum of the largest
+// sizes, as many as `-concurrency` lets run at once.
+func concurrentBytes(cfg config, sizes []uint64) uint64 {
+	k := 1
+	if !cfg.sequential {
+		k = concurrencyLimit(cfg.concurrency)
+	}
+	slices.SortFunc(sizes, func(a, b uint64) int { return cmp.Compare(b, a) })
+	var total uint64
+	for _, size := range sizes[:min(k, len(sizes))] {
+		total = addBytes(total, size)
+	}
+	return total
+}
+
+// plannedBytes estimates the peak memory in bytes of a run of cfg: that of
+// F(n), of the largest term of a batch, of the indices of `-n-list` running
+// at once, of the last term of a range or of a benchmark sweep, whose
+// algorithms run one after the other. It returns 0 for the modes that never
+// compute a full term of index n, -word streaming its output, and for those
+// whose indices are only known later (-stdin checks each of them, see
+// runBatch, and -serve each request, see handleRemote).
+func plannedBytes(cfg config) uint64 {
+	switch {
+	case cfg.estimateDigits, cfg.head > 0, cfg.tail > 0, cfg.isFib != "", cfg.zeckendorf != "", cfg.indexOf != "",
+		cfg.crt != "" && !cfg.crtReconstruct, cfg.selftest, cfg.stdin, cfg.serve != "", cfg.word >= 0:
+		return 0
+	case cfg.batch != "":
+		ns, _ := parseIndices("batch", cfg.batch) // Validated by parseConfig
+		return mulBytes(workingSetFactor, largestTermBytes(cfg, ns))
+	case cfg.nList != "":
+		ns, _ := parseIndices("n-list", cfg.nList) // Validated by parseConfig
+		var sizes []uint64
+		seen := make(map[int]bool)
+		for _, n := range ns {
+			if seen[n] {
+				continue // Duplicates are computed once
+			}
+			seen[n] = true
+			for _, t := range plannedTasks(cfg, n) {
+				sizes = append(sizes, taskBytes(cfg, t, n))
+			}
+		}
+		return concurrentBytes(cfg, sizes)
+	case cfg.rangeSpec != "":
+		_, b, _ := parseRange(cfg.rangeSpec) // Validated by parseConfig
+		return mulBytes(workingSetFactor, termBytes(cfg, b))
+	case cfg.crtReconstruct:
+		return mulBytes(workingSetFactor, termBytes(cfg, cfg.n))
+	case cfg.benchmark != "":
+		ns, _ := parseSweep(cfg.benchmark) // Validated by parseConfig
+		sequential := cfg
+		sequential.sequential = true
+		return indexBytes(sequential, ns[len(ns)-1])
+	}
+	// -warmup runs the algorithms one after the other before the
+	// calculation, and -check-gcd computes F(m) by Fast Doubling after it.
+	warmup := cfg
+	warmup.sequential = true
+	return max(indexBytes(cfg, cfg.n), indexBytes(warmup, cfg.warmup), mulBytes(workingSetFactor, termBytes(cfg, cfg.checkGCD)))
+}
+
+// largestTermBytes returns the largest termBytes among the indices ns.
+func largestTermBytes(cfg config, ns []int) uint64 {
+	var size uint64
+	for _, n := range ns {
+		size = max(size, termBytes(cfg, n))
+	}
+	return size
+}
+
+// addBytes returns a+b, saturating at math.MaxUint64.
+func addBytes(a, b uint64) uint64 {
+	sum, carry := bits.Add64(a, b, 0)
+	if carry != 0 {
+		return math.MaxUint64
+	}
+	return sum
+}
+
+// mulBytes returns a·b, saturating at math.MaxUint64.
+func mulBytes(a, b uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	if hi != 0 {
+		return math.MaxUint64
+	}
+	return lo
+}
+
+// checkMemoryBudget returns an error when a calculation peaking at need
+// bytes exceeds budget. A budget of 0 disables the check.
+func checkMemoryBudget(need, budget uint64) error {
+	if budget == 0 || need <= budget {
+		return nil
+	}
+	return fmt.Errorf("the calculation needs about %s of memory, more than the -max-memory budget of %s: raise -max-memory, or set it to 0 to disable the check",
+		formatBytes(need), formatBytes(budget))
+}
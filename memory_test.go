@@ -0,0 +1,122 @@
+// memory_test.go
+
+package main
+
+import (
+	"io"
+	"math"
+	"math/big"
+	"runtime"
+	"testing"
+
+	"github.com/agbruneau/FibJule/fib"
+)
+
+// TestMemoryTracker checks that an allocation made while tracking shows up in
+// the reported peak, even after it became garbage.
+func TestMemoryTracker(t *testing.T) {
+	const size = 64 << 20 // 64 MiB, far above the background noise of the test binary
+
+	runtime.GC()
+	tracker := startMemoryTracker()
+	buf := make([]byte, size)
+	for i := range buf {
+		buf[i] = byte(i) // Touch the memory so the allocation is not optimized away
+	}
+	tracker.sample()
+	runtime.KeepAlive(buf)
+	peak := tracker.Stop()
+
+	// Allow some slack: objects from the baseline may be swept in the meantime.
+	if peak < size*3/4 {
+		t.Errorf("expected a peak close to %s, but got %s", formatBytes(size), formatBytes(peak))
+	}
+}
+
+// TestParseByteSize checks the accepted units and the rejected sizes.
+func TestParseByteSize(t *testing.T) {
+	for in, want := range map[string]uint64{
+		"0":           0,
+		"4096":        4096,
+		"512B":        512,
+		"3KiB":        3 << 10,
+		"512MiB":      512 << 20,
+		"8 GiB":       8 << 30,
+		"2tib":        2 << 40,
+		"16777215TiB": 16777215 << 40,
+	} {
+		if got, err := parseByteSize(in); err != nil || got != want {
+			t.Errorf("parseByteSize(%q) = %d, %v, expected %d", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "GiB", "-1", "1.5GiB", "8GB", "16777216TiB"} {
+		if _, err := parseByteSize(in); err == nil {
+			t.Errorf("expected an error for %q", in)
+		}
+	}
+}
+
+// TestMemoryBudget checks the estimated peak memory of the planned
+// calculations and the refusal of those exceeding the budget, which the modes
+// computing no full term, modular mode and a budget of 0 never trigger.
+func TestMemoryBudget(t *testing.T) {
+	const n = 1000000000000 // A mistyped -n, about 80 GiB for F(n)
+	size := fib.EstimateBytes(n)
+	need := workingSetFactor * size
+	for _, c := range []struct {
+		args []string
+		want uint64
+	}{
+		{[]string{"-n", "1000000000000"}, need},
+		{[]string{"-n", "1000000000000", "-sum"}, need + workingSetFactor},
+		{[]string{"-n", "1000000000000", "-sum-squares"}, 2 * need},
+		{[]string{"-n", "1000000000000", "-seed", "2,1"}, need + 2*workingSetFactor},
+		{[]string{"-n", "1000000000000", "-recurrence", "1,1"}, workingSetFactor * fib.EstimateRecurrenceBytes(n, []*big.Int{big.NewInt(1), big.NewInt(1)}, []*big.Int{big.NewInt(0), big.NewInt(1)})},
+		{[]string{"-range", "0:1000000000000"}, need},
+		{[]string{"-benchmark", "1000:1000000000000:1000x"}, need},
+		{[]string{"-n", "1000000000000", "-mod", "7"}, 0},
+		{[]string{"-n", "1000000000000", "-estimate-digits"}, 0},
+		{[]string{"-n", "1000000000000", "-tail", "5"}, 0},
+		{[]string{"-n", "1000000000000", "-crt", "7"}, 0},
+		{[]string{"-n", "1000000000000", "-crt", "7", "-crt-reconstruct"}, need},
+		{[]string{"-n", "1000000000000", "-stdin", "-format", "json"}, 0},
+		// The selected algorithms running at once add up, unless -sequential
+		// or -concurrency runs them one after the other.
+		{[]string{"-n", "1000000000000", "-algorithms", "fast,matrix", "-concurrency", "2"}, 2 * need},
+		{[]string{"-n", "1000000000000", "-algorithms", "fast,matrix", "-sequential"}, need},
+		{[]string{"-n", "1000000000000", "-algorithms", "fast,matrix", "-concurrency", "1"}, need},
+		{[]string{"-n-list", "1000,1000000000000,1000000000000", "-concurrency", "1"}, need},
+		{[]string{"-n", "1000000000000", "-warmup", "1000", "-algorithms", "fast,matrix", "-concurrency", "2"}, 2 * need},
+		// Memoization keeps every F(k): n/2 times F(n), quadratic in n.
+		{[]string{"-n", "300000", "-algorithms", "memo"}, fib.EstimateBytes(300000)*150001 + workingSetFactor*fib.EstimateBytes(300000)},
+		{[]string{"-n", "1000000000000", "-algorithms", "memo"}, math.MaxUint64},
+		// T(n) grows faster than F(n): 0.879·n bits.
+		{[]string{"-n", "1000000000000", "-algorithms", "tribonacci"}, workingSetFactor * (uint64(n*tribonacciBitsPerIndex/8) + 1)},
+	} {
+		cfg, err := parseConfig(append(c.args, "-max-memory", "0"), io.Discard)
+		if err != nil {
+			t.Fatalf("%v: unexpected error: %v", c.args, err)
+		}
+		if got := plannedBytes(cfg); got != c.want {
+			t.Errorf("%v: expected %d bytes, got %d", c.args, c.want, got)
+		}
+	}
+
+	// On 6 GB, memoizing F(300000) is refused, Fast Doubling is not.
+	for algorithms, wantErr := range map[string]bool{"fast": false, "memo": true, "all": true} {
+		_, err := parseConfig([]string{"-n", "300000", "-algorithms", algorithms, "-max-memory", "3GiB"}, io.Discard)
+		if (err != nil) != wantErr {
+			t.Errorf("-algorithms %s: expected an error: %v, got %v", algorithms, wantErr, err)
+		}
+	}
+
+	if err := checkMemoryBudget(need, 1<<30); err == nil {
+		t.Error("expected F(10^12) to exceed a budget of 1 GiB")
+	}
+	if err := checkMemoryBudget(need, need); err != nil {
+		t.Errorf("expected a budget of exactly the working set to be enough: %v", err)
+	}
+	if err := checkMemoryBudget(need, 0); err != nil {
+		t.Errorf("expected a budget of 0 to disable the check: %v", err)
+	}
+}
@@ -0,0 +1,198 @@
+// nlist.go
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// ------------------------------------------------------------
+// Independent Indices (-n-list)
+// ------------------------------------------------------------
+//
+// Concept:
+// Unlike `-batch`, which shares the doubling steps of its indices, `-n-list`
+// computes each listed index as a separate task of a single algorithm, with
+// its own pool, progress and result. The tasks go through runTasks like the
+// algorithms of a single n, so `-concurrency`, `-sequential`, `-repeat` and
+// `-per-algo-timeout` apply to the indices as they do to the algorithms.
+
+// indexTask is the task computing one index of `-n-list`.
+type indexTask struct {
+	task
+	n         int
+	algorithm string // Name of the algorithm; task.name names the term, e.g. "F(1000)"
+}
+
+// indexResult is the result of one index of `-n-list`.
+type indexResult struct {
+	Result
+	n         int
+	algorithm string
+}
+
+// renamedReporter forwards the progress of an algorithm under the name of
+// the index task running it, which tells the indices apart in the display.
+type renamedReporter struct {
+	next ProgressReporter
+	name string
+}
+
+// Report forwards the update under r.name.
+func (r renamedReporter) Report(_ string, pct float64) {
+	r.next.Report(r.name, pct)
+}
+
+// indexTasks returns one task per distinct index of ns, in the order of the
+// list, running the algorithm cfg selects for it (`-algorithms auto` picks
+// one per index). Each task is named after its term and computes its own
+// index once runTasks is given the indices of the tasks.
+func indexTasks(cfg config, ns []int) ([]indexTask, error) {
+	m, opts := algorithmOptions(cfg)
+	var tasks []indexTask
+	seen := make(map[int]bool)
+	for _, n := range ns {
+		if seen[n] {
+			continue
+		}
+		seen[n] = true
+
+		var selected []task
+		if t, ok := quantityTask(cfg, m, opts...); ok {
+			selected = []task{t}
+		} else {
+			var err error
+			if selected, err = resolveTasks(cfg.algorithms, n, m, opts...); err != nil {
+				return nil, err
+			}
+		}
+		if len(selected) != 1 {
+			return nil, fmt.Errorf("-n-list runs a single algorithm per index: select one with -algorithms, or 'auto'")
+		}
+		t := selected[0]
+		name := fmt.Sprintf("%s(%d)", t.symbol, n)
+		fn := t.fn
+		tasks = append(tasks, indexTask{
+			task: task{name: name, symbol: t.symbol, fn: func(ctx context.Context, n int, pool *sync.Pool) (*big.Int, error) {
+				if r, ok := progressReporter(ctx); ok {
+					ctx = WithProgressReporter(ctx, renamedReporter{next: r, name: name})
+				}
+				return fn(ctx, n, pool)
+			}},
+			n:         n,
+			algorithm: t.name,
+		})
+	}
+	return tasks, nil
+}
+
+// computeIndices runs the index tasks through runTasks, each with a fresh
+// pool, and returns their results in the order of the tasks.
+func computeIndices(ctx context.Context, cfg config, tasks []indexTask) []indexResult {
+	plain := make([]task, len(tasks))
+	indices := make([]int, len(tasks))
+	for i, t := range tasks {
+		plain[i], indices[i] = t.task, t.n
+	}
+
+	resultsCh := make(chan Result, len(tasks))
+	runTasks(ctx, plain, 0, resultsCh, runOptions{
+		sequential:  cfg.sequential,
+		taskTimeout: cfg.perAlgoTimeout,
+		repeat:      cfg.repeat,
+		warmup:      cfg.warmup,
+		gcBetween:   cfg.gcBetween,
+		countOps:    cfg.countOps,
+		concurrency: concurrencyLimit(cfg.concurrency),
+		sizedPools:  cfg.mod == 0,
+		indices:     indices,
+	})
+	close(resultsCh)
+
+	byName := make(map[string]Result, len(tasks))
+	for r := range resultsCh {
+		byName[r.Name] = r
+	}
+	results := make([]indexResult, len(tasks))
+	for i, t := range tasks {
+		results[i] = indexResult{Result: byName[t.name], n: t.n, algorithm: t.algorithm}
+	}
+	return results
+}
+
+// writeIndicesTable writes the results of `-n-list` to w, one row per index
+// in the order of the list, like writeResultsTable with the index first.
+func writeIndicesTable(w io.Writer, results []indexResult, cfg config) error {
+	header := []string{"n", "Algorithm", "Duration", "Status", "Peak Mem"}
+	if cfg.countOps {
+		header = append(header, "Ops")
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(append(header, "Result"), "\t"))
+	for _, r := range results {
+		valStr := "N/A"
+		if r.Err == nil && r.Value != nil {
+			if cfg.digitsOnly {
+				valStr = fmt.Sprintf("%d digits", decimalDigits(r.Value))
+			} else {
+				valStr = abbreviate(r.Value.Text(cfg.base))
+			}
+		}
+		row := []string{strconv.Itoa(r.n), r.algorithm, formatDuration(r.Result), resultStatus(r.Result), formatBytes(r.peakMem)}
+		if cfg.countOps {
+			row = append(row, formatOps(r.Result))
+		}
+		fmt.Fprintln(tw, strings.Join(append(row, valStr), "\t"))
+	}
+	return tw.Flush()
+}
+
+// runNListMode runs the `-n-list` mode: it computes each listed index as a
+// separate task, then prints their results as one table keyed by n. The
+// exit status is the highest among the indices (see exitStatus).
+func runNListMode(cfg config) int {
+	ns, _ := parseIndices("n-list", cfg.nList) // Validated by parseConfig
+	tasks, err := indexTasks(cfg, ns)
+	if err != nil {
+		fatal("invalid arguments", "error", err)
+	}
+	names := make([]string, len(tasks))
+	for i, t := range tasks {
+		names[i] = t.name
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.timeout)
+	defer cancel()
+	stopSignals := handleSignals(cancel)
+	defer stopSignals()
+
+	slog.Info("calculating indices", "indices", strings.Join(names, ","), "timeout", cfg.timeout)
+	calcCtx, stopProgress := startProgressDisplay(ctx, cfg, names)
+	start := time.Now()
+	results := computeIndices(calcCtx, cfg, tasks)
+	stopProgress()
+	slog.Info("calculations finished", "elapsed", time.Since(start).Round(time.Millisecond))
+
+	status := exitOK
+	for _, r := range results {
+		if r.Err != nil {
+			logTaskFailure(ctx, r.Result)
+		}
+		status = max(status, exitStatus([]Result{r.Result}, false))
+	}
+	// The table is the result itself: `-quiet` keeps it.
+	if err := writeIndicesTable(os.Stdout, results, cfg); err != nil {
+		fatal("failed to write the results", "error", err)
+	}
+	return status
+}
@@ -0,0 +1,40 @@
+// nlist_test.go
+
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+// TestNListMode runs `-n-list` with three indices, one of them repeated, on
+// a single slot and checks that the table has one row per distinct index,
+// in the order of the list, with the full value of each.
+func TestNListMode(t *testing.T) {
+	cfg, err := parseConfig([]string{"-n-list", "100,10,-7,10", "-concurrency", "1", "-progress", "never"}, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var status int
+	out := captureStdout(t, func() { status = runNListMode(cfg) })
+	if status != exitOK {
+		t.Fatalf("expected exit status %d, got %d:\n%s", exitOK, status, out)
+	}
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	want := []struct{ n, value string }{{"100", "35422...15075"}, {"10", "55"}, {"-7", "13"}}
+	if len(lines) != len(want)+1 || !strings.HasPrefix(lines[0], "n ") {
+		t.Fatalf("expected a header and %d rows, got:\n%s", len(want), out)
+	}
+	for i, w := range want {
+		fields := strings.Fields(lines[i+1])
+		if fields[0] != w.n || fields[len(fields)-1] != w.value || !strings.Contains(lines[i+1], "Fast Doubling") {
+			t.Errorf("row %d: expected F(%s) = %s by Fast Doubling, got %q", i+1, w.n, w.value, lines[i+1])
+		}
+	}
+
+	// Several algorithms per index are refused.
+	if _, err := indexTasks(config{algorithms: "fast,matrix"}, []int{1, 2}); err == nil {
+		t.Error("expected an error for two algorithms per index")
+	}
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"math/big"
+	"os"
+	"strconv"
+)
+
+// ------------------------------------------------------------
+// Machine-Readable Output
+// ------------------------------------------------------------
+
+// jsonMaxValueDigits is the largest number of decimal digits for which the
+// full value is included in the JSON report. Beyond it, only the digit count
+// is emitted to keep the document a manageable size.
+const jsonMaxValueDigits = 10000
+
+// jsonReport is the top-level object written by `-format json`.
+type jsonReport struct {
+	N         int          `json:"n"`
+	Mod       uint64       `json:"mod,omitempty"`
+	TimeoutNs int64        `json:"timeout_ns"`
+	Results   []jsonResult `json:"results"`
+}
+
+// jsonResult describes the outcome of a single algorithm.
+type jsonResult struct {
+	Name        string  `json:"name"`
+	DurationNs  int64   `json:"duration_ns"`               // Median duration with -repeat
+	Repeats     int     `json:"repeats,omitempty"`         // Number of executions with -repeat
+	MinDuration int64   `json:"min_duration_ns,omitempty"` // Fastest execution with -repeat
+	MaxDuration int64   `json:"max_duration_ns,omitempty"` // Slowest execution with -repeat
+	PeakMem     uint64  `json:"peak_mem_bytes"`
+	Error       *string `json:"error"`                  // null on success
+	Cached      bool    `json:"cached,omitempty"`       // Value loaded from the cache
+	TaskTimeout bool    `json:"task_timeout,omitempty"` // Exceeded -per-algo-timeout, not the global one
+	MulOps      int64   `json:"mul_ops,omitempty"`      // Multiplications counted with -count-ops
+	AddOps      int64   `json:"add_ops,omitempty"`      // Additions counted with -count-ops
+	Digits      int     `json:"digits,omitempty"`       // Number of decimal digits of the value
+	Value       string  `json:"value,omitempty"`        // Decimal value, omitted for huge numbers
+}
+
+// newJSONResult converts a calculation result into its JSON representation.
+// With digitsOnly, the value itself is left out.
+func newJSONResult(r Result, digitsOnly bool) jsonResult {
+	jr := jsonResult{
+		Name:        r.Name,
+		DurationNs:  r.Duration.Nanoseconds(),
+		PeakMem:     r.peakMem,
+		Cached:      r.cached,
+		TaskTimeout: r.taskTimeout,
+	}
+	if r.repeats > 1 && r.Err == nil {
+		jr.Repeats = r.repeats
+		jr.MinDuration, jr.MaxDuration = r.minDuration.Nanoseconds(), r.maxDuration.Nanoseconds()
+	}
+	if r.ops != nil {
+		jr.MulOps, jr.AddOps = r.ops.Mul, r.ops.Add
+	}
+	if r.Err != nil {
+		msg := r.Err.Error()
+		jr.Error = &msg
+	}
+	if r.Value != nil && digitsOnly {
+		jr.Digits = decimalDigits(r.Value)
+	} else if r.Value != nil {
+		jr.Digits, jr.Value = decimalSummary(r.Value)
+	}
+	return jr
+}
+
+// decimalSummary returns the decimal digit count of v and, when it has no more
+// than jsonMaxValueDigits digits, its decimal representation.
+func decimalSummary(v *big.Int) (int, string) {
+	text := v.Text(10)
+	digits := len(text)
+	if v.Sign() < 0 {
+		digits-- // Do not count the minus sign
+	}
+	if digits > jsonMaxValueDigits {
+		return digits, ""
+	}
+	return digits, text
+}
+
+// writeJSONReport writes a single JSON object describing the run to w.
+func writeJSONReport(w io.Writer, cfg config, results []Result) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(newJSONReport(cfg, results))
+}
+
+// newJSONReport builds the JSON object describing the results of cfg.n.
+func newJSONReport(cfg config, results []Result) jsonReport {
+	report := jsonReport{
+		N:         cfg.n,
+		Mod:       cfg.mod,
+		TimeoutNs: cfg.timeout.Nanoseconds(),
+		Results:   make([]jsonResult, 0, len(results)),
+	}
+	for _, r := range results {
+		report.Results = append(report.Results, newJSONResult(r, cfg.digitsOnly))
+	}
+	return report
+}
+
+// writeCSVReport writes a header row, then one line per algorithm with its
+// name, duration, status and digit count, to w. The digit count keeps the
+// report compact; with cfg.csvValues, a last column holds the full value in
+// cfg.base. The digits and value of failed algorithms are left empty.
+func writeCSVReport(w io.Writer, cfg config, results []Result) error {
+	cw := csv.NewWriter(w)
+	cw.Write(csvHeader(cfg))
+	for _, r := range results {
+		cw.Write(csvRecord(cfg, r))
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvHeader returns the header row of writeCSVReport.
+func csvHeader(cfg config) []string {
+	header := []string{"name", "duration_ns", "status", "digits"}
+	if cfg.csvValues {
+		header = append(header, "value")
+	}
+	return header
+}
+
+// csvRecord returns the line of writeCSVReport describing r.
+func csvRecord(cfg config, r Result) []string {
+	digits, value := "", ""
+	if r.Err == nil && r.Value != nil {
+		digits = strconv.Itoa(decimalDigits(r.Value))
+		if cfg.csvValues {
+			value = r.Value.Text(cfg.base)
+		}
+	}
+	record := []string{r.Name, strconv.FormatInt(r.Duration.Nanoseconds(), 10), resultStatus(r), digits}
+	if cfg.csvValues {
+		record = append(record, value)
+	}
+	return record
+}
+
+// writeValueReport writes the value of the first successful result, the
+// winning one of sorted results, in cfg.base followed by a newline, and
+// nothing else. Without a success, nothing is written.
+func writeValueReport(w io.Writer, cfg config, results []Result) error {
+	for _, r := range results {
+		if r.Err == nil && r.Value != nil {
+			bw := bufio.NewWriter(w)
+			if err := writeValueLine(bw, r.Value, cfg.base); err != nil {
+				return err
+			}
+			return bw.Flush()
+		}
+	}
+	return nil
+}
+
+// ------------------------------------------------------------
+// Full Value Export
+// ------------------------------------------------------------
+
+// writeResultFile writes the full representation of v in the given base,
+// followed by a newline, to the file at path (created or truncated). It
+// returns the number of bytes written.
+//
+// In base 10, the digits are streamed with writeDecimal so the full string
+// never sits in memory. Other bases use v.Text(base), which is linear-time
+// for power-of-two bases. Either way, a buffered writer avoids many small
+// system calls.
+func writeResultFile(path string, v *big.Int, base int) (int64, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	cw := &countingWriter{w: f}
+	w := bufio.NewWriterSize(cw, 1<<20)
+	err = writeValueLine(w, v, base)
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = f.Close()
+	}
+	return cw.n, err
+}
+
+// writeValueLine writes v in the given base followed by a newline.
+func writeValueLine(w *bufio.Writer, v *big.Int, base int) error {
+	var err error
+	if base == 10 {
+		err = writeDecimal(w, v)
+	} else {
+		_, err = w.WriteString(v.Text(base))
+	}
+	if err != nil {
+		return err
+	}
+	return w.WriteByte('\n')
+}
+
+// countingWriter counts the bytes successfully written to w.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// saveResultToFile writes v to path and reports the outcome through the log.
+func saveResultToFile(path string, v *big.Int, base int) {
+	written, err := writeResultFile(path, v, base)
+	if err != nil {
+		slog.Error("failed to write the result", "path", path, "error", err)
+		return
+	}
+	slog.Info("full value written", "path", path, "bytes", written)
+}
+
+// ------------------------------------------------------------
+// Raw Binary Export
+// ------------------------------------------------------------
+//
+// Concept:
+// With `-format raw`, the value is written as the bytes of its binary
+// representation rather than as digits: one sign byte, 0 for a non-negative
+// value and 1 for a negative one (negafibonacci), then the magnitude |v| in
+// big-endian order, as returned by v.Bytes(), with no leading zero byte and
+// no byte at all for 0. It is about 2.4 times smaller than the decimal
+// string and takes no base conversion to produce. A reader restores the
+// value with new(big.Int).SetBytes(data[1:]), negated if data[0] is 1.
+
+// Sign bytes of the raw format.
+const (
+	rawPositive byte = 0
+	rawNegative byte = 1
+)
+
+// writeRawFile writes v in the raw format to the file at path (created or
+// truncated). It returns the number of bytes written.
+func writeRawFile(path string, v *big.Int) (int64, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	// The bytes of v.Bytes(), filled in place after the sign byte.
+	data := make([]byte, 1+(v.BitLen()+7)/8)
+	data[0] = rawPositive
+	if v.Sign() < 0 {
+		data[0] = rawNegative
+	}
+	v.FillBytes(data[1:])
+	cw := &countingWriter{w: f}
+	_, err = cw.Write(data)
+	if err == nil {
+		err = f.Close()
+	}
+	return cw.n, err
+}
+
+// saveRawToFile writes v in the raw format to path and reports the outcome
+// through the log.
+func saveRawToFile(path string, v *big.Int) {
+	written, err := writeRawFile(path, v)
+	if err != nil {
+		slog.Error("failed to write the result", "path", path, "error", err)
+		return
+	}
+	slog.Info("raw value written", "path", path, "bytes", written)
+}
@@ -0,0 +1,190 @@
+// output_test.go
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestWriteJSONReport checks that the JSON report is well-formed and contains
+// the expected fields for both successful and failed results.
+func TestWriteJSONReport(t *testing.T) {
+	cfg := config{n: 100, timeout: time.Second, format: formatJSON}
+	huge := new(big.Int).Exp(big.NewInt(10), big.NewInt(jsonMaxValueDigits), nil) // jsonMaxValueDigits+1 digits
+	results := []Result{
+		{Name: "Fast Doubling", Value: big.NewInt(6765), Duration: 3 * time.Millisecond},
+		{Name: "Huge", Value: huge, Duration: time.Millisecond},
+		{Name: "Timed Out", Duration: time.Second, Err: context.DeadlineExceeded},
+	}
+
+	var buf bytes.Buffer
+	if err := writeJSONReport(&buf, cfg, results); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got struct {
+		N         int   `json:"n"`
+		TimeoutNs int64 `json:"timeout_ns"`
+		Results   []struct {
+			Name       string  `json:"name"`
+			DurationNs int64   `json:"duration_ns"`
+			Error      *string `json:"error"`
+			Digits     int     `json:"digits"`
+			Value      string  `json:"value"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("report is not valid JSON: %v\n%s", err, buf.String())
+	}
+
+	if got.N != 100 || got.TimeoutNs != time.Second.Nanoseconds() || len(got.Results) != 3 {
+		t.Fatalf("unexpected report header: %+v", got)
+	}
+	ok := got.Results[0]
+	if ok.Error != nil || ok.Value != "6765" || ok.Digits != 4 || ok.DurationNs != (3*time.Millisecond).Nanoseconds() {
+		t.Errorf("unexpected successful result: %+v", ok)
+	}
+	if h := got.Results[1]; h.Value != "" || h.Digits != jsonMaxValueDigits+1 {
+		t.Errorf("expected only the digit count for a huge value, got digits=%d value length=%d", h.Digits, len(h.Value))
+	}
+	if failed := got.Results[2]; failed.Error == nil || !strings.Contains(*failed.Error, "deadline") {
+		t.Errorf("expected a deadline error, got %+v", failed)
+	}
+	if !strings.Contains(buf.String(), `"error": null`) {
+		t.Error("expected a null error for the successful result")
+	}
+}
+
+// TestWriteCSVReport checks the CSV header and rows, with and without the
+// value column.
+func TestWriteCSVReport(t *testing.T) {
+	results := []Result{
+		{Name: "Fast Doubling", Value: big.NewInt(6765), Duration: 3 * time.Millisecond},
+		{Name: "Timed Out", Duration: time.Second, Err: context.DeadlineExceeded},
+	}
+
+	var buf bytes.Buffer
+	if err := writeCSVReport(&buf, config{format: formatCSV, base: 10}, results); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "name,duration_ns,status,digits\n" +
+		"Fast Doubling,3000000,OK,4\n" +
+		"Timed Out,1000000000,Timeout,\n"
+	if buf.String() != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, buf.String())
+	}
+
+	buf.Reset()
+	if err := writeCSVReport(&buf, config{format: formatCSV, base: 16, csvValues: true}, results); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want = "name,duration_ns,status,digits,value\n" +
+		"Fast Doubling,3000000,OK,4,1a6d\n" +
+		"Timed Out,1000000000,Timeout,,\n"
+	if buf.String() != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, buf.String())
+	}
+}
+
+// TestValueFormat checks that `-format value` writes exactly the winning value
+// and a newline to stdout, in the requested base, and nothing without a
+// success.
+func TestValueFormat(t *testing.T) {
+	results := []Result{
+		{Name: "Timed Out", Duration: time.Millisecond, Err: context.DeadlineExceeded},
+		{Name: "Fast Doubling", Value: big.NewInt(6765), Duration: 3 * time.Millisecond},
+	}
+	for _, c := range []struct {
+		base int
+		want string
+	}{
+		{10, "6765\n"},
+		{16, "1a6d\n"},
+	} {
+		cfg := config{n: 20, format: formatValue, base: c.base}
+		out := captureStdout(t, func() { reportResults(context.Background(), results, cfg) })
+		if out != c.want {
+			t.Errorf("base %d: expected %q, got %q", c.base, c.want, out)
+		}
+	}
+
+	failed := []Result{{Name: "Timed Out", Err: context.DeadlineExceeded}}
+	out := captureStdout(t, func() { reportResults(context.Background(), failed, config{format: formatValue, base: 10}) })
+	if out != "" {
+		t.Errorf("expected no output without a success, got %q", out)
+	}
+}
+
+// TestWriteResultFile checks that the file contains exactly the decimal value
+// followed by a newline, and that the reported byte count matches.
+func TestWriteResultFile(t *testing.T) {
+	v, err := fibFastDoubling(context.Background(), 1000, newIntPool())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "fib.txt")
+
+	// Write twice to check that an existing file is truncated.
+	for _, base := range []int{10, 16} {
+		written, err := writeResultFile(path, v, base)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("failed to read back the file: %v", err)
+		}
+		if want := v.Text(base) + "\n"; string(data) != want {
+			t.Errorf("file content mismatch: got %d bytes, expected %d", len(data), len(want))
+		}
+		if written != int64(len(data)) {
+			t.Errorf("reported %d bytes written, but the file has %d", written, len(data))
+		}
+	}
+
+	if _, err := writeResultFile(filepath.Join(t.TempDir(), "missing", "fib.txt"), v, 10); err == nil {
+		t.Error("expected an error for a missing directory, but got none")
+	}
+}
+
+// TestRawFormat checks the round trip of `-format raw` for positive,
+// negative and zero values: the value is restored from the file with
+// SetBytes and the sign byte, and stdout stays empty.
+func TestRawFormat(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	for _, n := range []int{1000, -1000, 0} {
+		v, err := fibFastDoubling(ctx, n, newIntPool())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		cfg := config{n: n, format: formatRaw, base: 10, output: filepath.Join(dir, "fib.bin")}
+		out := captureStdout(t, func() { reportResults(ctx, []Result{{Name: "Fast Doubling", symbol: "F", Value: v}}, cfg) })
+		if out != "" {
+			t.Errorf("n=%d: expected nothing on stdout, got %q", n, out)
+		}
+
+		data, err := os.ReadFile(cfg.output)
+		if err != nil {
+			t.Fatalf("failed to read back the file: %v", err)
+		}
+		if len(data) != 1+len(v.Bytes()) {
+			t.Fatalf("n=%d: expected %d bytes, got %d", n, 1+len(v.Bytes()), len(data))
+		}
+		got := new(big.Int).SetBytes(data[1:])
+		if data[0] == rawNegative {
+			got.Neg(got)
+		}
+		if got.Cmp(v) != 0 {
+			t.Errorf("n=%d: round trip gave %s, expected %s", n, abbreviate(got.String()), abbreviate(v.String()))
+		}
+	}
+}
@@ -0,0 +1,128 @@
+// profile.go
+
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+	"sync"
+)
+
+// ------------------------------------------------------------
+// Profiling Hooks (-cpuprofile, -memprofile, -trace)
+// ------------------------------------------------------------
+//
+// Concept:
+// Where the time of a concurrent run goes is a question for the Go tools,
+// not for the timings of the table: `-cpuprofile` and `-memprofile` write
+// `runtime/pprof` profiles for `go tool pprof`, and `-trace` an execution
+// trace for `go tool trace`, which shows each goroutine, its blocking and the
+// garbage collections over time. They cover the whole run of the program.
+//
+// A profile is only usable once flushed. run stops them with a deferred call,
+// which covers a normal end, a timeout and a first interruption signal;
+// fatal and the forced exit of a second signal bypass the deferred calls and
+// call flushProfiles before exiting.
+
+// profiler records the profiles requested on the command line.
+type profiler struct {
+	cpu     *os.File // CPU profile being written, nil when disabled
+	trace   *os.File // Execution trace being written, nil when disabled
+	memPath string   // File receiving the heap profile at the end, empty when disabled
+	once    sync.Once
+}
+
+// activeProfiler is the profiler started by run, flushed by flushProfiles.
+var activeProfiler struct {
+	sync.Mutex
+	p *profiler
+}
+
+// startProfiling starts the CPU profile and the execution trace requested by
+// cfg, and registers the profiler for flushProfiles. On error, whatever was
+// started is stopped again.
+func startProfiling(cfg config) (*profiler, error) {
+	p := &profiler{memPath: cfg.memProfile}
+	if cfg.cpuProfile != "" {
+		f, err := os.Create(cfg.cpuProfile)
+		if err != nil {
+			return nil, err
+		}
+		if err := pprof.StartCPUProfile(f); err != nil {
+			f.Close()
+			return nil, err
+		}
+		p.cpu = f
+	}
+	if cfg.trace != "" {
+		f, err := os.Create(cfg.trace)
+		if err == nil {
+			if err = trace.Start(f); err != nil {
+				f.Close()
+			}
+		}
+		if err != nil {
+			p.memPath = ""
+			p.Stop()
+			return nil, err
+		}
+		p.trace = f
+	}
+
+	activeProfiler.Lock()
+	activeProfiler.p = p
+	activeProfiler.Unlock()
+	return p, nil
+}
+
+// Stop stops the trace and the CPU profile and writes the heap profile,
+// after a garbage collection so that it reflects the live memory. Only the
+// first call has an effect, later ones wait for it to complete. Failures are
+// logged: the result of the run does not depend on them.
+func (p *profiler) Stop() {
+	p.once.Do(func() {
+		if p.trace != nil {
+			trace.Stop()
+			logProfileError("trace", p.trace.Name(), p.trace.Close())
+		}
+		if p.cpu != nil {
+			pprof.StopCPUProfile()
+			logProfileError("CPU profile", p.cpu.Name(), p.cpu.Close())
+		}
+		if p.memPath != "" {
+			logProfileError("memory profile", p.memPath, writeHeapProfile(p.memPath))
+		}
+	})
+}
+
+// writeHeapProfile writes the heap profile to path.
+func writeHeapProfile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	runtime.GC()
+	return errors.Join(pprof.WriteHeapProfile(f), f.Close())
+}
+
+// logProfileError logs the failure to write a profile, if any.
+func logProfileError(kind, path string, err error) {
+	if err != nil {
+		slog.Error("failed to write the "+kind, "path", path, "error", err)
+	}
+}
+
+// flushProfiles stops the profiler started by run, if any, before an exit
+// that skips the deferred calls.
+func flushProfiles() {
+	activeProfiler.Lock()
+	p := activeProfiler.p
+	activeProfiler.Unlock()
+	if p != nil {
+		p.Stop()
+	}
+}
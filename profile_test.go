@@ -0,0 +1,54 @@
+// profile_test.go
+
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime/pprof"
+	"testing"
+)
+
+// TestProfiling checks that the three profiles are written once stopped,
+// that stopping twice or flushing afterwards is harmless, and that a failure
+// to start the trace stops the CPU profile started before it.
+func TestProfiling(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config{
+		cpuProfile: filepath.Join(dir, "cpu.out"),
+		memProfile: filepath.Join(dir, "mem.out"),
+		trace:      filepath.Join(dir, "trace.out"),
+	}
+	p, err := startProfiling(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() {
+		activeProfiler.Lock()
+		activeProfiler.p = nil
+		activeProfiler.Unlock()
+	}()
+	if _, err := fibFastDoubling(context.Background(), 100000, newIntPool()); err != nil {
+		t.Fatal(err)
+	}
+	p.Stop()
+	p.Stop()
+	flushProfiles()
+	for _, path := range []string{cfg.cpuProfile, cfg.memProfile, cfg.trace} {
+		if info, err := os.Stat(path); err != nil || info.Size() == 0 {
+			t.Errorf("expected a non-empty profile %s (err=%v)", filepath.Base(path), err)
+		}
+	}
+
+	cfg.trace = filepath.Join(dir, "missing", "trace.out")
+	if _, err := startProfiling(cfg); err == nil {
+		t.Fatal("expected an error for a trace in a missing directory")
+	}
+	// The CPU profile must have been stopped, or this one could not start.
+	if err := pprof.StartCPUProfile(io.Discard); err != nil {
+		t.Fatalf("the CPU profile is still running after the failed start: %v", err)
+	}
+	pprof.StopCPUProfile()
+}
@@ -0,0 +1,104 @@
+// progressbar.go
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ------------------------------------------------------------
+// Progress Bar and ETA Estimation
+// ------------------------------------------------------------
+
+const (
+	// defaultBarWidth is the number of cells of each progress bar.
+	defaultBarWidth = 20
+	// etaWindow is the number of samples the ETA is smoothed over.
+	etaWindow = 5
+	// etaSampleInterval is the minimum delay between two samples. Algorithms
+	// may report progress thousands of times per second; sampling over a
+	// longer span keeps the rate from jittering with each update.
+	etaSampleInterval = progressRefreshInterval
+)
+
+// progressSample is a percentage observed at a given instant.
+type progressSample struct {
+	at  time.Time
+	pct float64
+}
+
+// taskProgress holds the display state of one task: its latest percentage
+// and the recent samples used to estimate the remaining time.
+type taskProgress struct {
+	pct       float64
+	samples   []progressSample // At most etaWindow samples, oldest first
+	rep, reps int              // Repetition in flight out of reps, with `-repeat`
+}
+
+// update records a new percentage observed at the given instant.
+func (p *taskProgress) update(at time.Time, pct float64) {
+	p.pct = pct
+	if n := len(p.samples); n > 0 && at.Sub(p.samples[n-1].at) < etaSampleInterval {
+		return
+	}
+	p.samples = append(p.samples, progressSample{at: at, pct: pct})
+	if len(p.samples) > etaWindow {
+		p.samples = p.samples[1:]
+	}
+}
+
+// label returns the task name, followed by the repetition in flight, e.g.
+// `Fast Doubling (2/5)`, when the task is repeated.
+func (p *taskProgress) label(name string) string {
+	if p.reps > 1 {
+		return fmt.Sprintf("%s (%d/%d)", name, p.rep, p.reps)
+	}
+	return name
+}
+
+// eta estimates the remaining time from the average rate over the sample
+// window, extrapolated from the latest percentage. The boolean is false when
+// no estimate is possible yet (fewer than two samples, or no progress).
+func (p *taskProgress) eta() (time.Duration, bool) {
+	if p.pct >= 100 {
+		return 0, true
+	}
+	if len(p.samples) < 2 {
+		return 0, false
+	}
+	first, last := p.samples[0], p.samples[len(p.samples)-1]
+	elapsed := last.at.Sub(first.at)
+	done := last.pct - first.pct
+	if elapsed <= 0 || done <= 0 {
+		return 0, false
+	}
+	return time.Duration(float64(elapsed) * (100 - p.pct) / done), true
+}
+
+// renderBar formats a single task as `Name [#####-----] 52.3% ETA 1.4s`.
+// A width of 0 leaves out the bar and keeps only the percentage and ETA.
+func renderBar(name string, pct float64, width int, eta time.Duration, etaKnown bool) string {
+	pct = min(max(pct, 0), 100)
+	var b strings.Builder
+	b.WriteString(name)
+	if width > 0 {
+		filled := int(pct / 100 * float64(width))
+		b.WriteString(" [")
+		b.WriteString(strings.Repeat("#", filled))
+		b.WriteString(strings.Repeat("-", width-filled))
+		b.WriteString("]")
+	}
+	fmt.Fprintf(&b, " %5.1f%% ETA %s", pct, formatETA(eta, etaKnown))
+	return b.String()
+}
+
+// formatETA renders a remaining duration rounded to a tenth of a second, or
+// `--` when it cannot be estimated yet.
+func formatETA(d time.Duration, known bool) string {
+	if !known {
+		return "--"
+	}
+	return d.Round(100 * time.Millisecond).String()
+}
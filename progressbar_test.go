@@ -0,0 +1,59 @@
+// progressbar_test.go
+
+package main
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+// TestProgressBar verifies bar rendering and the smoothed ETA estimate.
+func TestProgressBar(t *testing.T) {
+	if got, want := renderBar("Fast", 52.3, 10, 1400*time.Millisecond, true), "Fast [#####-----]  52.3% ETA 1.4s"; got != want {
+		t.Errorf("renderBar: expected %q, got %q", want, got)
+	}
+	if got, want := renderBar("Fast", 150, 4, 0, false), "Fast [####] 100.0% ETA --"; got != want {
+		t.Errorf("renderBar (clamped): expected %q, got %q", want, got)
+	}
+	if got, want := renderBar("Fast", 10, 0, 0, false), "Fast  10.0% ETA --"; got != want {
+		t.Errorf("renderBar (no bar): expected %q, got %q", want, got)
+	}
+
+	var p taskProgress
+	start := time.Now()
+	p.update(start, 0)
+	if _, known := p.eta(); known {
+		t.Error("expected no ETA after a single sample")
+	}
+
+	// Updates closer than etaSampleInterval only move the percentage.
+	p.update(start.Add(etaSampleInterval/2), 5)
+	if len(p.samples) != 1 || p.pct != 5 {
+		t.Errorf("expected 1 sample at 5%%, got %d samples at %v%%", len(p.samples), p.pct)
+	}
+
+	// 10% per second over the window: 60% remaining takes 6 seconds.
+	for i := 1; i <= 2*etaWindow; i++ {
+		p.update(start.Add(time.Duration(i)*etaSampleInterval), float64(i)*etaSampleInterval.Seconds()*10)
+	}
+	if len(p.samples) != etaWindow {
+		t.Errorf("expected the window to hold %d samples, got %d", etaWindow, len(p.samples))
+	}
+	p.pct = 40
+	if eta, known := p.eta(); !known || eta != 6*time.Second {
+		t.Errorf("expected an ETA of 6s, got %v (known=%v)", eta, known)
+	}
+}
+
+// TestIsTerminal verifies that a regular file is not detected as a terminal.
+func TestIsTerminal(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "stdout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if isTerminal(f) {
+		t.Error("expected a regular file not to be detected as a terminal")
+	}
+}
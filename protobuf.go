@@ -0,0 +1,263 @@
+// protobuf.go
+
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+)
+
+// ------------------------------------------------------------
+// Protocol Buffers Output (`-format protobuf`)
+// ------------------------------------------------------------
+//
+// The report follows the schema of report.proto but is encoded by hand, so
+// the program keeps depending on the standard library only. It is written
+// length-delimited (a varint byte count, then the message), the framing of
+// writeDelimitedTo in the protobuf libraries, so that several reports can be
+// concatenated in one stream.
+
+// Field numbers of report.proto.
+const (
+	pbReportN         = 1 // sint64
+	pbReportMod       = 2 // uint64
+	pbReportTimeoutNs = 3 // int64
+	pbReportResults   = 4 // repeated AlgorithmResult
+
+	pbResultName       = 1 // string
+	pbResultSymbol     = 2 // string
+	pbResultDurationNs = 3 // int64
+	pbResultStatus     = 4 // string
+	pbResultValue      = 5 // bytes
+	pbResultNegative   = 6 // bool
+	pbResultError      = 7 // string
+)
+
+// Wire types used by the report.
+const (
+	pbWireVarint = 0
+	pbWireBytes  = 2
+)
+
+// pbReport is the in-memory form of the Report message.
+type pbReport struct {
+	N         int
+	Mod       uint64
+	TimeoutNs int64
+	Results   []pbResult
+}
+
+// pbResult is the in-memory form of the AlgorithmResult message. Value is nil
+// for a failed algorithm.
+type pbResult struct {
+	Name, Symbol string
+	DurationNs   int64
+	Status       string
+	Value        *big.Int
+	Err          string
+}
+
+// newPBReport converts the results of a run into a report.
+func newPBReport(cfg config, results []Result) pbReport {
+	report := pbReport{N: cfg.n, Mod: cfg.mod, TimeoutNs: cfg.timeout.Nanoseconds()}
+	for _, r := range results {
+		pr := pbResult{Name: r.Name, Symbol: r.symbol, DurationNs: r.Duration.Nanoseconds(), Status: resultStatus(r)}
+		if r.Err != nil {
+			pr.Err = r.Err.Error()
+		} else {
+			pr.Value = r.Value
+		}
+		report.Results = append(report.Results, pr)
+	}
+	return report
+}
+
+// writeProtobufReport writes the length-delimited Report message of the run to w.
+func writeProtobufReport(w io.Writer, cfg config, results []Result) error {
+	msg := newPBReport(cfg, results).marshal()
+	bw := bufio.NewWriter(w)
+	bw.Write(binary.AppendUvarint(nil, uint64(len(msg))))
+	bw.Write(msg)
+	return bw.Flush()
+}
+
+// writeProtobufFile writes the report of the run to the file at path.
+func writeProtobufFile(path string, cfg config, results []Result) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := writeProtobufReport(f, cfg, results); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// readProtobufReport reads one length-delimited Report message from r.
+func readProtobufReport(r *bufio.Reader) (pbReport, error) {
+	size, err := binary.ReadUvarint(r)
+	if err != nil {
+		return pbReport{}, fmt.Errorf("reading message size: %w", err)
+	}
+	msg := make([]byte, size)
+	if _, err := io.ReadFull(r, msg); err != nil {
+		return pbReport{}, fmt.Errorf("reading message: %w", err)
+	}
+	return unmarshalPBReport(msg)
+}
+
+// marshal encodes the report. Zero scalars are omitted, as in proto3.
+func (report pbReport) marshal() []byte {
+	var b []byte
+	b = appendPBVarint(b, pbReportN, uint64(report.N)<<1^uint64(report.N>>63)) // ZigZag
+	b = appendPBVarint(b, pbReportMod, report.Mod)
+	b = appendPBVarint(b, pbReportTimeoutNs, uint64(report.TimeoutNs))
+	for _, r := range report.Results {
+		b = appendPBBytes(b, pbReportResults, r.marshal())
+	}
+	return b
+}
+
+// marshal encodes the result. The value is present, possibly empty for 0,
+// exactly when the algorithm succeeded.
+func (r pbResult) marshal() []byte {
+	var b []byte
+	b = appendPBString(b, pbResultName, r.Name)
+	b = appendPBString(b, pbResultSymbol, r.Symbol)
+	b = appendPBVarint(b, pbResultDurationNs, uint64(r.DurationNs))
+	b = appendPBString(b, pbResultStatus, r.Status)
+	if r.Value != nil {
+		b = appendPBBytes(b, pbResultValue, r.Value.Bytes())
+		if r.Value.Sign() < 0 {
+			b = appendPBVarint(b, pbResultNegative, 1)
+		}
+	}
+	b = appendPBString(b, pbResultError, r.Err)
+	return b
+}
+
+// appendPBVarint appends a varint field, omitted when v is 0.
+func appendPBVarint(b []byte, field int, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = binary.AppendUvarint(b, uint64(field)<<3|pbWireVarint)
+	return binary.AppendUvarint(b, v)
+}
+
+// appendPBBytes appends a length-delimited field, even when data is empty.
+func appendPBBytes(b []byte, field int, data []byte) []byte {
+	b = binary.AppendUvarint(b, uint64(field)<<3|pbWireBytes)
+	b = binary.AppendUvarint(b, uint64(len(data)))
+	return append(b, data...)
+}
+
+// appendPBString appends a string field, omitted when s is empty.
+func appendPBString(b []byte, field int, s string) []byte {
+	if s == "" {
+		return b
+	}
+	return appendPBBytes(b, field, []byte(s))
+}
+
+// pbField is one decoded field: v holds a varint, data a length-delimited value.
+type pbField struct {
+	num  int
+	v    uint64
+	data []byte
+}
+
+// parsePBFields splits an encoded message into its fields. Unknown fields are
+// returned like the others and left to the caller to skip.
+func parsePBFields(msg []byte) ([]pbField, error) {
+	var fields []pbField
+	for len(msg) > 0 {
+		tag, n := binary.Uvarint(msg)
+		if n <= 0 {
+			return nil, errors.New("malformed field tag")
+		}
+		msg = msg[n:]
+		f := pbField{num: int(tag >> 3)}
+		switch tag & 7 {
+		case pbWireVarint:
+			if f.v, n = binary.Uvarint(msg); n <= 0 {
+				return nil, fmt.Errorf("malformed varint in field %d", f.num)
+			}
+			msg = msg[n:]
+		case pbWireBytes:
+			size, n := binary.Uvarint(msg)
+			if n <= 0 || size > uint64(len(msg)-n) {
+				return nil, fmt.Errorf("malformed length in field %d", f.num)
+			}
+			f.data, msg = msg[n:n+int(size)], msg[n+int(size):]
+		default:
+			return nil, fmt.Errorf("unsupported wire type %d in field %d", tag&7, f.num)
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// unmarshalPBReport decodes a Report message.
+func unmarshalPBReport(msg []byte) (pbReport, error) {
+	var report pbReport
+	fields, err := parsePBFields(msg)
+	if err != nil {
+		return report, err
+	}
+	for _, f := range fields {
+		switch f.num {
+		case pbReportN:
+			report.N = int(f.v>>1) ^ -int(f.v&1) // ZigZag
+		case pbReportMod:
+			report.Mod = f.v
+		case pbReportTimeoutNs:
+			report.TimeoutNs = int64(f.v)
+		case pbReportResults:
+			r, err := unmarshalPBResult(f.data)
+			if err != nil {
+				return report, fmt.Errorf("result %d: %w", len(report.Results)+1, err)
+			}
+			report.Results = append(report.Results, r)
+		}
+	}
+	return report, nil
+}
+
+// unmarshalPBResult decodes an AlgorithmResult message.
+func unmarshalPBResult(msg []byte) (pbResult, error) {
+	var r pbResult
+	fields, err := parsePBFields(msg)
+	if err != nil {
+		return r, err
+	}
+	negative := false
+	for _, f := range fields {
+		switch f.num {
+		case pbResultName:
+			r.Name = string(f.data)
+		case pbResultSymbol:
+			r.Symbol = string(f.data)
+		case pbResultDurationNs:
+			r.DurationNs = int64(f.v)
+		case pbResultStatus:
+			r.Status = string(f.data)
+		case pbResultValue:
+			r.Value = new(big.Int).SetBytes(f.data)
+		case pbResultNegative:
+			negative = f.v != 0
+		case pbResultError:
+			r.Err = string(f.data)
+		}
+	}
+	if negative && r.Value != nil {
+		r.Value.Neg(r.Value)
+	}
+	return r, nil
+}
@@ -0,0 +1,77 @@
+// protobuf_test.go
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestProtobufRoundTrip marshals a sample result set, including a negative
+// index, a zero, a negative value and a failure, and decodes it back.
+func TestProtobufRoundTrip(t *testing.T) {
+	cfg := config{n: -100, mod: 0, timeout: time.Minute}
+	f100, _ := new(big.Int).SetString("-354224848179261915075", 10)
+	results := []Result{
+		{Name: "Fast Doubling", symbol: "F", Value: f100, Duration: 2 * time.Millisecond},
+		{Name: "Zero", symbol: "F", Value: new(big.Int), Duration: time.Microsecond},
+		{Name: "Binet", symbol: "F", Err: context.DeadlineExceeded, Duration: time.Second},
+		{Name: "Memoized", symbol: "F", Err: errors.New("boom"), Duration: 3 * time.Millisecond},
+	}
+
+	// Two reports in a row check the length-delimited framing.
+	var buf bytes.Buffer
+	for i := 0; i < 2; i++ {
+		if err := writeProtobufReport(&buf, cfg, results); err != nil {
+			t.Fatalf("writeProtobufReport failed: %v", err)
+		}
+	}
+	want := pbReport{
+		N: -100, TimeoutNs: time.Minute.Nanoseconds(),
+		Results: []pbResult{
+			{Name: "Fast Doubling", Symbol: "F", DurationNs: 2e6, Status: "OK", Value: f100},
+			{Name: "Zero", Symbol: "F", DurationNs: 1e3, Status: "OK", Value: new(big.Int)},
+			{Name: "Binet", Symbol: "F", DurationNs: 1e9, Status: "Timeout", Err: context.DeadlineExceeded.Error()},
+			{Name: "Memoized", Symbol: "F", DurationNs: 3e6, Status: "Error", Err: "boom"},
+		},
+	}
+	r := bufio.NewReader(&buf)
+	for i := 0; i < 2; i++ {
+		got, err := readProtobufReport(r)
+		if err != nil {
+			t.Fatalf("report %d: readProtobufReport failed: %v", i+1, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("report %d: round trip mismatch:\ngot  %+v\nwant %+v", i+1, got, want)
+		}
+	}
+	if _, err := readProtobufReport(r); err == nil {
+		t.Error("expected an error at the end of the stream")
+	}
+}
+
+// TestProtobufEncoding pins the encoding of a small report against the
+// protobuf wire format, and checks that a truncated message is rejected.
+func TestProtobufEncoding(t *testing.T) {
+	report := pbReport{N: -1, Mod: 150, Results: []pbResult{{Name: "a", Value: big.NewInt(5)}}}
+	got := report.marshal()
+	want := []byte{
+		0x08, 0x01, // n = -1, ZigZag encoded as 1
+		0x10, 0x96, 0x01, // mod = 150
+		0x22, 0x06, // results, 6 bytes
+		0x0a, 0x01, 'a', // name = "a"
+		0x2a, 0x01, 0x05, // value = 5
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("unexpected encoding:\ngot  % x\nwant % x", got, want)
+	}
+	if _, err := unmarshalPBReport(want[:len(want)-1]); err == nil {
+		t.Error("expected an error for a truncated message")
+	}
+}
@@ -0,0 +1,91 @@
+// range.go
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/agbruneau/FibJule/fib"
+)
+
+// ------------------------------------------------------------
+// Range Mode: Emitting F(a)..F(b)
+// ------------------------------------------------------------
+
+// parseRange parses a range of the form `a:b` with 0 <= a <= b.
+func parseRange(spec string) (int, int, error) {
+	lo, hi, found := strings.Cut(spec, ":")
+	if !found {
+		return 0, 0, fmt.Errorf("invalid range %q (expected a:b, e.g. 0:100)", spec)
+	}
+	a, err := strconv.Atoi(lo)
+	if err != nil || a < 0 {
+		return 0, 0, fmt.Errorf("invalid range start %q (expected a non-negative integer)", lo)
+	}
+	b, err := strconv.Atoi(hi)
+	if err != nil || b < a {
+		return 0, 0, fmt.Errorf("invalid range end %q (expected an integer >= %d)", hi, a)
+	}
+	return a, b, nil
+}
+
+// writeRange writes F(i) for every i in [a, b], one value per line in the
+// given base. A non-nil m reduces every value modulo m.
+func writeRange(ctx context.Context, w io.Writer, a, b int, m *big.Int, base int) error {
+	bw := bufio.NewWriterSize(w, 1<<20)
+	yield := func(_ int, v *big.Int) error {
+		return writeValueLine(bw, v, base)
+	}
+
+	var err error
+	if m != nil {
+		err = fib.RangeMod(ctx, a, b, m, yield, fib.WithPool(newIntPool()))
+	} else {
+		err = fib.Range(ctx, a, b, yield, fib.WithPool(newIntPool()))
+	}
+	// Flush even on error, so the values computed so far are kept.
+	if flushErr := bw.Flush(); err == nil {
+		err = flushErr
+	}
+	return err
+}
+
+// runRangeMode runs the `-range` mode: it writes the requested terms to
+// stdout, or to the `-output` file when set, within the global timeout.
+func runRangeMode(cfg config) {
+	a, b, err := parseRange(cfg.rangeSpec) // Already validated by parseConfig
+	if err != nil {
+		fatal("invalid arguments", "error", err)
+	}
+	m, _ := algorithmOptions(cfg)
+
+	w := io.Writer(os.Stdout)
+	if cfg.output != "" {
+		f, err := os.Create(cfg.output)
+		if err != nil {
+			fatal("failed to create output file", "path", cfg.output, "error", err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.timeout)
+	defer cancel()
+	stopSignals := handleSignals(cancel)
+	defer stopSignals()
+
+	slog.Info("writing range", "from", a, "to", b, "timeout", cfg.timeout)
+	if err := writeRange(ctx, w, a, b, m, cfg.base); err != nil {
+		slog.Error("range interrupted", "error", err)
+		return
+	}
+	slog.Info("range finished")
+}
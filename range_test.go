@@ -0,0 +1,38 @@
+// range_test.go
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+)
+
+// TestWriteRange verifies range parsing and the line-by-line output.
+func TestWriteRange(t *testing.T) {
+	if a, b, err := parseRange("3:10"); err != nil || a != 3 || b != 10 {
+		t.Errorf("expected 3, 10, got %d, %d (err=%v)", a, b, err)
+	}
+	for _, spec := range []string{"10", "-1:5", "5:4", "a:b"} {
+		if _, _, err := parseRange(spec); err == nil {
+			t.Errorf("expected an error for range %q, but got none", spec)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := writeRange(context.Background(), &buf, 5, 12, nil, 10); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := buf.String(), "5\n8\n13\n21\n34\n55\n89\n144\n"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	buf.Reset()
+	if err := writeRange(context.Background(), &buf, 10, 12, big.NewInt(10), 16); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := buf.String(), "5\n9\n4\n"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,115 @@
+// ratio.go
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/agbruneau/FibJule/fib"
+)
+
+// ------------------------------------------------------------
+// Ratio Mode: F(n+1)/F(n) Approaching φ (-ratio)
+// ------------------------------------------------------------
+//
+// Concept:
+// The ratio of consecutive Fibonacci numbers tends to the golden ratio, each
+// step adding about 0.418 correct decimal digits (see fib.Ratio). `-ratio`
+// prints the ratio for n next to φ, their difference, and how many decimals
+// they share, which makes the convergence visible from one n to the next.
+
+// ratioShownDecimals bounds the decimals printed for the ratio and φ: beyond
+// it, the agreement is given by its count only.
+const ratioShownDecimals = 60
+
+// agreeingDecimals returns the number of leading decimals shared by the
+// positive x and y, whose difference is diff: the largest d for which
+// ⌊x·10^d⌋ = ⌊y·10^d⌋, 0 when their integer parts already differ. The
+// search starts from −log10|diff| + 1 and goes down, usually for one or two
+// steps, comparing integers rather than decimal expansions, whose conversion
+// is quadratic in the precision.
+func agreeingDecimals(x, y, diff *big.Float) int {
+	if diff.Sign() == 0 {
+		return math.MaxInt
+	}
+	mant := new(big.Float)
+	exp := diff.MantExp(mant) // |diff| = |mant|·2^exp, with |mant| in [0.5, 1)
+	m, _ := mant.Abs(mant).Float64()
+	d := max(int(-(float64(exp)+math.Log2(m))*math.Log10(2))+1, 0)
+
+	prec := max(x.Prec(), y.Prec())
+	for ; d > 0; d-- {
+		scale := new(big.Float).SetPrec(prec).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(d)), nil))
+		xi, _ := new(big.Float).SetPrec(prec).Mul(x, scale).Int(nil)
+		yi, _ := new(big.Float).SetPrec(prec).Mul(y, scale).Int(nil)
+		if xi.Cmp(yi) == 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// roundedText formats x with k decimals, first rounding it to the precision
+// those decimals need: formatting converts the whole mantissa, which takes
+// long for the millions of bits of a large n.
+func roundedText(x *big.Float, k int) string {
+	prec := uint(float64(k+10)*math.Log2(10)) + 64
+	if prec >= x.Prec() {
+		return x.Text('f', k)
+	}
+	return new(big.Float).SetPrec(prec).Set(x).Text('f', k)
+}
+
+// scientificFloat formats the non-zero x like x.Text('e', k). Text converts
+// the binary exponent to decimal digit by digit, which takes minutes for a
+// difference of 10^−400000: x is first scaled into [1, 10) by a power of ten,
+// whose exponent comes from the float64 logarithm of x.
+func scientificFloat(x *big.Float, k int) string {
+	mant := new(big.Float)
+	exp := x.MantExp(mant) // |x| = |mant|·2^exp, with |mant| in [0.5, 1)
+	m, _ := mant.Abs(mant).Float64()
+	e10 := int(math.Floor((float64(exp) + math.Log2(m)) * math.Log10(2)))
+
+	prec := uint(math.Ceil(float64(k+1)*math.Log2(10))) + sciGuardBits
+	pow := new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(max(e10, -e10))), nil))
+	scaled := new(big.Float).SetPrec(prec)
+	if e10 < 0 {
+		scaled.Mul(x, pow)
+	} else {
+		scaled.Quo(x, pow)
+	}
+	// The logarithm may be off by one near a power of ten: Text then
+	// reports the remaining exponent, added to e10.
+	mantissa, rest, _ := strings.Cut(scaled.Text('e', k), "e")
+	adjust, _ := strconv.Atoi(rest)
+	return fmt.Sprintf("%se%+03d", mantissa, e10+adjust)
+}
+
+// runRatioMode runs the `-ratio` mode: it prints F(n+1)/F(n), φ, their
+// difference and the number of decimals they share.
+func runRatioMode(cfg config) {
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.timeout)
+	defer cancel()
+	stopSignals := handleSignals(cancel)
+	defer stopSignals()
+
+	ratio, diff, err := fib.Ratio(ctx, cfg.n)
+	if err != nil {
+		fatal("failed to compute the ratio", "n", cfg.n, "error", err)
+	}
+	phi := fib.Phi(ratio.Prec())
+	agree := agreeingDecimals(ratio, phi, diff)
+	shown := min(agree+3, ratioShownDecimals)
+
+	label := fmt.Sprintf("F(%d)/F(%d)", cfg.n+1, cfg.n)
+	width := max(len(label), len("Difference"))
+	fmt.Printf("%-*s = %s\n", width, label, roundedText(ratio, shown))
+	fmt.Printf("%-*s = %s\n", width, "φ", roundedText(phi, shown))
+	fmt.Printf("%-*s = %s\n", width, "Difference", scientificFloat(diff, max(cfg.sciDigits, 1)))
+	fmt.Printf("Agreeing decimals: %d\n", agree)
+}
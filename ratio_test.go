@@ -0,0 +1,41 @@
+// ratio_test.go
+
+package main
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/agbruneau/FibJule/fib"
+)
+
+// TestAgreeingDecimals checks the decimals F(n+1)/F(n) shares with φ, read
+// off their expansions: F(11)/F(10) = 1.618181… and φ = 1.618033….
+func TestAgreeingDecimals(t *testing.T) {
+	for n, want := range map[int]int{1: 0, 2: 0, 10: 3, 100: 40} {
+		ratio, diff, err := fib.Ratio(context.Background(), n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := agreeingDecimals(ratio, fib.Phi(ratio.Prec()), diff); got != want {
+			t.Errorf("n=%d: expected %d agreeing decimals, got %d", n, want, got)
+		}
+	}
+}
+
+// TestScientificFloat compares scientificFloat with big.Float.Text, including
+// values just below a power of ten, where the logarithm is off by one.
+func TestScientificFloat(t *testing.T) {
+	for _, s := range []string{"3.56415803e-42", "-6.18033989e-01", "12345.678", "9.9999999999e-7", "1e100", "-0.000999999999999"} {
+		x, _, err := big.ParseFloat(s, 10, 200, big.ToNearestEven)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, k := range []int{1, 8} {
+			if got, want := scientificFloat(x, k), x.Text('e', k); got != want {
+				t.Errorf("scientificFloat(%s, %d) = %s, expected %s", s, k, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,126 @@
+// registry.go
+
+package main
+
+import (
+	"context"
+	"math/big"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/agbruneau/FibJule/fib"
+)
+
+// ------------------------------------------------------------
+// Algorithm Registry
+// ------------------------------------------------------------
+//
+// The algorithms selectable with `-algorithms` are kept in a package-level
+// registry rather than hardcoded in `main`, so that a new algorithm can be
+// added from the init function of its own file:
+//
+//	func init() {
+//		Register("naive", fibNaive)
+//	}
+
+// algorithmFactory builds the fibFunc of an algorithm for a run, given the
+// modulus (nil outside modular mode) and the options derived from the flags.
+type algorithmFactory func(m *big.Int, opts ...fib.Option) fibFunc
+
+// registration describes a registered algorithm.
+type registration struct {
+	name    string // Display name
+	symbol  string // Notation of the computed sequence, e.g. "F", "L" or "T"
+	factory algorithmFactory
+}
+
+var (
+	registryMu sync.Mutex
+	registry   = make(map[string]registration) // Keyed by the lowercase `-algorithms` name
+)
+
+// builtinOrder is the order in which "all" expands the built-in algorithms.
+// Other registered algorithms follow in alphabetical order, so that the order
+// never depends on map iteration or on the init order of files.
+var builtinOrder = []string{"fast", "lucas", "matrix", "matrix-fast", "memo", "binet", "binet-exact", "tribonacci"}
+
+// Register makes fn selectable under `-algorithms name`, and includes it in
+// "all". fn must compute F(n); in modular mode its result is reduced modulo m
+// afterwards. Registering an existing name replaces the previous algorithm.
+func Register(name string, fn fibFunc) {
+	register(name, name, "F", func(m *big.Int, _ ...fib.Option) fibFunc {
+		if m == nil {
+			return fn
+		}
+		return func(ctx context.Context, n int, pool *sync.Pool) (*big.Int, error) {
+			v, err := fn(ctx, n, pool)
+			if err != nil {
+				return nil, err
+			}
+			return v.Mod(v, m), nil
+		}
+	})
+}
+
+// register adds an algorithm under the given key, with full control over
+// its display name, sequence symbol, and construction from the flags.
+func register(key, name, symbol string, factory algorithmFactory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[strings.ToLower(key)] = registration{name: name, symbol: symbol, factory: factory}
+}
+
+// registeredTasks builds a task for every registered algorithm, along with
+// the order in which "all" expands them: builtinOrder first, then the other
+// algorithms sorted by name.
+func registeredTasks(m *big.Int, opts ...fib.Option) (map[string]task, []string) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	tasks := make(map[string]task, len(registry))
+	for key, r := range registry {
+		tasks[key] = task{name: r.name, symbol: r.symbol, fn: r.factory(m, opts...)}
+	}
+	return tasks, registryOrder()
+}
+
+// registeredNames returns the `-algorithms` names of the registered
+// algorithms, in the order "all" expands them.
+func registeredNames() []string {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	return registryOrder()
+}
+
+// registryOrder returns the keys of the registry in the order "all" expands
+// them: builtinOrder first, then the other algorithms sorted by name. The
+// caller holds registryMu.
+func registryOrder() []string {
+	var extra []string
+	for key := range registry {
+		if !slices.Contains(builtinOrder, key) {
+			extra = append(extra, key)
+		}
+	}
+	slices.Sort(extra)
+
+	order := make([]string, 0, len(registry))
+	for _, key := range builtinOrder {
+		if _, ok := registry[key]; ok {
+			order = append(order, key)
+		}
+	}
+	return append(order, extra...)
+}
+
+// init registers the built-in algorithms.
+func init() {
+	register("fast", "Fast Doubling", "F", fastDoublingTask)
+	register("lucas", "Lucas", "L", lucasTask)
+	register("matrix", "Matrix", "F", matrixTask)
+	register("matrix-fast", "Matrix Fast", "F", matrixFastTask)
+	register("memo", "Memoized", "F", memoTask)
+	register("binet", "Binet", "F", binetTask)
+	register("binet-exact", "Binet Exact", "F", binetExactTask)
+	register("tribonacci", "Tribonacci", "T", tribonacciTask)
+}
@@ -0,0 +1,82 @@
+// registry_test.go
+
+package main
+
+import (
+	"context"
+	"math/big"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// TestRegister verifies that a registered algorithm becomes selectable, is
+// included in "all" after the built-ins, and is reduced in modular mode.
+func TestRegister(t *testing.T) {
+	dummy := func(ctx context.Context, n int, pool *sync.Pool) (*big.Int, error) {
+		return fibFastDoubling(ctx, n, pool)
+	}
+	Register("Dummy", dummy)
+
+	available, order := registeredTasks(nil)
+	if !slices.Equal(order[:len(builtinOrder)], builtinOrder) || !slices.Contains(order, "dummy") {
+		t.Errorf("expected the built-ins first, then the dummy, got %v", order)
+	}
+	tasks, err := selectTasks("dummy", available, order)
+	if err != nil || len(tasks) != 1 || tasks[0].name != "Dummy" || tasks[0].symbol != "F" {
+		t.Fatalf("expected the dummy algorithm to be selectable, got %+v (err=%v)", tasks, err)
+	}
+	if v, err := tasks[0].fn(context.Background(), 20, newIntPool()); err != nil || v.Int64() != 6765 {
+		t.Errorf("expected F(20) = 6765, got %v (err=%v)", v, err)
+	}
+
+	// Registering the same name again replaces it without duplicating it.
+	Register("dummy", dummy)
+	if _, again := registeredTasks(nil); len(again) != len(order) {
+		t.Errorf("expected %d registered algorithms, got %v", len(order), again)
+	}
+
+	modTasks, _ := registeredTasks(big.NewInt(1000))
+	if v, err := modTasks["dummy"].fn(context.Background(), 20, newIntPool()); err != nil || v.Int64() != 765 {
+		t.Errorf("expected F(20) mod 1000 = 765, got %v (err=%v)", v, err)
+	}
+}
+
+// TestRegisteredOrder checks that "all" expands the extra algorithms in a
+// stable, alphabetical order after the built-ins.
+func TestRegisteredOrder(t *testing.T) {
+	Register("zeta", fibFastDoubling)
+	Register("alpha", fibFastDoubling)
+
+	available, order := registeredTasks(nil)
+	first, err := selectTasks("all", available, order)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if alpha, zeta := slices.Index(order, "alpha"), slices.Index(order, "zeta"); alpha < len(builtinOrder) || alpha > zeta {
+		t.Errorf("expected alpha before zeta after the built-ins, got %v", order)
+	}
+
+	for i := 0; i < 100; i++ {
+		available, order := registeredTasks(nil)
+		tasks, _ := selectTasks("all", available, order)
+		for j := range tasks {
+			if tasks[j].name != first[j].name {
+				t.Fatalf("run %d: order changed at position %d: %s instead of %s", i, j, tasks[j].name, first[j].name)
+			}
+		}
+	}
+}
+
+// TestAlgorithmsHelp checks that the help of -algorithms lists every
+// registered algorithm, so that it follows the registry.
+func TestAlgorithmsHelp(t *testing.T) {
+	var help strings.Builder
+	parseConfig([]string{"-h"}, &help)
+	for _, name := range registeredNames() {
+		if !strings.Contains(help.String(), "'"+name+"'") {
+			t.Errorf("expected the help of -algorithms to list %q", name)
+		}
+	}
+}
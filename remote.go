@@ -0,0 +1,311 @@
+// remote.go
+
+package main
+
+import (
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net"
+	"time"
+
+	"github.com/agbruneau/FibJule/fib"
+)
+
+// ------------------------------------------------------------
+// Client-Server Mode (`-serve` / `-connect`)
+// ------------------------------------------------------------
+//
+// Protocol:
+// The client opens a TCP connection and sends one gob-encoded remoteRequest.
+// The server answers with a remoteHeader, then one remoteResult per selected
+// algorithm, in completion order, and closes the connection. The algorithms
+// run unchanged on the server; this file is only a transport around them.
+
+// remoteGrace is added to the request timeout to bound how long the client
+// waits for the server before giving up on the connection.
+const remoteGrace = 10 * time.Second
+
+// remoteReadTimeout bounds how long the server waits for the request of a
+// client that has connected, so that an idle connection does not hold a
+// goroutine forever.
+const remoteReadTimeout = 10 * time.Second
+
+// maxRemoteRepeat and maxRemoteConcurrency bound the `-repeat` and
+// `-concurrency` a client may ask of the server.
+const (
+	maxRemoteRepeat      = 100
+	maxRemoteConcurrency = 256
+)
+
+// remoteRequest carries the options of one calculation from client to server.
+type remoteRequest struct {
+	N              int
+	Algorithms     string
+	Mod            uint64
+	Timeout        time.Duration
+	PerAlgoTimeout time.Duration
+	Sequential     bool
+	Repeat         int
+	Warmup         int
+	GCBetween      bool
+	CountOps       bool
+	Concurrency    int
+}
+
+// remoteHeader announces how many results follow, or why there are none.
+type remoteHeader struct {
+	Results int
+	Err     string // Rejected request, e.g. an unknown algorithm
+}
+
+// remoteResult is the wire form of a result: errors travel as their message.
+type remoteResult struct {
+	Name, Symbol string
+	Value        *big.Int
+	Duration     time.Duration
+	Err          string
+	PeakMem      uint64
+	TaskTimeout  bool
+	Repeats      int
+	MinDuration  time.Duration
+	MaxDuration  time.Duration
+	Ops          *fib.OpCounts
+	Cached       bool
+}
+
+// newRemoteRequest builds the request matching the client's flags.
+func newRemoteRequest(cfg config) remoteRequest {
+	return remoteRequest{
+		N: cfg.n, Algorithms: cfg.algorithms, Mod: cfg.mod, Timeout: cfg.timeout,
+		PerAlgoTimeout: cfg.perAlgoTimeout, Sequential: cfg.sequential, Repeat: cfg.repeat, Warmup: cfg.warmup,
+		GCBetween: cfg.gcBetween, CountOps: cfg.countOps,
+		Concurrency: cfg.concurrency,
+	}
+}
+
+// toRemote converts a result to its wire form.
+func toRemote(r Result) remoteResult {
+	rr := remoteResult{
+		Name: r.Name, Symbol: r.symbol, Value: r.Value, Duration: r.Duration, PeakMem: r.peakMem,
+		TaskTimeout: r.taskTimeout, Repeats: r.repeats, MinDuration: r.minDuration, MaxDuration: r.maxDuration,
+		Ops: r.ops, Cached: r.cached,
+	}
+	if r.Err != nil {
+		rr.Err = r.Err.Error()
+	}
+	return rr
+}
+
+// fromRemote converts a wire result back. The context errors are restored
+// as their sentinel values so that failures are reported as on the server.
+func fromRemote(rr remoteResult) Result {
+	r := Result{
+		Name: rr.Name, symbol: rr.Symbol, Value: rr.Value, Duration: rr.Duration, peakMem: rr.PeakMem,
+		taskTimeout: rr.TaskTimeout, repeats: rr.Repeats, minDuration: rr.MinDuration, maxDuration: rr.MaxDuration,
+		ops: rr.Ops, cached: rr.Cached,
+	}
+	switch rr.Err {
+	case "":
+	case context.DeadlineExceeded.Error():
+		r.Err = context.DeadlineExceeded
+	case context.Canceled.Error():
+		r.Err = context.Canceled
+	default:
+		r.Err = errors.New(rr.Err)
+	}
+	return r
+}
+
+// serveRemote accepts connections on ln and answers each one in its own
+// goroutine, until ctx is cancelled. cfg provides the settings the request
+// does not carry, such as the parallel multiplication options, and budget
+// the memory budget of the server in bytes (0 for none).
+func serveRemote(ctx context.Context, ln net.Listener, cfg config, cache *ResultCache, budget uint64) error {
+	go func() {
+		<-ctx.Done()
+		ln.Close() // Unblocks Accept
+	}()
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		go func() {
+			defer conn.Close()
+			if err := handleRemote(ctx, conn, cfg, cache, budget); err != nil {
+				slog.Warn("remote request failed", "client", conn.RemoteAddr().String(), "error", err)
+			}
+		}()
+	}
+}
+
+// handleRemote reads one request from rw, runs the selected algorithms and
+// streams their results back as they complete. The values found in cache
+// are sent first, without running their algorithms, unless the request
+// measures them (`-repeat`, `-warmup`, `-count-ops`); the computed values
+// are added to it. A request whose index or warmup index exceeds the
+// `-max-n` of the server, whose calculation or warmup would exceed budget
+// (see checkMemoryBudget), or whose repeat count or concurrency is negative
+// or beyond maxRemoteRepeat and maxRemoteConcurrency, is refused. The
+// timeout of the request is capped by the `-timeout` of the server. When rw
+// is a connection, the request must arrive within remoteReadTimeout.
+func handleRemote(ctx context.Context, rw io.ReadWriter, cfg config, cache *ResultCache, budget uint64) error {
+	conn, isConn := rw.(interface{ SetReadDeadline(time.Time) error })
+	if isConn {
+		conn.SetReadDeadline(time.Now().Add(remoteReadTimeout))
+	}
+	var req remoteRequest
+	if err := gob.NewDecoder(rw).Decode(&req); err != nil {
+		return fmt.Errorf("decoding request: %w", err)
+	}
+	if isConn {
+		conn.SetReadDeadline(time.Time{})
+	}
+	enc := gob.NewEncoder(rw)
+
+	cfg.n, cfg.algorithms, cfg.mod = req.N, req.Algorithms, req.Mod
+	m, opts := algorithmOptions(cfg)
+	tasks, err := resolveTasks(req.Algorithms, req.N, m, opts...)
+	if err == nil && req.Timeout <= 0 {
+		err = fmt.Errorf("timeout must be positive. Received: %v", req.Timeout)
+	}
+	if err == nil && (req.Repeat < 0 || req.Repeat > maxRemoteRepeat) {
+		err = fmt.Errorf("repeat must be between 0 and %d. Received: %d", maxRemoteRepeat, req.Repeat)
+	}
+	if err == nil && (req.Concurrency < 0 || req.Concurrency > maxRemoteConcurrency) {
+		err = fmt.Errorf("concurrency must be between 0 and %d. Received: %d", maxRemoteConcurrency, req.Concurrency)
+	}
+	if err == nil {
+		err = errors.Join(checkMaxN(cfg, req.N), checkMaxN(cfg, req.Warmup))
+	}
+	if err == nil {
+		// The warmup runs the algorithms one after the other, before the
+		// calculation.
+		cfg.sequential, cfg.concurrency = req.Sequential, req.Concurrency
+		warmup := cfg
+		warmup.sequential = true
+		err = checkMemoryBudget(max(tasksBytes(cfg, tasks, req.N), tasksBytes(warmup, tasks, req.Warmup)), budget)
+	}
+	if err != nil {
+		return errors.Join(err, enc.Encode(remoteHeader{Err: err.Error()}))
+	}
+	var hits []Result
+	if req.Repeat <= 1 && req.Warmup == 0 && !req.CountOps {
+		hits, tasks = cache.lookup(tasks, req.N, req.Mod)
+	}
+	if err := enc.Encode(remoteHeader{Results: len(hits) + len(tasks)}); err != nil {
+		return err
+	}
+	slog.Info("serving calculation", "n", req.N, "mod", req.Mod, "algorithms", req.Algorithms, "cached", len(hits), "timeout", min(req.Timeout, cfg.timeout))
+	for _, r := range hits {
+		if err := enc.Encode(toRemote(r)); err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, min(req.Timeout, cfg.timeout))
+	defer cancel()
+	resultsCh := make(chan Result, len(tasks))
+	go func() {
+		runTasks(ctx, tasks, req.N, resultsCh, runOptions{
+			sequential:  req.Sequential,
+			taskTimeout: req.PerAlgoTimeout,
+			repeat:      req.Repeat,
+			warmup:      req.Warmup,
+			gcBetween:   req.GCBetween,
+			countOps:    req.CountOps,
+			concurrency: concurrencyLimit(req.Concurrency),
+		})
+		close(resultsCh)
+	}()
+	for r := range resultsCh {
+		cache.store([]Result{r}, req.N, req.Mod)
+		if err := enc.Encode(toRemote(r)); err != nil {
+			cancel() // The client is gone: stop the remaining calculations
+			for range resultsCh {
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// requestRemote sends req over rw and returns the results streamed back.
+func requestRemote(rw io.ReadWriter, req remoteRequest) ([]Result, error) {
+	if err := gob.NewEncoder(rw).Encode(req); err != nil {
+		return nil, fmt.Errorf("sending request: %w", err)
+	}
+	dec := gob.NewDecoder(rw)
+	var header remoteHeader
+	if err := dec.Decode(&header); err != nil {
+		return nil, fmt.Errorf("reading response: %w", err)
+	}
+	if header.Err != "" {
+		return nil, fmt.Errorf("server rejected the request: %s", header.Err)
+	}
+	results := make([]Result, 0, header.Results)
+	for i := 0; i < header.Results; i++ {
+		var rr remoteResult
+		if err := dec.Decode(&rr); err != nil {
+			return results, fmt.Errorf("reading result %d of %d: %w", i+1, header.Results, err)
+		}
+		results = append(results, fromRemote(rr))
+	}
+	return results, nil
+}
+
+// runServeMode runs the `-serve` mode: it answers requests until interrupted.
+func runServeMode(cfg config) {
+	ln, err := net.Listen("tcp", cfg.serve)
+	if err != nil {
+		fatal("failed to listen", "address", cfg.serve, "error", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stopSignals := handleSignals(cancel)
+	defer stopSignals()
+
+	budget, _ := memoryBudget(cfg.maxMemory) // Validated by parseConfig
+	slog.Info("serving", "address", ln.Addr().String(), "cache_size", cfg.cacheSize, "max_memory", formatBytes(budget))
+	if err := serveRemote(ctx, ln, cfg, NewResultCache(cfg.cacheSize), budget); err != nil {
+		fatal("server stopped", "error", err)
+	}
+	slog.Info("server stopped")
+}
+
+// runConnectMode runs the `-connect` mode: it sends the calculation to the
+// server and displays the results as if they had been computed locally.
+func runConnectMode(cfg config) int {
+	conn, err := net.DialTimeout("tcp", cfg.connect, remoteGrace)
+	if err != nil {
+		fatal("failed to connect", "address", cfg.connect, "error", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(cfg.timeout + remoteGrace))
+
+	slog.Info("calculating remotely", "address", cfg.connect, "n", cfg.n, "algorithms", cfg.algorithms)
+	results, err := requestRemote(conn, newRemoteRequest(cfg))
+	if err != nil {
+		fatal("remote calculation failed", "error", err)
+	}
+	results = reportResults(context.Background(), results, cfg)
+	if cfg.verify {
+		if err := verifyResults(cfg, results); err != nil {
+			fatal("verification failed", "error", err)
+		}
+	}
+	if err := saveAndCompare(cfg, results); err != nil {
+		slog.Error("comparison with the saved result failed", "path", cfg.compareWith, "error", err)
+		return exitMismatch
+	}
+	slog.Info("program finished")
+	return exitStatus(results, failOnDiscrepancy(cfg))
+}
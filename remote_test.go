@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRemoteRoundTrip runs a server on a loopback port and checks that a
+// client request comes back with the same values as a local calculation.
+func TestRemoteRoundTrip(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen on loopback: %v", err)
+	}
+	cfg, err := parseConfig(nil, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- serveRemote(ctx, ln, cfg, NewResultCache(cfg.cacheSize), 0) }()
+	defer func() {
+		cancel()
+		if err := <-served; err != nil {
+			t.Errorf("server returned an error: %v", err)
+		}
+	}()
+
+	request := func(req remoteRequest) ([]Result, error) {
+		conn, err := net.Dial("tcp", ln.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		return requestRemote(conn, req)
+	}
+
+	want, _ := fibFastDoubling(context.Background(), 1000, newIntPool())
+	results, err := request(remoteRequest{N: 1000, Algorithms: "fast,lucas", Timeout: time.Minute, Repeat: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	for _, r := range results {
+		if r.Err != nil || r.Value == nil {
+			t.Fatalf("unexpected failure for %s: %v", r.Name, r.Err)
+		}
+		if r.symbol == "F" && r.Value.Cmp(want) != 0 {
+			t.Errorf("expected F(1000) = %s, but got %s", want, r.Value)
+		}
+	}
+
+	// The same request is answered from the server's cache.
+	results, err = request(remoteRequest{N: 1000, Algorithms: "fast,lucas", Timeout: time.Minute, Repeat: 1})
+	if err != nil || len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d (err=%v)", len(results), err)
+	}
+	for _, r := range results {
+		if !r.cached || r.Err != nil || r.symbol == "F" && r.Value.Cmp(want) != 0 {
+			t.Errorf("expected a cached result for %s, got %+v", r.Name, r)
+		}
+	}
+
+	if _, err := request(remoteRequest{N: 10, Algorithms: "bogus", Timeout: time.Minute}); err == nil {
+		t.Error("expected an error for an unknown algorithm, but got none")
+	}
+	for _, req := range []remoteRequest{
+		{N: 10, Algorithms: "fast", Timeout: time.Minute, Repeat: -1},
+		{N: 10, Algorithms: "fast", Timeout: time.Minute, Repeat: maxRemoteRepeat + 1},
+		{N: 10, Algorithms: "fast", Timeout: time.Minute, Repeat: 1, Concurrency: -1},
+		{N: 10, Algorithms: "fast", Timeout: time.Minute, Repeat: 1, Concurrency: maxRemoteConcurrency + 1},
+	} {
+		if _, err := request(req); err == nil {
+			t.Errorf("expected an error for repeat %d and concurrency %d, but got none", req.Repeat, req.Concurrency)
+		}
+	}
+
+	// A client that sends nothing is disconnected once the read deadline
+	// expires, instead of holding its goroutine.
+	idle, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idle.Close()
+	idle.SetReadDeadline(time.Now().Add(remoteReadTimeout + 5*time.Second))
+	if _, err := idle.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("expected the server to close an idle connection, got %v", err)
+	}
+
+	// A context error keeps its identity across the wire.
+	results, err = request(remoteRequest{N: 10000000, Algorithms: "memo", Timeout: time.Millisecond, Repeat: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 1 || results[0].Err != context.DeadlineExceeded {
+		t.Errorf("expected a deadline error, got %+v", results)
+	}
+}
+
+// TestRemoteMemoryBudget checks that the server refuses the requests whose
+// calculation or warmup exceeds its own memory budget, whatever the client
+// checked.
+func TestRemoteMemoryBudget(t *testing.T) {
+	cfg, err := parseConfig(nil, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := func(req remoteRequest) error {
+		client, server := net.Pipe()
+		defer client.Close()
+		go func() {
+			defer server.Close()
+			_ = handleRemote(context.Background(), server, cfg, nil, 1<<30)
+		}()
+		_, err := requestRemote(client, req)
+		return err
+	}
+
+	// Memoizing F(300000) needs about 3.6 GiB.
+	for _, req := range []remoteRequest{
+		{N: 300000, Algorithms: "memo", Timeout: time.Minute, Repeat: 1},
+		{N: 10, Algorithms: "fast,memo", Timeout: time.Minute, Repeat: 1, Warmup: 300000},
+	} {
+		if err := request(req); err == nil || !strings.Contains(err.Error(), "-max-memory budget of 1.0 GiB") {
+			t.Errorf("n=%d warmup=%d: expected the server to refuse the request, got %v", req.N, req.Warmup, err)
+		}
+	}
+	if err := request(remoteRequest{N: 300000, Algorithms: "fast", Timeout: time.Minute, Repeat: 1}); err != nil {
+		t.Errorf("expected F(300000) by Fast Doubling to fit the budget: %v", err)
+	}
+}
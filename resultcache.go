@@ -0,0 +1,127 @@
+// resultcache.go
+
+package main
+
+import (
+	"container/list"
+	"math/big"
+	"sync"
+	"time"
+)
+
+// ------------------------------------------------------------
+// In-Memory Result Cache (LRU)
+// ------------------------------------------------------------
+//
+// Concept:
+// A long-running process, such as the `-serve` server, often receives the
+// same query again. A ResultCache keeps the last computed values in memory,
+// keyed by the index n, the modulus and the algorithm, so that a repeated
+// query is answered without recomputing. Unlike the on-disk cache of
+// `-cache`, it is bounded: beyond its capacity, the least recently used
+// value is evicted. The options of the algorithms are not part of the key:
+// a cache must only be shared by calculations made with identical options.
+//
+// Implementation:
+// The classic map + doubly linked list: the map finds the entry of a key,
+// and the list orders the entries from the most to the least recently used,
+// so that a lookup, an insertion and an eviction each take O(1). A mutex
+// guards both, the server answering its clients concurrently.
+
+// defaultResultCacheSize is the default capacity of the `-serve` cache.
+const defaultResultCacheSize = 64
+
+// resultKey identifies a cached value. It leaves out the fib options of the
+// algorithms, which are not comparable: a cache is shared only by callers
+// passing the same ones, such as the calls of a `-serve` server, which all
+// use the options of its own flags (see WithResultCache).
+type resultKey struct {
+	n         int
+	mod       uint64
+	algorithm string
+}
+
+// resultEntry is the element stored in the list.
+type resultEntry struct {
+	key   resultKey
+	value *big.Int
+}
+
+// ResultCache is a bounded in-memory cache of computed values, evicting the
+// least recently used one at capacity. A nil *ResultCache is a valid,
+// always empty cache. It is safe for concurrent use.
+type ResultCache struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List // Most recently used at the front
+	entries  map[resultKey]*list.Element
+}
+
+// NewResultCache returns a cache holding at most capacity values, or nil,
+// the disabled cache, when capacity is 0 or less.
+func NewResultCache(capacity int) *ResultCache {
+	if capacity <= 0 {
+		return nil
+	}
+	return &ResultCache{capacity: capacity, order: list.New(), entries: make(map[resultKey]*list.Element)}
+}
+
+// get returns the cached value of key and marks it as the most recently
+// used. The value is shared: callers must not modify it.
+func (c *ResultCache) get(key resultKey) (*big.Int, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*resultEntry).value, true
+}
+
+// add stores the value of key as the most recently used, evicting the least
+// recently used value if the cache is full.
+func (c *ResultCache) add(key resultKey, v *big.Int) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[key]; ok {
+		e.Value.(*resultEntry).value = v
+		c.order.MoveToFront(e)
+		return
+	}
+	if c.order.Len() >= c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*resultEntry).key)
+	}
+	c.entries[key] = c.order.PushFront(&resultEntry{key, v})
+}
+
+// lookup splits tasks into the results found in the cache, flagged as
+// cached with the duration of the lookup, and the tasks left to run.
+func (c *ResultCache) lookup(tasks []task, n int, mod uint64) (hits []Result, misses []task) {
+	for _, t := range tasks {
+		start := time.Now()
+		if v, ok := c.get(resultKey{n, mod, t.name}); ok {
+			hits = append(hits, Result{Name: t.name, symbol: t.symbol, Value: v, Duration: time.Since(start), cached: true})
+			continue
+		}
+		misses = append(misses, t)
+	}
+	return hits, misses
+}
+
+// store caches the value of every successful result of F(n) (mod m).
+func (c *ResultCache) store(results []Result, n int, mod uint64) {
+	for _, r := range results {
+		if r.Err == nil && !r.cached {
+			c.add(resultKey{n, mod, r.Name}, r.Value)
+		}
+	}
+}
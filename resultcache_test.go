@@ -0,0 +1,100 @@
+// resultcache_test.go
+
+package main
+
+import (
+	"context"
+	"math/big"
+	"testing"
+)
+
+// TestResultCacheEviction fills a cache of capacity 2 and checks that the
+// least recently used value, a lookup counting as a use, is evicted first.
+func TestResultCacheEviction(t *testing.T) {
+	c := NewResultCache(2)
+	a, b, d := resultKey{1, 0, "A"}, resultKey{2, 0, "A"}, resultKey{3, 0, "A"}
+	c.add(a, big.NewInt(1))
+	c.add(b, big.NewInt(2))
+	if _, ok := c.get(a); !ok { // a becomes the most recently used
+		t.Fatal("expected a hit for a")
+	}
+	c.add(d, big.NewInt(3))
+	if _, ok := c.get(b); ok {
+		t.Error("expected b, the least recently used, to be evicted")
+	}
+	for _, key := range []resultKey{a, d} {
+		if _, ok := c.get(key); !ok {
+			t.Errorf("expected a hit for %+v", key)
+		}
+	}
+
+	// Replacing a value evicts nothing.
+	c.add(a, big.NewInt(10))
+	if v, ok := c.get(a); !ok || v.Int64() != 10 || c.order.Len() != 2 {
+		t.Errorf("expected a to be replaced in place, got %v (%d entries)", v, c.order.Len())
+	}
+	if _, ok := c.get(d); !ok {
+		t.Error("expected d to survive the replacement of a")
+	}
+
+	// The modulus and the algorithm are part of the key.
+	for _, key := range []resultKey{{1, 7, "A"}, {1, 0, "B"}} {
+		if _, ok := c.get(key); ok {
+			t.Errorf("unexpected hit for %+v", key)
+		}
+	}
+
+	// A zero capacity disables the cache, which then finds nothing.
+	var disabled *ResultCache = NewResultCache(0)
+	disabled.add(a, big.NewInt(1))
+	if _, ok := disabled.get(a); disabled != nil || ok {
+		t.Error("expected a disabled cache")
+	}
+}
+
+// TestComputeResultCache checks that a second Compute of the same n is
+// answered from cache, flagged as cached, and that a miss still computes.
+func TestComputeResultCache(t *testing.T) {
+	ctx := context.Background()
+	cache := NewResultCache(8)
+	first, err := Compute(ctx, 1000, WithAlgorithms("fast,lucas"), WithResultCache(cache))
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Compute(ctx, 1000, WithAlgorithms("fast,lucas"), WithResultCache(cache))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first) != 2 || len(second) != 2 {
+		t.Fatalf("expected 2 results twice, got %d and %d", len(first), len(second))
+	}
+	computed := make(map[string]Result)
+	for _, r := range first {
+		computed[r.Name] = r
+	}
+	for _, r := range second {
+		miss := computed[r.Name]
+		if miss.cached || !r.cached || resultStatus(r) != "Cached" {
+			t.Errorf("%s: expected a miss then a hit, got cached=%v then %v", r.Name, miss.cached, r.cached)
+		}
+		if miss.Value == nil || r.Value.Cmp(miss.Value) != 0 {
+			t.Errorf("%s: the cached value differs from the computed one", r.Name)
+		}
+	}
+
+	results, err := Compute(ctx, 1000, WithAlgorithms("fast,matrix"), WithModulus(1000), WithResultCache(cache))
+	if err != nil || len(results) != 2 || results[0].cached || results[1].cached {
+		t.Errorf("expected misses for another modulus, got %+v (err=%v)", results, err)
+	}
+
+	// With -only-fastest, a hit wins the race and the others do not run.
+	results, err = Compute(ctx, 1000, WithAlgorithms("fast,matrix"), WithOnlyFastest(), WithResultCache(cache))
+	if err != nil || len(results) != 2 {
+		t.Fatalf("expected 2 results, got %+v (err=%v)", results, err)
+	}
+	for _, r := range results {
+		if r.Name == "Fast Doubling" && !r.cached || r.Name == "Matrix" && !r.outrun {
+			t.Errorf("expected a cached Fast Doubling and an outrun Matrix, got %+v", r)
+		}
+	}
+}
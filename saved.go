@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"os"
+)
+
+// ------------------------------------------------------------
+// Saved Results (-save, -compare-with)
+// ------------------------------------------------------------
+//
+// Concept:
+// Regression testing across versions needs a reference value produced by a
+// trusted build. `-save file` stores the winning value of a run gob-encoded,
+// together with the sequence, n and modulus it belongs to; `-compare-with file`
+// loads it and requires the freshly computed value to be equal, digit for
+// digit. On mismatch, the position of the first differing digit is reported,
+// which tells a wrong last carry from a value that is wrong from the start.
+
+// savedResult is the content of a `-save` file.
+type savedResult struct {
+	Symbol string   // Notation of the sequence, e.g. "F", "L" or "G[2,1]" (see sequenceKey)
+	N      int      // Index of the term
+	Mod    uint64   // Modulus of modular mode, 0 when disabled
+	Value  *big.Int // Value of the term
+}
+
+// errSavedMismatch reports a value differing from the `-compare-with` file.
+var errSavedMismatch = errors.New("result differs from the saved value")
+
+// winningResult returns the saved form of the winning result: the first
+// success of the sorted results, that is the fastest one.
+func winningResult(cfg config, results []Result) (savedResult, bool) {
+	for _, r := range results {
+		if r.Err == nil && r.Value != nil {
+			return savedResult{Symbol: sequenceKey(cfg, r.symbol), N: cfg.n, Mod: cfg.mod, Value: r.Value}, true
+		}
+	}
+	return savedResult{}, false
+}
+
+// writeSavedResult writes s to path, which is created or truncated.
+func writeSavedResult(path string, s savedResult) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := gob.NewEncoder(f).Encode(s); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// readSavedResult reads a file written by writeSavedResult.
+func readSavedResult(path string) (savedResult, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return savedResult{}, err
+	}
+	defer f.Close()
+
+	var s savedResult
+	if err := gob.NewDecoder(f).Decode(&s); err != nil {
+		return savedResult{}, fmt.Errorf("invalid saved result %s: %w", path, err)
+	}
+	if s.Value == nil {
+		return savedResult{}, fmt.Errorf("invalid saved result %s: no value", path)
+	}
+	return s, nil
+}
+
+// compareWithSaved compares the computed result got with the saved one. A
+// saved result of another term is an error of its own; a different value
+// wraps errSavedMismatch and locates the first differing digit.
+func compareWithSaved(got, saved savedResult) error {
+	if got.Symbol != saved.Symbol || got.N != saved.N || got.Mod != saved.Mod {
+		return fmt.Errorf("the saved result is %s, not %s", describeTerm(saved), describeTerm(got))
+	}
+	if got.Value.Cmp(saved.Value) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", errSavedMismatch, describeDifference(got.Value, saved.Value))
+}
+
+// describeTerm renders the term held by s, e.g. "F(500)" or "F(500) mod 97".
+func describeTerm(s savedResult) string {
+	if s.Mod > 0 {
+		return fmt.Sprintf("%s(%d) mod %d", s.Symbol, s.N, s.Mod)
+	}
+	return fmt.Sprintf("%s(%d)", s.Symbol, s.N)
+}
+
+// describeDifference locates where two different values first differ, in
+// decimal digits counted from the most significant one.
+func describeDifference(got, want *big.Int) string {
+	if got.Sign() != want.Sign() {
+		return fmt.Sprintf("the signs differ (%d instead of %d)", got.Sign(), want.Sign())
+	}
+	g := new(big.Int).Abs(got).Text(10)
+	w := new(big.Int).Abs(want).Text(10)
+	pos := 0
+	for pos < len(g) && pos < len(w) && g[pos] == w[pos] {
+		pos++
+	}
+	if len(g) != len(w) {
+		return fmt.Sprintf("%d digits instead of %d, first difference at digit %d", len(g), len(w), pos+1)
+	}
+	return fmt.Sprintf("first difference at digit %d of %d ('%c' instead of '%c')", pos+1, len(w), g[pos], w[pos])
+}
+
+// saveAndCompare applies `-compare-with`, then `-save`, to the winning result.
+// The comparison comes first so that both flags may name the same file. It
+// returns an error wrapping errSavedMismatch when the values differ; any
+// other failure is fatal.
+func saveAndCompare(cfg config, results []Result) error {
+	if cfg.save == "" && cfg.compareWith == "" {
+		return nil
+	}
+	got, ok := winningResult(cfg, results)
+	if !ok {
+		slog.Warn("no successful result to save or compare")
+		return nil
+	}
+	if cfg.compareWith != "" {
+		saved, err := readSavedResult(cfg.compareWith)
+		if err != nil {
+			fatal("failed to read the saved result", "path", cfg.compareWith, "error", err)
+		}
+		if err := compareWithSaved(got, saved); err != nil {
+			if !errors.Is(err, errSavedMismatch) {
+				fatal("cannot compare with the saved result", "path", cfg.compareWith, "error", err)
+			}
+			return err
+		}
+		slog.Info("result matches the saved value", "path", cfg.compareWith, "term", describeTerm(got))
+	}
+	if cfg.save != "" {
+		if err := writeSavedResult(cfg.save, got); err != nil {
+			fatal("failed to save the result", "path", cfg.save, "error", err)
+		}
+		slog.Info("result saved", "path", cfg.save, "term", describeTerm(got))
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+// saved_test.go
+
+package main
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/agbruneau/FibJule/fib"
+)
+
+// TestCompareWithSaved saves F(500), then compares a fresh F(500) with it and
+// with a copy corrupted in a single digit, which must be located.
+func TestCompareWithSaved(t *testing.T) {
+	v, err := fib.FastDoubling(context.Background(), 500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := config{n: 500}
+	got, ok := winningResult(cfg, []Result{
+		{Name: "Matrix", symbol: "F", Err: errors.New("timeout")},
+		{Name: "Fast Doubling", symbol: "F", Value: v},
+	})
+	if !ok || got.Value != v {
+		t.Fatalf("expected the first success to win, got %+v", got)
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "f500.gob")
+	if err := writeSavedResult(path, got); err != nil {
+		t.Fatal(err)
+	}
+	saved, err := readSavedResult(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := compareWithSaved(got, saved); err != nil {
+		t.Errorf("expected the saved F(500) to match, got %v", err)
+	}
+
+	// F(500) has 105 digits: adding 10^94 changes the 11th one.
+	corrupted := savedResult{Symbol: "F", N: 500, Value: new(big.Int).Add(v, new(big.Int).Exp(big.NewInt(10), big.NewInt(94), nil))}
+	corruptedPath := filepath.Join(dir, "corrupted.gob")
+	if err := writeSavedResult(corruptedPath, corrupted); err != nil {
+		t.Fatal(err)
+	}
+	if saved, err = readSavedResult(corruptedPath); err != nil {
+		t.Fatal(err)
+	}
+	err = compareWithSaved(got, saved)
+	if !errors.Is(err, errSavedMismatch) || !strings.Contains(err.Error(), "digit 11 of 105") {
+		t.Errorf("expected a mismatch at digit 11 of 105, got %v", err)
+	}
+
+	// A file saved for another term is not a mismatch but an error.
+	other := savedResult{Symbol: "F", N: 501, Value: v}
+	if err := compareWithSaved(got, other); err == nil || errors.Is(err, errSavedMismatch) {
+		t.Errorf("expected an error for F(501), got %v", err)
+	}
+
+	// Neither is a file saved for another recurrence, both displayed as G.
+	g := []Result{{Name: "Recurrence", symbol: "G", Value: big.NewInt(123)}}
+	pell, _ := winningResult(config{n: 10, recurrence: "2,1"}, g)
+	tribonacci, _ := winningResult(config{n: 10, recurrence: "1,1,1"}, g)
+	if err := compareWithSaved(tribonacci, pell); err == nil || !strings.Contains(err.Error(), "G[2,1;0,1](10)") {
+		t.Errorf("expected an error naming the saved G[2,1;0,1](10), got %v", err)
+	}
+}
@@ -0,0 +1,101 @@
+// selftest.go
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"os"
+	"strings"
+)
+
+// ------------------------------------------------------------
+// Self-Test (-selftest)
+// ------------------------------------------------------------
+//
+// Concept:
+// A packaged binary ships without its tests. `-selftest` runs every
+// algorithm at a few small indices and checks the results against values
+// written down here, independently of any algorithm, with the same
+// cross-validation as a normal run: the expected values simply take part in
+// it as one more result per sequence. It needs no network and completes in
+// milliseconds.
+
+// selftestCases are the indices computed by the self-test, with the expected
+// term of each sequence symbol.
+var selftestCases = []struct {
+	n        int
+	expected map[string]string
+}{
+	{50, map[string]string{
+		"F": "12586269025",
+		"L": "28143753123",
+		"T": "3122171529233",
+	}},
+	{200, map[string]string{
+		"F": "280571172992510140037611932413038677189525",
+		"L": "627376215338105766356982006981782561278127",
+		"T": "15555116989073938986569525465884451018665640926743832",
+	}},
+}
+
+// selftestExpected is the name of the results holding the expected values.
+const selftestExpected = "Expected"
+
+// selfTestTasks returns the tasks of the self-test: every registered
+// algorithm, and the iterative method of `-algorithms auto`.
+func selfTestTasks() []task {
+	available, order := registeredTasks(nil)
+	tasks, _ := selectTasks("all", available, order)
+	iterative, _ := autoTask(0, nil)
+	return append(tasks, iterative)
+}
+
+// runSelfTest computes every index of selftestCases with the tasks, writes
+// one status line per index to w and, on failure, the details: the tasks
+// that failed and the fingerprints of the disagreeing values (see
+// writeDiscrepancies). It reports whether every result matched.
+func runSelfTest(ctx context.Context, w io.Writer, tasks []task) bool {
+	passed := true
+	for _, c := range selftestCases {
+		results := sortResults(computeTasks(ctx, tasks, c.n, runOptions{}))
+		var failed []string
+		for _, r := range results {
+			if r.Err != nil {
+				failed = append(failed, fmt.Sprintf("%s (%v)", r.Name, r.Err))
+			}
+		}
+		for symbol, text := range c.expected {
+			v, _ := new(big.Int).SetString(text, 10)
+			results = append(results, Result{Name: selftestExpected, symbol: symbol, Value: v})
+		}
+
+		if _, ok := crossValidate(results); ok && len(failed) == 0 {
+			fmt.Fprintf(w, "Self-test n=%d: OK (%d algorithms)\n", c.n, len(tasks))
+			continue
+		}
+		passed = false
+		fmt.Fprintf(w, "Self-test n=%d: FAILED\n", c.n)
+		if len(failed) > 0 {
+			fmt.Fprintf(w, "  Failed: %s\n", strings.Join(failed, ", "))
+		}
+		writeDiscrepancies(w, results)
+	}
+	return passed
+}
+
+// runSelfTestMode runs the `-selftest` mode within the global timeout.
+func runSelfTestMode(cfg config) int {
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.timeout)
+	defer cancel()
+
+	if !runSelfTest(ctx, os.Stdout, selfTestTasks()) {
+		slog.Error("self-test failed")
+		return exitDiscrepancy
+	}
+	slog.Info("self-test passed")
+	return exitOK
+}
@@ -0,0 +1,46 @@
+// selftest_test.go
+
+package main
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// TestSelfTest runs the self-test with the real algorithms, which must pass,
+// then with a task off by one and a failing one, which must be reported.
+func TestSelfTest(t *testing.T) {
+	var out strings.Builder
+	if !runSelfTest(context.Background(), &out, selfTestTasks()) {
+		t.Fatalf("expected the self-test to pass, got:\n%s", out.String())
+	}
+	if got := strings.Count(out.String(), ": OK"); got != len(selftestCases) {
+		t.Errorf("expected %d OK lines, got:\n%s", len(selftestCases), out.String())
+	}
+
+	offByOne := func(ctx context.Context, n int, pool *sync.Pool) (*big.Int, error) {
+		v, err := fibFastDoubling(ctx, n, pool)
+		return v.Add(v, big.NewInt(1)), err
+	}
+	failing := func(context.Context, int, *sync.Pool) (*big.Int, error) {
+		return nil, errors.New("broken")
+	}
+	tasks := []task{
+		{name: "Fast Doubling", symbol: "F", fn: fibFastDoubling},
+		{name: "Off By One", symbol: "F", fn: offByOne},
+		{name: "Broken", symbol: "F", fn: failing},
+	}
+	out.Reset()
+	if runSelfTest(context.Background(), &out, tasks) {
+		t.Fatal("expected the self-test to fail")
+	}
+	for _, want := range []string{"Self-test n=50: FAILED", "Failed: Broken (broken)", "Off By One", selftestExpected} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected %q in the report, got:\n%s", want, out.String())
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ------------------------------------------------------------
+// Graceful Interruption (SIGINT / SIGTERM)
+// ------------------------------------------------------------
+//
+// Concept:
+// The first Ctrl-C does not kill the process: it cancels the shared context,
+// the algorithms notice it cooperatively and return `ctx.Err()`, the progress
+// printer finishes its line, and the usual summary reports whatever completed.
+// A second Ctrl-C within forceExitWindow exits immediately, for calculations
+// stuck in a long multiplication between two cancellation checks.
+
+// forceExitWindow is the delay during which a second signal forces an exit.
+const forceExitWindow = 2 * time.Second
+
+// forceExitCode is the conventional exit status for a process killed by SIGINT.
+const forceExitCode = 130
+
+// handleSignals installs a SIGINT/SIGTERM handler calling cancel. The returned
+// function uninstalls the handler and waits for its goroutine to exit; it must
+// be called on normal completion to avoid leaking the goroutine.
+func handleSignals(cancel context.CancelFunc) (stop func()) {
+	sigCh := make(chan os.Signal, 2)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		watchSignals(sigCh, done, cancel, func() {
+			flushProfiles()
+			os.Exit(forceExitCode)
+		})
+	}()
+
+	return func() {
+		signal.Stop(sigCh)
+		close(done)
+		wg.Wait()
+	}
+}
+
+// watchSignals reacts to the signals received on sigCh until done is closed:
+// the first one calls cancel, a second one within forceExitWindow calls
+// forceExit. It is separated from handleSignals so it can be tested with
+// fake signals.
+func watchSignals(sigCh <-chan os.Signal, done <-chan struct{}, cancel context.CancelFunc, forceExit func()) {
+	var last time.Time
+	for {
+		select {
+		case <-done:
+			return
+		case sig := <-sigCh:
+			if !last.IsZero() && time.Since(last) <= forceExitWindow {
+				slog.Warn("signal received again, exiting immediately", "signal", sig)
+				forceExit()
+				return
+			}
+			last = time.Now()
+			slog.Warn("signal received, cancelling calculations (repeat to force exit)", "signal", sig, "force_exit_window", forceExitWindow)
+			cancel()
+		}
+	}
+}
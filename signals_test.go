@@ -0,0 +1,63 @@
+// signals_test.go
+
+package main
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestWatchSignals checks that a first signal cancels the context, a second
+// one forces the exit, and that closing done stops the watcher.
+func TestWatchSignals(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigCh := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	forced := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		watchSignals(sigCh, done, cancel, func() { close(forced) })
+	}()
+
+	sigCh <- os.Interrupt
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("the first signal did not cancel the context")
+	}
+
+	sigCh <- os.Interrupt
+	select {
+	case <-forced:
+	case <-time.After(time.Second):
+		t.Fatal("the second signal did not force the exit")
+	}
+	<-finished
+
+	// Without any signal, closing done must stop the watcher.
+	done = make(chan struct{})
+	finished = make(chan struct{})
+	go func() {
+		defer close(finished)
+		watchSignals(make(chan os.Signal), done, func() {}, func() {})
+	}()
+	close(done)
+	select {
+	case <-finished:
+	case <-time.After(time.Second):
+		t.Fatal("the watcher did not stop after done was closed")
+	}
+}
+
+// TestHandleSignalsStop checks that the handler can be installed and torn down.
+func TestHandleSignalsStop(t *testing.T) {
+	_, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stop := handleSignals(cancel)
+	stop() // Must return promptly, without leaking the goroutine
+}
@@ -2,11 +2,18 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
-	"math/big"
+	"io"
+	"log/slog"
+	"math"
+	"os"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/agbruneau/FibJule/fib"
 )
 
 // ------------------------------------------------------------
@@ -15,11 +22,81 @@ import (
 
 const progressRefreshInterval = 100 * time.Millisecond
 
+// progressLogInterval is the minimum delay between two progress lines of a
+// task when the animation is replaced by plain log lines.
+const progressLogInterval = 5 * time.Second
+
+// Progress display modes accepted by the `-progress` flag.
+const (
+	progressAuto   = "auto"   // Animate on a terminal, log periodic lines otherwise
+	progressAlways = "always" // Always animate, even when stdout is redirected
+	progressLog    = "log"    // Always log periodic timestamped lines
+	progressNever  = "never"  // Display no progress at all
+)
+
 // progressData encapsulates progress information for a task.
 // This is the canonical definition.
 type progressData struct {
 	name string  // Name of the task
 	pct  float64 // Percentage of progress
+	rep  int     // Repetition in flight (1-based) with `-repeat`, 0 otherwise
+	reps int     // Total number of repetitions with `-repeat`, 0 otherwise
+}
+
+// ProgressReporter receives the progress of running algorithms, identified by
+// their display name, as a percentage from 0 to 100. Report is called from the
+// goroutine running the algorithm, so an implementation shared by concurrent
+// tasks must be safe for concurrent use.
+//
+// A reporter travels on the context (see WithProgressReporter), which keeps
+// it out of the fibFunc signature: without one, the algorithms simply report
+// nothing. The CLI feeds its progress display through channelReporter; a
+// program calling Compute can plug in its own UI the same way.
+type ProgressReporter interface {
+	Report(task string, pct float64)
+}
+
+// progressReporterKey is the context key of the ProgressReporter.
+type progressReporterKey struct{}
+
+// WithProgressReporter returns a copy of ctx carrying r, to which the
+// algorithms run with that context report their progress.
+func WithProgressReporter(ctx context.Context, r ProgressReporter) context.Context {
+	return context.WithValue(ctx, progressReporterKey{}, r)
+}
+
+// progressReporter returns the ProgressReporter carried by ctx, if any.
+func progressReporter(ctx context.Context) (ProgressReporter, bool) {
+	r, ok := ctx.Value(progressReporterKey{}).(ProgressReporter)
+	return r, ok && r != nil
+}
+
+// channelReporter is the ProgressReporter of the CLI: it forwards each update
+// to the channel read by progressPrinter or progressLogger.
+type channelReporter chan<- progressData
+
+// Report sends the update on the channel.
+func (c channelReporter) Report(task string, pct float64) {
+	c <- progressData{name: task, pct: pct}
+}
+
+// repetitionReporter reports the progress of repetition rep (0-based) out of
+// reps with `-repeat`, converted to the overall progress of the task. To a
+// channelReporter, the updates are also tagged with the repetition, which the
+// display shows next to the task name.
+type repetitionReporter struct {
+	next      ProgressReporter
+	rep, reps int
+}
+
+// Report forwards the overall progress to the wrapped reporter.
+func (r repetitionReporter) Report(task string, pct float64) {
+	overall := (float64(r.rep)*100 + pct) / float64(r.reps)
+	if c, ok := r.next.(channelReporter); ok {
+		c <- progressData{name: task, pct: overall, rep: r.rep + 1, reps: r.reps}
+		return
+	}
+	r.next.Report(task, overall)
 }
 
 // progressPrinter manages consolidated progress display for all tasks.
@@ -30,11 +107,10 @@ type progressData struct {
 // It collects percentages from each task and refreshes a single line
 // on the terminal to display the overall status. The `\r` (carriage return) trick
 // allows rewriting on the same line, creating a smooth progress animation.
-func progressPrinter(ctx context.Context, progress <-chan progressData, taskNames []string) {
-	status := make(map[string]float64)
-	for _, name := range taskNames {
-		status[name] = 0.0 // Initialize progress of each task to 0%
-	}
+// Each task is drawn as a bar of barWidth cells followed by its estimated
+// remaining time.
+func progressPrinter(ctx context.Context, progress <-chan progressData, taskNames []string, barWidth int) {
+	status := newProgressStatus(taskNames)
 
 	ticker := time.NewTicker(progressRefreshInterval)
 	defer ticker.Stop()
@@ -43,69 +119,243 @@ func progressPrinter(ctx context.Context, progress <-chan progressData, taskName
 		select {
 		case p, ok := <-progress:
 			if !ok { // Channel is closed, signifies end of progress updates.
-				printStatus(status, taskNames) // Print one last time
-				fmt.Println()                  // Move to a new line after all progress is done
+				printStatus(status, taskNames, barWidth) // Print one last time
+				fmt.Println()                            // Move to a new line after all progress is done
 				return
 			}
-			status[p.name] = p.pct
-			printStatus(status, taskNames) // Print current status
+			applyProgress(status, p, time.Now())
+			printStatus(status, taskNames, barWidth) // Print current status
 
 		case <-ticker.C:
 			// Periodically refresh display to show the program is still active,
 			// even if no new progress updates have been received.
-			printStatus(status, taskNames)
+			printStatus(status, taskNames, barWidth)
 
 		case <-ctx.Done():
 			// Main context is done (e.g., timeout or cancellation), stop displaying.
 			// Print one last status before exiting, then a newline.
-			printStatus(status, taskNames)
+			printStatus(status, taskNames, barWidth)
 			fmt.Println()
 			return
 		}
 	}
 }
 
-// printStatus displays the current progress status for each task on a single line.
-func printStatus(status map[string]float64, keys []string) {
-	var b strings.Builder
-	b.WriteString("\r") // Carriage return to overwrite the previous line
+// progressLogger is the non-interactive counterpart of progressPrinter, used
+// with `-progress log` or when stdout is not a terminal. Instead of rewriting
+// a line with `\r`, it writes ordinary timestamped lines to w, such as
+// `[12:00:03] Fast Doubling 45.2%`, coalescing the updates of each task so
+// that it appears at most once per interval (see progressLines).
+func progressLogger(ctx context.Context, progress <-chan progressData, taskNames []string, w io.Writer, interval time.Duration) {
+	l := newProgressLines(w, taskNames, interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case p, ok := <-progress:
+			if !ok {
+				return
+			}
+			l.update(p, time.Now())
+
+		case at := <-ticker.C:
+			// Log the updates held back since the last line of their task.
+			l.flush(at)
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// progressEvent is the JSON form of a progress update written by
+// progressEvents, e.g. {"task":"Fast Doubling","pct":42.5}.
+type progressEvent struct {
+	Task string  `json:"task"`
+	Pct  float64 `json:"pct"`            // Rounded to one decimal
+	Rep  int     `json:"rep,omitempty"`  // Repetition in flight with `-repeat`
+	Reps int     `json:"reps,omitempty"` // Total number of repetitions with `-repeat`
+}
+
+// progressEvents is the machine-readable counterpart of progressPrinter, used
+// with `-progress-fd`: it writes every update to w as one JSON object per
+// line (JSON Lines), for a parent process driving its own progress widget.
+// After a failed write, the remaining updates are still drained, so that
+// the tasks never block, but discarded.
+func progressEvents(ctx context.Context, progress <-chan progressData, w io.Writer) {
+	enc := json.NewEncoder(w)
+	failed := false
+	for {
+		select {
+		case p, ok := <-progress:
+			if !ok {
+				return
+			}
+			if failed {
+				continue
+			}
+			if err := enc.Encode(progressEvent{Task: p.name, Pct: math.Round(p.pct*10) / 10, Rep: p.rep, Reps: p.reps}); err != nil {
+				slog.Warn("failed to write progress events, giving up", "error", err)
+				failed = true
+			}
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// progressLines renders progress as plain lines for progressLogger. A task's
+// first update is written at once; later ones are held back until interval
+// has elapsed since its previous line, then written by update or flush, so
+// that fast updates cost at most one line per interval.
+type progressLines struct {
+	w        io.Writer
+	interval time.Duration
+	order    []string // Task names, in the order flush visits them
+	tasks    map[string]*loggedProgress
+}
+
+// loggedProgress is the latest progress of a task and the state of its lines.
+type loggedProgress struct {
+	taskProgress
+	logged  time.Time // When the last line was written, zero before the first
+	pending bool      // The progress changed since that line
+}
+
+// newProgressLines returns a progressLines for the given tasks, none logged yet.
+func newProgressLines(w io.Writer, taskNames []string, interval time.Duration) *progressLines {
+	tasks := make(map[string]*loggedProgress, len(taskNames))
+	for _, name := range taskNames {
+		tasks[name] = &loggedProgress{}
+	}
+	return &progressLines{w: w, interval: interval, order: taskNames, tasks: tasks}
+}
+
+// update records p, received at the given time, and writes it if its task
+// has not been logged for an interval. Updates of unknown tasks are ignored.
+func (l *progressLines) update(p progressData, at time.Time) {
+	t, known := l.tasks[p.name]
+	if !known {
+		return
+	}
+	t.pct, t.rep, t.reps = p.pct, p.rep, p.reps
+	t.pending = true
+	if at.Sub(t.logged) >= l.interval {
+		l.write(p.name, t, at)
+	}
+}
+
+// flush writes the held-back progress of the tasks whose interval elapsed.
+func (l *progressLines) flush(at time.Time) {
+	for _, name := range l.order {
+		if t := l.tasks[name]; t.pending && at.Sub(t.logged) >= l.interval {
+			l.write(name, t, at)
+		}
+	}
+}
+
+// write writes the progress line of a task, timestamped with at.
+func (l *progressLines) write(name string, t *loggedProgress, at time.Time) {
+	fmt.Fprintf(l.w, "[%s] %s %.1f%%\n", at.Format(time.TimeOnly), t.label(name), t.pct)
+	t.logged, t.pending = at, false
+}
+
+// isTerminal reports whether f is attached to a terminal (character device)
+// rather than redirected to a file or a pipe.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
 
+// newProgressStatus returns the progress of each task, all at 0%.
+func newProgressStatus(taskNames []string) map[string]*taskProgress {
+	status := make(map[string]*taskProgress, len(taskNames))
+	for _, name := range taskNames {
+		status[name] = &taskProgress{}
+	}
+	return status
+}
+
+// applyProgress records p, received at the given time, as the latest
+// progress of its task. Updates of unknown tasks are ignored.
+func applyProgress(status map[string]*taskProgress, p progressData, at time.Time) {
+	if s, known := status[p.name]; known {
+		s.update(at, p.pct)
+		s.rep, s.reps = p.rep, p.reps
+	}
+}
+
+// formatStatus renders the progress of every task, in the order of keys, as
+// one consolidated line such as `Fast Doubling  80.0% ETA 1s | Matrix  55.0% ETA 3s`.
+func formatStatus(status map[string]*taskProgress, keys []string, barWidth int) string {
+	parts := make([]string, len(keys))
 	for i, k := range keys {
-		if i > 0 {
-			b.WriteString("   ") // Separator between tasks
+		eta, known := status[k].eta()
+		parts[i] = renderBar(status[k].label(k), status[k].pct, barWidth, eta, known)
+	}
+	return strings.Join(parts, " | ")
+}
+
+// printStatus displays the current progress status for each task on a single line.
+func printStatus(status map[string]*taskProgress, keys []string, barWidth int) {
+	// The carriage return overwrites the previous line; the final escape
+	// sequence erases any remnants of a longer one (e.g. a wider ETA).
+	fmt.Print("\r" + formatStatus(status, keys, barWidth) + "\033[K")
+}
+
+// formatBytes renders a byte count in human-readable binary units (B, KiB, MiB, GiB).
+func formatBytes(b uint64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+	div, exp := uint64(unit), 0
+	for v := b / unit; v >= unit && exp < 2; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(b)/float64(div), "KMG"[exp])
+}
+
+// byteUnits are the suffixes accepted by parseByteSize, by power of 1024.
+var byteUnits = []string{"B", "KiB", "MiB", "GiB", "TiB"}
+
+// parseByteSize parses a byte count written as an integer optionally
+// followed by a binary unit, e.g. "4096", "512MiB" or "8 GiB". The units are
+// case-insensitive.
+func parseByteSize(s string) (uint64, error) {
+	text := strings.TrimSpace(s)
+	shift := 0
+	for i := len(byteUnits) - 1; i >= 0; i-- {
+		unit := byteUnits[i]
+		if len(text) > len(unit) && strings.EqualFold(text[len(text)-len(unit):], unit) {
+			text = strings.TrimSpace(text[:len(text)-len(unit)])
+			shift = 10 * i
+			break
 		}
-		// Format string for aligned display: Task Name: XX.YY%
-		fmt.Fprintf(&b, "%-15s %6.2f%%", k+":", status[k])
 	}
-	// Add trailing spaces to clear any remnants of a longer previous line.
-	// Adjust the number of spaces if task names or formatting changes significantly.
-	b.WriteString("                    ") // Increased padding
-	fmt.Print(b.String())
+	v, err := strconv.ParseUint(text, 10, 64)
+	if err != nil || v > math.MaxUint64>>shift {
+		return 0, fmt.Errorf("invalid size %q (expected a byte count with an optional unit B, KiB, MiB, GiB or TiB, e.g. 8GiB)", s)
+	}
+	return v << shift, nil
 }
 
 // ------------------------------------------------------------
 // *big.Int Object Pool for Memory Reuse
 // ------------------------------------------------------------
-//
-// Memory Optimization Concept (sync.Pool):
-// Calculations for large Fibonacci numbers require handling integers
-// that exceed the capacity of standard types (e.g., int64). Go's `math/big.Int` is used.
-// The problem: Creating numerous `big.Int` objects, especially in loops for complex
-// algorithms, puts significant pressure on the Garbage Collector (GC). Frequent GC cycles
-// can pause the program and degrade performance.
-// The solution: A `sync.Pool` provides a way to reuse objects that are otherwise
-// short-lived. Instead of allocating a new `big.Int` each time one is needed,
-// the program requests one from the pool. After the object is used, it's returned
-// to the pool. This drastically reduces the number of allocations and, consequently,
-// the GC overhead, leading to improved performance for memory-intensive operations.
-
-// newIntPool creates a new sync.Pool specifically for *big.Int objects.
-// The New function in the pool is called when Get is invoked on an empty pool.
+
+// newIntPool creates a new sync.Pool for *big.Int objects.
+// See fib.NewIntPool for the rationale behind pooling.
 func newIntPool() *sync.Pool {
-	return &sync.Pool{
-		New: func() interface{} {
-			// Allocate a new *big.Int instance when the pool is empty.
-			return new(big.Int)
-		},
-	}
+	return fib.NewIntPool()
+}
+
+// newSizedIntPool creates a sync.Pool whose *big.Int objects start with room
+// for F(n), for a task computing F(n) in full. See fib.NewSizedIntPool.
+func newSizedIntPool(n int) *sync.Pool {
+	return fib.NewSizedIntPool(n)
 }
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/agbruneau/FibJule/fib"
+)
+
+// ------------------------------------------------------------
+// Independent Verification (-verify)
+// ------------------------------------------------------------
+//
+// Cross-validation only compares the selected algorithms against each other,
+// so a bug they share would go unnoticed. `-verify` recomputes F(n) with the
+// naive iterative method, which shares no identity or code with the doubling
+// algorithms, and compares every F(n) result with it.
+
+// verifyWarnThreshold is the index above which the O(n) reference becomes
+// slow enough (seconds to minutes) to deserve a warning.
+const verifyWarnThreshold = 200000
+
+// verifyResults compares every successful F(n) result with the iterative
+// reference. It returns an error on mismatch, or when the reference could not
+// complete within the configured timeout.
+func verifyResults(cfg config, results []Result) error {
+	var toCheck []Result
+	for _, r := range results {
+		if r.symbol == "F" && r.Err == nil && r.Value != nil {
+			toCheck = append(toCheck, r)
+		}
+	}
+	if len(toCheck) == 0 {
+		slog.Warn("-verify: no successful F(n) result to verify")
+		return nil
+	}
+	if cfg.n > verifyWarnThreshold {
+		slog.Warn("-verify uses an O(n) reference; this may take a long time", "n", cfg.n)
+	}
+
+	// The reference gets its own budget: the global one may be nearly spent.
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.timeout)
+	defer cancel()
+
+	start := time.Now()
+	var reference *big.Int
+	var err error
+	if cfg.mod > 0 {
+		reference, err = fib.IterativeMod(ctx, cfg.n, new(big.Int).SetUint64(cfg.mod))
+	} else {
+		reference, err = fib.Iterative(ctx, cfg.n)
+	}
+	elapsed := time.Since(start).Round(time.Microsecond)
+	if err != nil {
+		return fmt.Errorf("iterative reference did not complete after %v: %w", elapsed, err)
+	}
+	slog.Info("verification reference computed", "method", "iterative O(n)", "duration", elapsed)
+
+	for _, r := range toCheck {
+		if r.Value.Cmp(reference) != 0 {
+			return fmt.Errorf("result of '%s' differs from the iterative reference", r.Name)
+		}
+	}
+	slog.Info("results match the iterative reference", "count", len(toCheck))
+	return nil
+}
+
+// ------------------------------------------------------------
+// GCD Identity Check (-check-gcd)
+// ------------------------------------------------------------
+//
+// The Fibonacci numbers form a strong divisibility sequence:
+// gcd(F(m), F(n)) = F(gcd(m, n)) for all m and n. `-check-gcd m` computes
+// F(m) and F(gcd(m, n)) and checks the identity against every F(n) result.
+// A wrong F(n) almost never keeps the right common factors with F(m), so the
+// check catches deep bugs at the cost of two more calculations, of indices
+// at most |m|, instead of the O(n) reference of `-verify`.
+
+// gcdTerms returns F(m), g = gcd(|m|, |n|) and F(g), the terms the identity
+// compares F(n) with, computed by Fast Doubling.
+func gcdTerms(ctx context.Context, m, n int) (fm *big.Int, g int, fg *big.Int, err error) {
+	if fm, err = fib.FastDoubling(ctx, m); err != nil {
+		return nil, 0, nil, err
+	}
+	g = gcdInt(abs(m), abs(n))
+	if fg, err = fib.FastDoubling(ctx, g); err != nil {
+		return nil, 0, nil, err
+	}
+	return fm, g, fg, nil
+}
+
+// gcdInt returns gcd(a, b) for a, b >= 0, with gcd(0, 0) = 0.
+func gcdInt(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// abs returns |n|. The caller rules out math.MinInt.
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// checkGCDResults checks the GCD identity for every successful F(n) result
+// and reports pass or fail on w, one line per result. It returns an error
+// on failure, or when F(m) and F(gcd(m, n)) could not complete within the
+// configured timeout.
+func checkGCDResults(w io.Writer, cfg config, results []Result) error {
+	var toCheck []Result
+	for _, r := range results {
+		if r.symbol == "F" && r.Err == nil && r.Value != nil {
+			toCheck = append(toCheck, r)
+		}
+	}
+	if len(toCheck) == 0 {
+		slog.Warn("-check-gcd: no successful F(n) result to check")
+		return nil
+	}
+
+	// The check gets its own budget, like the reference of -verify.
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.timeout)
+	defer cancel()
+
+	fm, g, fg, err := gcdTerms(ctx, cfg.checkGCD, cfg.n)
+	if err != nil {
+		return fmt.Errorf("F(%d) and F(gcd(%d, %d)) did not complete: %w", cfg.checkGCD, cfg.checkGCD, cfg.n, err)
+	}
+	var failed []string
+	lhs := new(big.Int)
+	for _, r := range toCheck {
+		status := "pass"
+		if lhs.GCD(nil, nil, fm, r.Value).Cmp(fg) != 0 {
+			status = "FAIL"
+			failed = append(failed, r.Name)
+		}
+		fmt.Fprintf(w, "GCD check (%s): gcd(F(%d), F(%d)) = F(gcd(%d, %d)) = F(%d): %s\n", r.Name, cfg.checkGCD, cfg.n, cfg.checkGCD, cfg.n, g, status)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("gcd(F(%d), F(%d)) differs from F(gcd(%d, %d)) for %s", cfg.checkGCD, cfg.n, cfg.checkGCD, cfg.n, strings.Join(failed, ", "))
+	}
+	slog.Info("results satisfy the GCD identity", "m", cfg.checkGCD, "count", len(toCheck))
+	return nil
+}
+
+// gcdReportWriter returns where checkGCDResults reports: stdout below the
+// results table, or stderr when stdout carries machine-readable output or
+// only the result with `-quiet`.
+func gcdReportWriter(cfg config) io.Writer {
+	if cfg.format == formatTable && !cfg.quiet {
+		return os.Stdout
+	}
+	return os.Stderr
+}
@@ -0,0 +1,79 @@
+// verify_test.go
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestVerifyResults checks that the iterative reference accepts correct
+// results, rejects wrong ones, and supports modular mode.
+func TestVerifyResults(t *testing.T) {
+	cfg := config{n: 100, timeout: time.Minute}
+	f100, _ := new(big.Int).SetString("354224848179261915075", 10)
+
+	correct := []Result{
+		{Name: "Fast Doubling", symbol: "F", Value: f100},
+		{Name: "Lucas", symbol: "L", Value: big.NewInt(1)}, // Other sequences are not checked
+		{Name: "Timed Out", symbol: "F", Err: context.DeadlineExceeded},
+	}
+	if err := verifyResults(cfg, correct); err != nil {
+		t.Errorf("unexpected verification failure: %v", err)
+	}
+
+	wrong := []Result{{Name: "Buggy", symbol: "F", Value: new(big.Int).Add(f100, big.NewInt(1))}}
+	if err := verifyResults(cfg, wrong); err == nil {
+		t.Error("expected a verification failure for a wrong result, but got none")
+	}
+
+	cfg.mod = 1000
+	if err := verifyResults(cfg, []Result{{Name: "Fast Doubling", symbol: "F", Value: big.NewInt(75)}}); err != nil {
+		t.Errorf("unexpected verification failure in modular mode: %v", err)
+	}
+
+	cfg = config{n: 10000000, timeout: time.Millisecond}
+	if err := verifyResults(cfg, []Result{{Name: "Fast Doubling", symbol: "F", Value: big.NewInt(1)}}); err == nil {
+		t.Error("expected an error when the reference times out, but got none")
+	}
+}
+
+// TestCheckGCDResults checks the identity gcd(F(m), F(n)) = F(gcd(m, n)) on
+// coprime and non-coprime pairs, negative indices included, and that it
+// rejects a wrong F(n).
+func TestCheckGCDResults(t *testing.T) {
+	for _, c := range []struct{ m, n, g int }{
+		{75, 100, 25}, {73, 100, 1}, {12, 18, 6}, {1000, 1500, 500}, {-35, 21, 7}, {14, -10, 2}, {7, 0, 7},
+	} {
+		fn, _ := fibMatrix(context.Background(), c.n, newIntPool())
+		cfg := config{n: c.n, checkGCD: c.m, timeout: time.Minute}
+		var out strings.Builder
+		if err := checkGCDResults(&out, cfg, []Result{{Name: "Matrix", symbol: "F", Value: fn}}); err != nil {
+			t.Errorf("m=%d, n=%d: unexpected failure: %v", c.m, c.n, err)
+		}
+		if want := fmt.Sprintf("= F(%d): pass\n", c.g); !strings.HasSuffix(out.String(), want) {
+			t.Errorf("m=%d, n=%d: expected a line ending with %q, got %q", c.m, c.n, want, out.String())
+		}
+	}
+
+	// F(100)+F(25) keeps the factor F(25) of gcd(F(150), F(100)) = F(50),
+	// but not the rest.
+	cfg := config{n: 100, checkGCD: 150, timeout: time.Minute}
+	f100, _ := fibMatrix(context.Background(), 100, newIntPool())
+	f25, _ := fibMatrix(context.Background(), 25, newIntPool())
+	wrong := []Result{
+		{Name: "Matrix", symbol: "F", Value: f100},
+		{Name: "Buggy", symbol: "F", Value: new(big.Int).Add(f100, f25)},
+	}
+	var out strings.Builder
+	if err := checkGCDResults(&out, cfg, wrong); err == nil || !strings.Contains(err.Error(), "Buggy") {
+		t.Errorf("expected a failure naming the wrong result, got %v", err)
+	}
+	if !strings.Contains(out.String(), "(Matrix)") || !strings.Contains(out.String(), "(Buggy): gcd(F(150), F(100)) = F(gcd(150, 100)) = F(50): FAIL") {
+		t.Errorf("expected a pass and a FAIL line, got:\n%s", out.String())
+	}
+}
@@ -0,0 +1,62 @@
+// word.go
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/agbruneau/FibJule/fib"
+)
+
+// ------------------------------------------------------------
+// Fibonacci Word Mode (-word)
+// ------------------------------------------------------------
+//
+// Concept:
+// The n-th Fibonacci word, S(n) = S(n−1)·S(n−2) from "0" and "01", has
+// F(n+2) symbols: about 10^20 bytes at n = 100. fib.WriteWord streams it
+// from a few built words instead of materializing it, so its length is only
+// bounded by the destination and the global timeout.
+
+// runWordMode runs the `-word` mode: it writes the Fibonacci word, followed
+// by a newline, to stdout or to the `-output` file when set.
+func runWordMode(cfg config) {
+	w := io.Writer(os.Stdout)
+	if cfg.output != "" {
+		f, err := os.Create(cfg.output)
+		if err != nil {
+			fatal("failed to create output file", "path", cfg.output, "error", err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.timeout)
+	defer cancel()
+	stopSignals := handleSignals(cancel)
+	defer stopSignals()
+
+	length, err := fib.FastDoubling(ctx, cfg.word+2)
+	if err != nil {
+		fatal("failed to compute the length of the word", "n", cfg.word, "error", err)
+	}
+	slog.Info("writing Fibonacci word", "n", cfg.word, "symbols", abbreviate(length.String()), "timeout", cfg.timeout)
+	bw := bufio.NewWriterSize(w, 1<<20)
+	err = fib.WriteWord(ctx, bw, cfg.word)
+	if err == nil {
+		err = bw.WriteByte('\n')
+	}
+	// Flush even on error, so the symbols written so far are kept.
+	if flushErr := bw.Flush(); err == nil {
+		err = flushErr
+	}
+	if err != nil {
+		slog.Error("word interrupted", "error", err)
+		return
+	}
+	slog.Info("word finished")
+}
@@ -0,0 +1,39 @@
+// word_test.go
+
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestWordMode writes a Fibonacci word longer than the words fib.WriteWord
+// builds in memory to an `-output` file, and checks its length, F(n+2)
+// symbols and a newline, and that the previous word is a prefix of it.
+func TestWordMode(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "word.txt")
+	cfg, err := parseConfig([]string{"-word", "27", "-output", path}, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	runWordMode(cfg)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	length, _ := fibFastDoubling(context.Background(), 29, newIntPool())
+	if int64(len(data)) != length.Int64()+1 || data[len(data)-1] != '\n' {
+		t.Fatalf("expected F(29) = %s symbols and a newline, got %d bytes", length, len(data))
+	}
+	out := captureStdout(t, func() {
+		cfg.word, cfg.output = 26, ""
+		runWordMode(cfg)
+	})
+	if !strings.HasPrefix(string(data), strings.TrimSuffix(out, "\n")) {
+		t.Error("S(26) written to stdout is not a prefix of S(27)")
+	}
+}